@@ -22,6 +22,47 @@ type Config struct {
 	JWTSecret     string
 	JWTExpiration int
 
+	CardKeysDir          string
+	CardHMACSecret       string
+	CardKeyRotationBatch int
+
+	BankKeysDir string
+
+	ISO8583Enabled bool
+	ISO8583Addr    string
+
+	CNPChallengeThreshold float64
+	OTPTTL                time.Duration
+	OTPNotifier           string
+	OTPNotifierFile       string
+
+	ScoreApproveThreshold int
+	ScoreDeclineThreshold int
+	ScoringStressRate     float64
+
+	PenaltyRate          float64
+	OverdueCheckInterval time.Duration
+	KeyRateCheckInterval time.Duration
+
+	StatementCheckInterval time.Duration
+
+	BureauMemberCode     string
+	BureauExportInterval time.Duration
+
+	CreditBureauClient   string
+	CreditBureauURL      string
+	CreditBureauToken    string
+	CreditBureauTimeout  time.Duration
+	CreditBureauCacheTTL time.Duration
+
+	DocumentFontPath string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	EmailFrom    string
+
 	ServerPort int
 	ServerHost string
 
@@ -53,6 +94,47 @@ func Init() error {
 		JWTSecret:     getEnv("JWT_SECRET", "your-secret-key"),
 		JWTExpiration: getEnvAsInt("JWT_EXPIRATION", 24),
 
+		CardKeysDir:          getEnv("CARD_KEYS_DIR", "keys"),
+		CardHMACSecret:       getEnv("CARD_HMAC_SECRET", ""),
+		CardKeyRotationBatch: getEnvAsInt("CARD_KEY_ROTATION_BATCH", 100),
+
+		BankKeysDir: getEnv("BANK_KEYS_DIR", "bank_keys"),
+
+		ISO8583Enabled: getEnvAsBool("ISO8583_ENABLED", false),
+		ISO8583Addr:    getEnv("ISO8583_ADDR", "localhost:8583"),
+
+		CNPChallengeThreshold: getEnvAsFloat("CNP_CHALLENGE_THRESHOLD", 3000),
+		OTPTTL:                getEnvAsDuration("OTP_TTL", 5*time.Minute),
+		OTPNotifier:           getEnv("OTP_NOTIFIER", "log"),
+		OTPNotifierFile:       getEnv("OTP_NOTIFIER_FILE", "otp.log"),
+
+		ScoreApproveThreshold: getEnvAsInt("SCORE_APPROVE_THRESHOLD", 750),
+		ScoreDeclineThreshold: getEnvAsInt("SCORE_DECLINE_THRESHOLD", 450),
+		ScoringStressRate:     getEnvAsFloat("SCORING_STRESS_RATE", 25),
+
+		PenaltyRate:          getEnvAsFloat("PENALTY_RATE", 20),
+		OverdueCheckInterval: getEnvAsDuration("OVERDUE_CHECK_INTERVAL", 24*time.Hour),
+		KeyRateCheckInterval: getEnvAsDuration("KEY_RATE_CHECK_INTERVAL", 24*time.Hour),
+
+		StatementCheckInterval: getEnvAsDuration("STATEMENT_CHECK_INTERVAL", 24*time.Hour),
+
+		BureauMemberCode:     getEnv("BUREAU_MEMBER_CODE", "FINGO0001"),
+		BureauExportInterval: getEnvAsDuration("BUREAU_EXPORT_INTERVAL", 24*time.Hour),
+
+		CreditBureauClient:   getEnv("CREDIT_BUREAU_CLIENT", "stub"),
+		CreditBureauURL:      getEnv("CREDIT_BUREAU_URL", ""),
+		CreditBureauToken:    getEnv("CREDIT_BUREAU_TOKEN", ""),
+		CreditBureauTimeout:  getEnvAsDuration("CREDIT_BUREAU_TIMEOUT", 10*time.Second),
+		CreditBureauCacheTTL: getEnvAsDuration("CREDIT_BUREAU_CACHE_TTL", 24*time.Hour),
+
+		DocumentFontPath: getEnv("DOCUMENT_FONT_PATH", "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		EmailFrom:    getEnv("EMAIL_FROM", ""),
+
 		ServerPort: getEnvAsInt("SERVER_PORT", 8080),
 		ServerHost: getEnv("SERVER_HOST", "localhost"),
 
@@ -71,6 +153,11 @@ func Get() *Config {
 	return cfg
 }
 
+// IsProduction сообщает, запущено ли приложение в production-окружении
+func (c *Config) IsProduction() bool {
+	return c.AppEnv == "production"
+}
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -87,6 +174,14 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value, exists := os.LookupEnv(key); exists {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
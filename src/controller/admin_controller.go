@@ -20,7 +20,10 @@ func CreateAdminController(scheduler *service.Scheduler) *AdminController {
 // CheckPayments запускает проверку платежей вручную
 func (c *AdminController) CheckPayments(ctx *gin.Context) {
 	// Запускаем проверку платежей
-	c.scheduler.CheckPayments()
+	if err := c.scheduler.CheckPayments(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Возвращаем результат
 	ctx.JSON(http.StatusOK, gin.H{
@@ -29,6 +32,34 @@ func (c *AdminController) CheckPayments(ctx *gin.Context) {
 	})
 }
 
+// CheckKeyRate запускает пересмотр плавающих ставок по текущей ключевой ставке вручную
+func (c *AdminController) CheckKeyRate(ctx *gin.Context) {
+	if err := c.scheduler.CheckKeyRate(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Проверка ключевой ставки выполнена",
+		"status":  "success",
+	})
+}
+
+// ProcessStatements запускает формирование выписок и начисление процентов по кредитным линиям вручную
+func (c *AdminController) ProcessStatements(ctx *gin.Context) {
+	statements, err := c.scheduler.ProcessStatements()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":    "Выписки по кредитным линиям обработаны",
+		"status":     "success",
+		"statements": statements,
+	})
+}
+
 // GetAllCredits возвращает список всех кредитов
 func (c *AdminController) GetAllCredits(ctx *gin.Context) {
 	credits, err := c.scheduler.GetAllCredits()
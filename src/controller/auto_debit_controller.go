@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"FinanceGolang/src/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AutoDebitController struct {
+	autoDebitService service.AutoDebitService
+}
+
+func CreateAutoDebitController(autoDebitService service.AutoDebitService) *AutoDebitController {
+	return &AutoDebitController{autoDebitService: autoDebitService}
+}
+
+// AuthorizeAutoDebitRequest резервный счет для списания платежей по кредиту.
+// Счета используются по возрастанию приоритета, по умолчанию приоритет 1.
+type AuthorizeAutoDebitRequest struct {
+	AccountID uint `json:"account_id" binding:"required"`
+	Priority  int  `json:"priority" binding:"gte=0"`
+}
+
+// GetAccounts возвращает резервные счета кредита
+func (c *AutoDebitController) GetAccounts(ctx *gin.Context) {
+	userID, creditID, ok := c.bindCredit(ctx)
+	if !ok {
+		return
+	}
+
+	accounts, err := c.autoDebitService.GetAccounts(userID, creditID)
+	if err != nil {
+		ctx.JSON(autoDebitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"accounts": accounts})
+}
+
+// AuthorizeAccount разрешает списывать платежи по кредиту с другого счета
+func (c *AutoDebitController) AuthorizeAccount(ctx *gin.Context) {
+	userID, creditID, ok := c.bindCredit(ctx)
+	if !ok {
+		return
+	}
+
+	var req AuthorizeAutoDebitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Priority == 0 {
+		req.Priority = 1
+	}
+
+	account, err := c.autoDebitService.AuthorizeAccount(userID, creditID, req.AccountID, req.Priority)
+	if err != nil {
+		ctx.JSON(autoDebitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "auto debit account authorized", "account": account})
+}
+
+// RevokeAccount отзывает разрешение на списание с резервного счета
+func (c *AutoDebitController) RevokeAccount(ctx *gin.Context) {
+	userID, creditID, ok := c.bindCredit(ctx)
+	if !ok {
+		return
+	}
+
+	authorizationID, err := strconv.ParseUint(ctx.Param("authorizationId"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid auto debit account id"})
+		return
+	}
+
+	if err := c.autoDebitService.RevokeAccount(userID, creditID, uint(authorizationID)); err != nil {
+		ctx.JSON(autoDebitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "auto debit account revoked"})
+}
+
+// GetAttempts возвращает попытки списания платежей по кредиту
+func (c *AutoDebitController) GetAttempts(ctx *gin.Context) {
+	userID, creditID, ok := c.bindCredit(ctx)
+	if !ok {
+		return
+	}
+
+	attempts, err := c.autoDebitService.GetAttempts(userID, creditID)
+	if err != nil {
+		ctx.JSON(autoDebitErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"attempts": attempts})
+}
+
+// bindCredit получает пользователя и кредит из запроса
+func (c *AutoDebitController) bindCredit(ctx *gin.Context) (uint, uint, bool) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return 0, 0, false
+	}
+
+	creditID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit id"})
+		return 0, 0, false
+	}
+
+	return userID.(uint), uint(creditID), true
+}
+
+// autoDebitErrorStatus возвращает HTTP-статус для ошибки резервных счетов
+func autoDebitErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrCreditNotOwned), errors.Is(err, service.ErrAccountNotOwned):
+		return http.StatusForbidden
+	case errors.Is(err, model.ErrCreditNotActive), errors.Is(err, model.ErrAutoDebitAccountExists):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrInvalidAutoDebitAccount):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -2,22 +2,86 @@ package controller
 
 import (
 	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
 	"FinanceGolang/src/service"
 
-	// "FinanceGolang/src/repository"
 	// "FinanceGolang/src/database"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type CardController struct {
-	cardService service.CardService
+	cardService           service.CardService
+	accountService        service.AccountService
+	cardNotPresentService service.CardNotPresentService
 }
 
-func CreateCardController(cardService service.CardService) *CardController {
-	return &CardController{cardService: cardService}
+func CreateCardController(
+	cardService service.CardService,
+	accountService service.AccountService,
+	cardNotPresentService service.CardNotPresentService,
+) *CardController {
+	return &CardController{
+		cardService:           cardService,
+		accountService:        accountService,
+		cardNotPresentService: cardNotPresentService,
+	}
+}
+
+type CreateCardRequest struct {
+	AccountID   uint   `json:"account_id"`
+	ProductCode string `json:"product_code"`
+}
+
+type CreateVirtualCardRequest struct {
+	AccountID   uint    `json:"account_id" binding:"required"`
+	ProductCode string  `json:"product_code"`
+	SingleUse   bool    `json:"single_use"`
+	AmountCap   float64 `json:"amount_cap" binding:"gte=0"`
+	ExpiryDays  int     `json:"expiry_days" binding:"gte=0"`
+}
+
+type CardTransferRequest struct {
+	FromAccountID uint    `json:"from_account_id" binding:"required"`
+	ToCardNumber  string  `json:"to_card_number" binding:"required"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	Description   string  `json:"description"`
+}
+
+type CardPaymentRequest struct {
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	MCC         string  `json:"mcc" binding:"required"`
+	Channel     string  `json:"channel" binding:"required"`
+	Country     string  `json:"country" binding:"required"`
+	Merchant    string  `json:"merchant"`
+	Description string  `json:"description"`
+}
+
+type CardControlsRequest struct {
+	AllowOnline      *bool    `json:"allow_online"`
+	AllowContactless *bool    `json:"allow_contactless"`
+	AllowCash        *bool    `json:"allow_cash"`
+	AllowForeign     *bool    `json:"allow_foreign"`
+	BlockedMCCs      []string `json:"blocked_mccs"`
+}
+
+type CardNotPresentPaymentRequest struct {
+	PAN         string  `json:"pan" binding:"required"`
+	ExpiryDate  string  `json:"expiry_date" binding:"required"`
+	CVV         string  `json:"cvv" binding:"required"`
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	MCC         string  `json:"mcc" binding:"required"`
+	Country     string  `json:"country" binding:"required"`
+	Merchant    string  `json:"merchant"`
+	Description string  `json:"description"`
+}
+
+type ConfirmPaymentRequest struct {
+	OTP string `json:"otp" binding:"required"`
 }
 
 func (cc *CardController) CreateCard(c *gin.Context) {
@@ -27,8 +91,8 @@ func (cc *CardController) CreateCard(c *gin.Context) {
 		return
 	}
 
-	var card model.Card
-	if err := c.ShouldBindJSON(&card); err != nil {
+	var req CreateCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
 			"message": "invalid request body",
@@ -36,7 +100,7 @@ func (cc *CardController) CreateCard(c *gin.Context) {
 		return
 	}
 
-	if card.AccountID == 0 {
+	if req.AccountID == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
 			"message": "account_id is required",
@@ -44,11 +108,15 @@ func (cc *CardController) CreateCard(c *gin.Context) {
 		return
 	}
 
-	unsecureCard, err := cc.cardService.CreateCard(&card, userID.(uint))
+	card := model.Card{AccountID: req.AccountID}
+	unsecureCard, err := cc.cardService.CreateCard(&card, req.ProductCode, userID.(uint))
 	if err != nil {
 		status := http.StatusInternalServerError
 		if err.Error() == "account does not belong to the user" {
 			status = http.StatusForbidden
+		} else if errors.Is(err, model.ErrCardProductNotFound) || errors.Is(err, model.ErrCardProductInactive) ||
+			errors.Is(err, model.ErrInvalidAccountType) {
+			status = http.StatusBadRequest
 		}
 		c.JSON(status, gin.H{
 			"status":  "error",
@@ -63,6 +131,50 @@ func (cc *CardController) CreateCard(c *gin.Context) {
 	})
 }
 
+// CreateVirtualCard выпускает виртуальную (в т.ч. одноразовую) карту
+func (cc *CardController) CreateVirtualCard(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+
+	var req CreateVirtualCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	unsecureCard, err := cc.cardService.CreateVirtualCard(
+		userID.(uint),
+		req.AccountID,
+		req.ProductCode,
+		req.SingleUse,
+		req.AmountCap,
+		req.ExpiryDays,
+	)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "account does not belong to the user" {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "virtual card created successfully",
+		"card":    unsecureCard,
+	})
+}
+
 func (cc *CardController) GetCardByID(router *gin.Context) {
 
 }
@@ -104,3 +216,304 @@ func (cc *CardController) GetAllCards(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// GetCardProducts возвращает каталог карточных продуктов
+func (cc *CardController) GetCardProducts(c *gin.Context) {
+	products, err := cc.cardService.GetCardProducts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"products": products,
+	})
+}
+
+// TransferToCard переводит средства со счета пользователя на счет, привязанный к карте
+func (cc *CardController) TransferToCard(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+
+	var req CardTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	fromAccount, err := cc.accountService.GetAccountByID(req.FromAccountID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "account not found",
+		})
+		return
+	}
+	if fromAccount.UserID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "account does not belong to the user",
+		})
+		return
+	}
+
+	card, err := cc.cardService.GetCardByNumber(req.ToCardNumber)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "card not found",
+		})
+		return
+	}
+	if !card.IsActive {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "card is not active",
+		})
+		return
+	}
+
+	if err := cc.accountService.Transfer(req.FromAccountID, card.AccountID, req.Amount, req.Description); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "transfer successful",
+	})
+}
+
+// PayByCard проводит оплату картой со списанием со связанного счета
+func (cc *CardController) PayByCard(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid card id"})
+		return
+	}
+
+	var req CardPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	payment := &model.CardPayment{
+		Amount:      req.Amount,
+		MCC:         req.MCC,
+		Channel:     model.CardChannel(req.Channel),
+		Country:     req.Country,
+		Merchant:    req.Merchant,
+		Description: req.Description,
+	}
+
+	transaction, reason, err := cc.cardService.ProcessCardPayment(userID.(uint), uint(cardID), payment)
+	if err != nil {
+		c.JSON(cardErrorStatus(err), gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if reason != model.DeclineNone {
+		c.JSON(http.StatusPaymentRequired, gin.H{
+			"status":      "declined",
+			"message":     "payment declined",
+			"reason_code": reason,
+			"transaction": transaction.ToDTO(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":      "success",
+		"message":     "payment successful",
+		"transaction": transaction.ToDTO(),
+	})
+}
+
+// UpdateCardControls меняет ограничения операций по карте
+func (cc *CardController) UpdateCardControls(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+
+	cardID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid card id"})
+		return
+	}
+
+	var req CardControlsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	card, err := cc.cardService.UpdateControls(userID.(uint), uint(cardID), &model.CardControls{
+		AllowOnline:      req.AllowOnline,
+		AllowContactless: req.AllowContactless,
+		AllowCash:        req.AllowCash,
+		AllowForeign:     req.AllowForeign,
+		BlockedMCCs:      req.BlockedMCCs,
+	})
+	if err != nil {
+		c.JSON(cardErrorStatus(err), gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"card":   card.ToDTO(),
+	})
+}
+
+// PayCardNotPresent проводит онлайн-платеж по реквизитам карты.
+// Для сумм выше порога возвращает 202 и ID транзакции, ожидающей подтверждения кодом.
+func (cc *CardController) PayCardNotPresent(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+
+	var req CardNotPresentPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	result, err := cc.cardNotPresentService.Pay(userID.(uint), &service.CardNotPresentPayment{
+		PAN:        req.PAN,
+		ExpiryDate: req.ExpiryDate,
+		CVV:        req.CVV,
+		Payment: model.CardPayment{
+			Amount:      req.Amount,
+			MCC:         req.MCC,
+			Country:     req.Country,
+			Merchant:    req.Merchant,
+			Description: req.Description,
+		},
+	})
+	if err != nil {
+		c.JSON(cardErrorStatus(err), gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	cc.respondCardNotPresent(c, result)
+}
+
+// ConfirmCardNotPresent подтверждает онлайн-платеж одноразовым кодом
+func (cc *CardController) ConfirmCardNotPresent(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "user not found"})
+		return
+	}
+
+	transactionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "invalid transaction id"})
+		return
+	}
+
+	var req ConfirmPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	result, err := cc.cardNotPresentService.Confirm(userID.(uint), uint(transactionID), req.OTP)
+	if err != nil {
+		c.JSON(cardErrorStatus(err), gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	cc.respondCardNotPresent(c, result)
+}
+
+// respondCardNotPresent отвечает результатом онлайн-платежа
+func (cc *CardController) respondCardNotPresent(c *gin.Context, result *service.CardNotPresentResult) {
+	response := gin.H{}
+	if result.Transaction != nil {
+		response["transaction_id"] = result.Transaction.ID
+		response["transaction"] = result.Transaction.ToDTO()
+	}
+
+	switch {
+	case result.DeclineReason != model.DeclineNone:
+		response["status"] = "declined"
+		response["message"] = "payment declined"
+		response["reason_code"] = result.DeclineReason
+		c.JSON(http.StatusPaymentRequired, response)
+	case result.ChallengeRequired:
+		response["status"] = "challenge_required"
+		response["message"] = "confirmation code sent"
+		response["expires_at"] = result.ChallengeExpiresAt
+		c.JSON(http.StatusAccepted, response)
+	default:
+		response["status"] = "success"
+		response["message"] = "payment successful"
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// cardErrorStatus подбирает HTTP-статус для ошибки операции с картой
+func cardErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, service.ErrChallengeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, model.ErrChallengeNotPending):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrCardNotOwned):
+		return http.StatusForbidden
+	case errors.Is(err, model.ErrInvalidAmount), errors.Is(err, model.ErrInvalidMCC),
+		errors.Is(err, model.ErrInvalidChannel), errors.Is(err, model.ErrInvalidCountry),
+		errors.Is(err, model.ErrInvalidOTP):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
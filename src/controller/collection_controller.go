@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"FinanceGolang/src/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CollectionController struct {
+	collectionService service.CollectionService
+}
+
+func CreateCollectionController(collectionService service.CollectionService) *CollectionController {
+	return &CollectionController{collectionService: collectionService}
+}
+
+// LogContactRequest попытка связаться с заемщиком
+type LogContactRequest struct {
+	Channel string `json:"channel" binding:"required,oneof=PHONE SMS EMAIL VISIT"`
+	Result  string `json:"result" binding:"required,oneof=REACHED NO_ANSWER WRONG_NUMBER REFUSED_TO_PAY"`
+	Comment string `json:"comment"`
+}
+
+// PromiseToPayRequest обещание заемщика внести сумму к дате в формате YYYY-MM-DD
+type PromiseToPayRequest struct {
+	Amount  float64 `json:"amount" binding:"required,gt=0"`
+	Date    string  `json:"date" binding:"required"`
+	Comment string  `json:"comment"`
+}
+
+// GetQueue возвращает очередь взыскания, отбор `?stage=`, `?bucket=`, `?call_tasks=true`
+func (c *CollectionController) GetQueue(ctx *gin.Context) {
+	queue, err := c.collectionService.GetQueue(service.CollectionQueueFilter{
+		Stage:     model.CollectionStage(ctx.Query("stage")),
+		Bucket:    model.CollectionBucket(ctx.Query("bucket")),
+		CallTasks: ctx.Query("call_tasks") == "true",
+	})
+	if err != nil {
+		ctx.JSON(collectionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, queue)
+}
+
+func (c *CollectionController) GetCase(ctx *gin.Context) {
+	caseID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection case id"})
+		return
+	}
+
+	details, err := c.collectionService.GetCase(uint(caseID))
+	if err != nil {
+		ctx.JSON(collectionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, details)
+}
+
+// LogContact записывает попытку связаться с заемщиком
+func (c *CollectionController) LogContact(ctx *gin.Context) {
+	operatorID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	caseID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection case id"})
+		return
+	}
+
+	var req LogContactRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact, err := c.collectionService.LogContact(operatorID.(uint), uint(caseID), &model.CollectionActivity{
+		Channel: model.ContactChannel(req.Channel),
+		Result:  model.ContactResult(req.Result),
+		Comment: req.Comment,
+	})
+	if err != nil {
+		ctx.JSON(collectionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "contact attempt logged", "activity": contact})
+}
+
+// AddPromise записывает обещание оплаты
+func (c *CollectionController) AddPromise(ctx *gin.Context) {
+	operatorID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	caseID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection case id"})
+		return
+	}
+
+	var req PromiseToPayRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid date, expected YYYY-MM-DD"})
+		return
+	}
+
+	promise, err := c.collectionService.AddPromise(operatorID.(uint), uint(caseID), &model.CollectionActivity{
+		PromisedAmount: req.Amount,
+		PromisedDate:   &date,
+		Comment:        req.Comment,
+	})
+	if err != nil {
+		ctx.JSON(collectionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "promise to pay logged", "activity": promise})
+}
+
+// collectionErrorStatus возвращает HTTP-статус для ошибки взыскания
+func collectionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, model.ErrCollectionCaseClosed), errors.Is(err, model.ErrPromiseToPayPending):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrInvalidContactAttempt), errors.Is(err, model.ErrInvalidPromiseToPay),
+		errors.Is(err, model.ErrInvalidCollectionFilter):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,285 @@
+package controller
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"FinanceGolang/src/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreditApplicationController struct {
+	applicationService service.CreditApplicationService
+}
+
+func CreateCreditApplicationController(applicationService service.CreditApplicationService) *CreditApplicationController {
+	return &CreditApplicationController{applicationService: applicationService}
+}
+
+type ReviewCreditApplicationRequest struct {
+	Comment string `json:"comment"`
+}
+
+// AddCollateralRequest предмет залога по заявке. Дата оценки в формате YYYY-MM-DD.
+type AddCollateralRequest struct {
+	Type           string                     `json:"type" binding:"required,oneof=VEHICLE REAL_ESTATE"`
+	Description    string                     `json:"description" binding:"required"`
+	AppraisedValue float64                    `json:"appraised_value" binding:"required,gt=0"`
+	AppraisalDate  string                     `json:"appraisal_date" binding:"required"`
+	Documents      []model.CollateralDocument `json:"documents"`
+}
+
+// AddGuarantorRequest поручитель по заявке. Без liability_amount поручитель отвечает в полном объеме долга.
+type AddGuarantorRequest struct {
+	UserID          uint    `json:"user_id" binding:"required"`
+	LiabilityAmount float64 `json:"liability_amount" binding:"gte=0"`
+}
+
+// SubmitApplication принимает заявку на кредит. Деньги зачисляются только после одобрения.
+func (c *CreditApplicationController) SubmitApplication(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	var req CreateCreditRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	application, err := c.applicationService.Submit(userID.(uint), service.CreditApplicationRequest{
+		AccountID:     req.AccountID,
+		ProductCode:   req.ProductCode,
+		Amount:        req.Amount,
+		TermMonths:    req.TermMonths,
+		RepaymentType: model.RepaymentType(req.RepaymentType),
+		Description:   req.Description,
+	})
+	if err != nil {
+		ctx.JSON(creditApplicationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message":     "credit application submitted",
+		"application": application,
+	})
+}
+
+func (c *CreditApplicationController) GetUserApplications(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	applications, err := c.applicationService.GetUserApplications(userID.(uint))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"applications": applications})
+}
+
+func (c *CreditApplicationController) GetUserApplication(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	applicationID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid application id"})
+		return
+	}
+
+	details, err := c.applicationService.GetUserApplication(userID.(uint), uint(applicationID))
+	if err != nil {
+		ctx.JSON(creditApplicationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, details)
+}
+
+// ListApplications возвращает заявки для рассмотрения, фильтр по статусу задается параметром status
+func (c *CreditApplicationController) ListApplications(ctx *gin.Context) {
+	applications, err := c.applicationService.ListApplications(model.CreditApplicationStatus(ctx.Query("status")))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"applications": applications})
+}
+
+func (c *CreditApplicationController) GetApplication(ctx *gin.Context) {
+	applicationID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid application id"})
+		return
+	}
+
+	details, err := c.applicationService.GetApplication(uint(applicationID))
+	if err != nil {
+		ctx.JSON(creditApplicationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, details)
+}
+
+func (c *CreditApplicationController) ApproveApplication(ctx *gin.Context) {
+	reviewerID, applicationID, req, ok := c.bindReview(ctx)
+	if !ok {
+		return
+	}
+
+	application, credit, err := c.applicationService.Approve(reviewerID, applicationID, req.Comment)
+	if err != nil {
+		ctx.JSON(creditApplicationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "credit application approved",
+		"application": application,
+		"credit":      credit.ToDTO(),
+	})
+}
+
+func (c *CreditApplicationController) RejectApplication(ctx *gin.Context) {
+	reviewerID, applicationID, req, ok := c.bindReview(ctx)
+	if !ok {
+		return
+	}
+
+	application, err := c.applicationService.Reject(reviewerID, applicationID, req.Comment)
+	if err != nil {
+		ctx.JSON(creditApplicationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "credit application rejected",
+		"application": application,
+	})
+}
+
+// bindReview разбирает ID заявки и комментарий сотрудника
+func (c *CreditApplicationController) bindReview(ctx *gin.Context) (uint, uint, ReviewCreditApplicationRequest, bool) {
+	var req ReviewCreditApplicationRequest
+
+	reviewerID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return 0, 0, req, false
+	}
+
+	applicationID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid application id"})
+		return 0, 0, req, false
+	}
+
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return 0, 0, req, false
+		}
+	}
+
+	return reviewerID.(uint), uint(applicationID), req, true
+}
+
+// creditApplicationErrorStatus возвращает HTTP-статус для ошибки операции с заявкой
+// AddCollateral регистрирует предмет залога по заявке
+func (c *CreditApplicationController) AddCollateral(ctx *gin.Context) {
+	applicationID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid application id"})
+		return
+	}
+
+	var req AddCollateralRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	appraisalDate, err := time.Parse("2006-01-02", req.AppraisalDate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid appraisal date, expected YYYY-MM-DD"})
+		return
+	}
+
+	collateral, err := c.applicationService.AddCollateral(uint(applicationID), &model.Collateral{
+		Type:           model.CollateralType(req.Type),
+		Description:    req.Description,
+		AppraisedValue: req.AppraisedValue,
+		AppraisalDate:  appraisalDate,
+		Documents:      req.Documents,
+	})
+	if err != nil {
+		ctx.JSON(creditApplicationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "collateral registered", "collateral": collateral})
+}
+
+// AddGuarantor регистрирует поручителя по заявке
+func (c *CreditApplicationController) AddGuarantor(ctx *gin.Context) {
+	applicationID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid application id"})
+		return
+	}
+
+	var req AddGuarantorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	guarantor, err := c.applicationService.AddGuarantor(uint(applicationID), &model.Guarantor{
+		UserID:          req.UserID,
+		LiabilityAmount: req.LiabilityAmount,
+	})
+	if err != nil {
+		ctx.JSON(creditApplicationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "guarantor registered", "guarantor": guarantor})
+}
+
+func creditApplicationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrApplicationNotOwned), errors.Is(err, service.ErrAccountNotOwned):
+		return http.StatusForbidden
+	case errors.Is(err, model.ErrApplicationNotPending):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrReviewCommentRequired), errors.Is(err, model.ErrInvalidCreditAmount),
+		errors.Is(err, model.ErrInvalidTerm), errors.Is(err, model.ErrInvalidRepaymentType),
+		errors.Is(err, model.ErrCreditProductNotFound), errors.Is(err, model.ErrCreditProductInactive),
+		errors.Is(err, model.ErrAmountOutOfProduct), errors.Is(err, model.ErrTermOutOfProduct),
+		errors.Is(err, model.ErrRepaymentTypeNotAllowed), errors.Is(err, model.ErrInvalidCollateral),
+		errors.Is(err, model.ErrInvalidCollateralType), errors.Is(err, model.ErrInvalidGuarantor):
+		return http.StatusBadRequest
+	case errors.Is(err, model.ErrCollateralRequired), errors.Is(err, model.ErrCollateralAppraisalExpired),
+		errors.Is(err, model.ErrLoanToValueExceeded):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
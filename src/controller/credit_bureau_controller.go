@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"FinanceGolang/src/repository"
+	"FinanceGolang/src/security"
+	"FinanceGolang/src/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultBureauExportsLimit число выгрузок в списке по умолчанию
+const defaultBureauExportsLimit = 50
+
+type CreditBureauController struct {
+	bureauService service.CreditBureauService
+}
+
+func CreateCreditBureauController(bureauService service.CreditBureauService) *CreditBureauController {
+	return &CreditBureauController{bureauService: bureauService}
+}
+
+// ListExports возвращает выгрузки кредитной истории, начиная с последней, `?offset=`, `?limit=`
+func (c *CreditBureauController) ListExports(ctx *gin.Context) {
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultBureauExportsLimit)))
+	if err != nil || limit <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	exports, err := c.bureauService.ListExports(offset, limit)
+	if err != nil {
+		ctx.JSON(creditBureauErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"exports": exports})
+}
+
+// GenerateExport формирует выгрузку с конца предыдущей выгрузки вручную
+func (c *CreditBureauController) GenerateExport(ctx *gin.Context) {
+	export, err := c.bureauService.GenerateExport(time.Now())
+	if err != nil {
+		ctx.JSON(creditBureauErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "credit bureau export generated", "export": export})
+}
+
+// DownloadFile отдает файл выгрузки
+func (c *CreditBureauController) DownloadFile(ctx *gin.Context) {
+	exportID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid export id"})
+		return
+	}
+
+	export, err := c.bureauService.GetExport(uint(exportID))
+	if err != nil {
+		ctx.JSON(creditBureauErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName))
+	ctx.Header("X-Signature-Key-Id", export.KeyID)
+	ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(export.Content))
+}
+
+// DownloadSignature отдает отсоединенную PGP-подпись файла выгрузки
+func (c *CreditBureauController) DownloadSignature(ctx *gin.Context) {
+	exportID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid export id"})
+		return
+	}
+
+	export, err := c.bureauService.GetExport(uint(exportID))
+	if err != nil {
+		ctx.JSON(creditBureauErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.SignatureFileName()))
+	ctx.Header("X-Signature-Key-Id", export.KeyID)
+	ctx.Data(http.StatusOK, "application/pgp-signature", []byte(export.Signature))
+}
+
+// GetPublicKey отдает открытый ключ банка для проверки подписи, `?key_id=` — версия ключа
+func (c *CreditBureauController) GetPublicKey(ctx *gin.Context) {
+	publicKey, err := c.bureauService.GetPublicKey(ctx.Query("key_id"))
+	if err != nil {
+		ctx.JSON(creditBureauErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/pgp-keys", []byte(publicKey))
+}
+
+// creditBureauErrorStatus возвращает HTTP-статус для ошибки выгрузки кредитной истории
+func creditBureauErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, security.ErrUnknownKeyID):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,7 +1,10 @@
 package controller
 
 import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
 	"FinanceGolang/src/service"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,18 +13,29 @@ import (
 )
 
 type CreditController struct {
-	creditService service.CreditService
+	creditService  service.CreditService
+	productService service.CreditProductService
 }
 
-func CreateCreditController(creditService service.CreditService) *CreditController {
-	return &CreditController{creditService: creditService}
+func CreateCreditController(creditService service.CreditService, productService service.CreditProductService) *CreditController {
+	return &CreditController{creditService: creditService, productService: productService}
 }
 
 type CreateCreditRequest struct {
-	AccountID   uint    `json:"account_id" binding:"required"`
-	Amount      float64 `json:"amount" binding:"required,gt=0"`
-	TermMonths  int     `json:"term_months" binding:"required,gt=0"`
-	Description string  `json:"description"`
+	AccountID     uint    `json:"account_id" binding:"required"`
+	ProductCode   string  `json:"product_code"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	TermMonths    int     `json:"term_months" binding:"required,gt=0"`
+	RepaymentType string  `json:"repayment_type" binding:"omitempty,oneof=ANNUITY DIFFERENTIATED"`
+	Description   string  `json:"description"`
+}
+
+// CreditQuoteRequest условия, по которым рассчитывается кредит без оформления заявки
+type CreditQuoteRequest struct {
+	ProductCode   string  `json:"product_code"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	TermMonths    int     `json:"term_months" binding:"required,gt=0"`
+	RepaymentType string  `json:"repayment_type" binding:"omitempty,oneof=ANNUITY DIFFERENTIATED"`
 }
 
 type CreditResponse struct {
@@ -32,66 +46,98 @@ type CreditResponse struct {
 	InterestRate   float64   `json:"interest_rate"`
 	TermMonths     int       `json:"term_months"`
 	MonthlyPayment float64   `json:"monthly_payment"`
+	NextPayment    time.Time `json:"next_payment"`
+	RepaymentType  string    `json:"repayment_type"`
 	Status         string    `json:"status"`
 	StartDate      time.Time `json:"start_date"`
 	EndDate        time.Time `json:"end_date"`
 	Description    string    `json:"description"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Полная стоимость кредита: в процентах годовых и в денежном выражении,
+	// включая комиссию за выдачу и страховую премию
+	FullCostRate     float64 `json:"full_cost_rate"`
+	FullCostAmount   float64 `json:"full_cost_amount"`
+	IssueFee         float64 `json:"issue_fee"`
+	InsurancePremium float64 `json:"insurance_premium"`
+
+	// Просроченная задолженность с неустойкой и число дней просрочки
+	OverdueAmount float64 `json:"overdue_amount"`
+	PenaltyDebt   float64 `json:"penalty_debt"`
+	DaysPastDue   int     `json:"days_past_due"`
+
+	// Отношение суммы кредита к стоимости залога на дату одобрения, %
+	LoanToValue float64 `json:"loan_to_value,omitempty"`
+
+	// Сравнение переплаты при аннуитетном и дифференцированном погашении
+	RepaymentComparison []model.RepaymentComparison `json:"repayment_comparison,omitempty"`
+}
+
+// newCreditResponse заполняет ответ по кредиту
+func newCreditResponse(credit *model.Credit) CreditResponse {
+	return CreditResponse{
+		ID:             credit.ID,
+		UserID:         credit.UserID,
+		AccountID:      credit.AccountID,
+		Amount:         credit.Amount,
+		InterestRate:   credit.InterestRate,
+		TermMonths:     credit.Term,
+		MonthlyPayment: credit.MonthlyPayment,
+		NextPayment:    credit.NextPayment,
+		RepaymentType:  string(credit.RepaymentType),
+		Status:         string(credit.Status),
+		StartDate:      credit.StartDate,
+		EndDate:        credit.EndDate,
+		CreatedAt:      credit.CreatedAt,
+		UpdatedAt:      credit.UpdatedAt,
+
+		FullCostRate:     credit.FullCostRate,
+		FullCostAmount:   credit.FullCostAmount,
+		IssueFee:         credit.IssueFee,
+		InsurancePremium: credit.InsurancePremium,
+
+		OverdueAmount: credit.OverdueAmount,
+		PenaltyDebt:   credit.PenaltyDebt,
+		DaysPastDue:   credit.DaysPastDue,
+
+		LoanToValue: credit.LoanToValue,
+	}
 }
 
 type ProcessPaymentRequest struct {
 	PaymentNumber int `json:"payment_number" binding:"required,gt=0"`
 }
 
-func (c *CreditController) CreateCredit(ctx *gin.Context) {
-	userID, exists := ctx.Get("userID")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
-		return
-	}
+// EarlyRepaymentRequest запрос на досрочное погашение. Режим пересчета графика
+// не нужен, если сумма покрывает полную задолженность.
+type EarlyRepaymentRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Mode   string  `json:"mode" binding:"omitempty,oneof=REDUCE_TERM REDUCE_PAYMENT"`
+}
 
-	var req CreateCreditRequest
+// QuoteCredit рассчитывает ставку, график платежей, переплату и ПСК по продукту
+// на текущую ключевую ставку. Кредит не выдается, заявка не создается.
+func (c *CreditController) QuoteCredit(ctx *gin.Context) {
+	var req CreditQuoteRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Дополнительная обработка - убеждаемся, что description всегда строка
-	description := req.Description
-	if description == "" {
-		description = "Потребительский кредит"
-	}
-
-	credit, err := c.creditService.CreateCredit(
-		userID.(uint),
-		req.AccountID,
-		req.Amount,
-		req.TermMonths,
-		description,
-	)
+	product, err := c.productService.GetProductByCode(req.ProductCode)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(creditApplicationErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	// Преобразуем модель в структуру ответа
-	response := CreditResponse{
-		ID:           credit.ID,
-		AccountID:    credit.AccountID,
-		Amount:       credit.Amount,
-		InterestRate: credit.InterestRate,
-		Status:       string(credit.Status),
-		StartDate:    credit.StartDate,
-		EndDate:      credit.EndDate,
-		CreatedAt:    credit.CreatedAt,
-		UpdatedAt:    credit.UpdatedAt,
+	quote, err := c.creditService.QuoteCredit(product, req.Amount, req.TermMonths, model.RepaymentType(req.RepaymentType))
+	if err != nil {
+		ctx.JSON(creditApplicationErrorStatus(err), gin.H{"error": err.Error()})
+		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{
-		"message": "credit created successfully",
-		"credit":  response,
-	})
+	ctx.JSON(http.StatusOK, gin.H{"quote": quote})
 }
 
 func (c *CreditController) GetCreditByID(ctx *gin.Context) {
@@ -107,17 +153,8 @@ func (c *CreditController) GetCreditByID(ctx *gin.Context) {
 		return
 	}
 
-	response := CreditResponse{
-		ID:           credit.ID,
-		AccountID:    credit.AccountID,
-		Amount:       credit.Amount,
-		InterestRate: credit.InterestRate,
-		Status:       string(credit.Status),
-		StartDate:    credit.StartDate,
-		EndDate:      credit.EndDate,
-		CreatedAt:    credit.CreatedAt,
-		UpdatedAt:    credit.UpdatedAt,
-	}
+	response := newCreditResponse(credit)
+	response.RepaymentComparison = credit.CompareRepaymentTypes()
 
 	ctx.JSON(http.StatusOK, response)
 }
@@ -136,18 +173,8 @@ func (c *CreditController) GetUserCredits(ctx *gin.Context) {
 	}
 
 	responses := make([]CreditResponse, len(credits))
-	for i, credit := range credits {
-		responses[i] = CreditResponse{
-			ID:           credit.ID,
-			AccountID:    credit.AccountID,
-			Amount:       credit.Amount,
-			InterestRate: credit.InterestRate,
-			Status:       string(credit.Status),
-			StartDate:    credit.StartDate,
-			EndDate:      credit.EndDate,
-			CreatedAt:    credit.CreatedAt,
-			UpdatedAt:    credit.UpdatedAt,
-		}
+	for i := range credits {
+		responses[i] = newCreditResponse(&credits[i])
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"credits": responses})
@@ -190,3 +217,151 @@ func (c *CreditController) ProcessPayment(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "payment processed successfully"})
 }
+
+func (c *CreditController) GetPayoffQuote(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	creditID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit id"})
+		return
+	}
+
+	quote, err := c.creditService.GetPayoffQuote(userID.(uint), uint(creditID))
+	if err != nil {
+		ctx.JSON(creditErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"payoff": quote})
+}
+
+func (c *CreditController) EarlyRepayment(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	creditID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit id"})
+		return
+	}
+
+	var req EarlyRepaymentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	credit, schedule, err := c.creditService.EarlyRepayment(
+		userID.(uint),
+		uint(creditID),
+		req.Amount,
+		model.EarlyRepaymentMode(req.Mode),
+	)
+	if err != nil {
+		ctx.JSON(creditErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":        "early repayment processed successfully",
+		"status":         credit.Status,
+		"total_paid":     credit.TotalPaid,
+		"remaining_debt": credit.RemainingDebt,
+		"next_payment":   credit.NextPayment,
+		"end_date":       credit.EndDate,
+		"schedule":       schedule,
+	})
+}
+
+// GetRateHistory возвращает историю ставок по кредиту
+func (c *CreditController) GetRateHistory(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	creditID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit id"})
+		return
+	}
+
+	history, err := c.creditService.GetRateHistory(userID.(uint), uint(creditID))
+	if err != nil {
+		ctx.JSON(creditErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"rate_history": history})
+}
+
+// GetCreditSecurity возвращает залог и поручителей по кредиту
+func (c *CreditController) GetCreditSecurity(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	creditID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit id"})
+		return
+	}
+
+	security, err := c.creditService.GetCreditSecurity(userID.(uint), uint(creditID))
+	if err != nil {
+		ctx.JSON(creditErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, security)
+}
+
+// GetGuaranteedCredits возвращает кредиты, по которым пользователь выступает поручителем
+func (c *CreditController) GetGuaranteedCredits(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	credits, err := c.creditService.GetGuaranteedCredits(userID.(uint))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responses := make([]CreditResponse, len(credits))
+	for i := range credits {
+		responses[i] = newCreditResponse(&credits[i])
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"credits": responses})
+}
+
+// creditErrorStatus возвращает HTTP-статус для ошибки операции с кредитом
+func creditErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrCreditNotOwned):
+		return http.StatusForbidden
+	case errors.Is(err, model.ErrCreditNotActive), errors.Is(err, model.ErrCreditHasOverdue):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrInvalidPaymentAmount), errors.Is(err, model.ErrAmountBelowAccrued),
+		errors.Is(err, model.ErrInvalidEarlyRepaymentMode), errors.Is(err, model.ErrInsufficientFunds):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
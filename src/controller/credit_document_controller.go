@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"FinanceGolang/src/repository"
+	"FinanceGolang/src/security"
+	"FinanceGolang/src/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreditDocumentController struct {
+	documentService service.CreditDocumentService
+}
+
+func CreateCreditDocumentController(documentService service.CreditDocumentService) *CreditDocumentController {
+	return &CreditDocumentController{documentService: documentService}
+}
+
+// GetDocuments возвращает договор и график платежей по действующей версии графика
+func (c *CreditDocumentController) GetDocuments(ctx *gin.Context) {
+	userID, creditID, ok := c.bindCredit(ctx)
+	if !ok {
+		return
+	}
+
+	documents, err := c.documentService.GetDocuments(userID, creditID)
+	if err != nil {
+		ctx.JSON(creditDocumentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"documents": documents})
+}
+
+// DownloadDocument отдает файл документа
+func (c *CreditDocumentController) DownloadDocument(ctx *gin.Context) {
+	userID, creditID, ok := c.bindCredit(ctx)
+	if !ok {
+		return
+	}
+
+	documentID, err := strconv.ParseUint(ctx.Param("documentId"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid document id"})
+		return
+	}
+
+	doc, err := c.documentService.GetDocument(userID, creditID, uint(documentID))
+	if err != nil {
+		ctx.JSON(creditDocumentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", doc.FileName))
+	ctx.Header("X-Signature-Key-Id", doc.KeyID)
+	ctx.Data(http.StatusOK, doc.ContentType(), doc.Content)
+}
+
+// DownloadSignature отдает отсоединенную PGP-подпись документа
+func (c *CreditDocumentController) DownloadSignature(ctx *gin.Context) {
+	userID, creditID, ok := c.bindCredit(ctx)
+	if !ok {
+		return
+	}
+
+	documentID, err := strconv.ParseUint(ctx.Param("documentId"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid document id"})
+		return
+	}
+
+	doc, err := c.documentService.GetDocument(userID, creditID, uint(documentID))
+	if err != nil {
+		ctx.JSON(creditDocumentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", doc.SignatureFileName()))
+	ctx.Header("X-Signature-Key-Id", doc.KeyID)
+	ctx.Data(http.StatusOK, "application/pgp-signature", []byte(doc.Signature))
+}
+
+// GetPublicKey отдает открытый ключ банка для проверки подписи документов, `?key_id=` — версия ключа
+func (c *CreditDocumentController) GetPublicKey(ctx *gin.Context) {
+	publicKey, err := c.documentService.GetPublicKey(ctx.Query("key_id"))
+	if err != nil {
+		ctx.JSON(creditDocumentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/pgp-keys", []byte(publicKey))
+}
+
+// bindCredit получает пользователя и кредит из запроса
+func (c *CreditDocumentController) bindCredit(ctx *gin.Context) (uint, uint, bool) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return 0, 0, false
+	}
+
+	creditID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit id"})
+		return 0, 0, false
+	}
+
+	return userID.(uint), uint(creditID), true
+}
+
+// creditDocumentErrorStatus возвращает HTTP-статус для ошибки документов по кредиту
+func creditDocumentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, security.ErrUnknownKeyID):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrCreditNotOwned):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"FinanceGolang/src/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreditLineController struct {
+	creditLineService service.CreditLineService
+}
+
+func CreateCreditLineController(creditLineService service.CreditLineService) *CreditLineController {
+	return &CreditLineController{creditLineService: creditLineService}
+}
+
+// OpenCreditLineRequest условия кредитной линии. Незаданные условия берутся по умолчанию,
+// день выписки по умолчанию — день открытия.
+type OpenCreditLineRequest struct {
+	UserID            uint    `json:"user_id" binding:"required"`
+	CreditLimit       float64 `json:"credit_limit" binding:"required,gt=0"`
+	InterestRate      float64 `json:"interest_rate" binding:"required,gt=0,lte=100"`
+	GracePeriodDays   int     `json:"grace_period_days" binding:"gte=0,lte=25"`
+	MinPaymentPercent float64 `json:"min_payment_percent" binding:"gte=0,lte=100"`
+	MinPaymentAmount  float64 `json:"min_payment_amount" binding:"gte=0"`
+	StatementDay      int     `json:"statement_day" binding:"gte=0,lte=28"`
+}
+
+// ChangeCreditLimitRequest новый кредитный лимит, нулевой лимит запрещает траты
+type ChangeCreditLimitRequest struct {
+	CreditLimit *float64 `json:"credit_limit" binding:"required,gte=0"`
+}
+
+// OpenCreditLine открывает клиенту кредитный счет с лимитом
+func (c *CreditLineController) OpenCreditLine(ctx *gin.Context) {
+	var req OpenCreditLineRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	line, err := c.creditLineService.OpenCreditLine(req.UserID, req.CreditLimit, &model.CreditLine{
+		InterestRate:      req.InterestRate,
+		GracePeriodDays:   req.GracePeriodDays,
+		MinPaymentPercent: req.MinPaymentPercent,
+		MinPaymentAmount:  req.MinPaymentAmount,
+		StatementDay:      req.StatementDay,
+	})
+	if err != nil {
+		ctx.JSON(creditLineErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "credit line opened", "credit_line": line})
+}
+
+// ChangeLimit изменяет кредитный лимит счета
+func (c *CreditLineController) ChangeLimit(ctx *gin.Context) {
+	creditLineID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit line id"})
+		return
+	}
+
+	var req ChangeCreditLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	line, err := c.creditLineService.ChangeLimit(uint(creditLineID), *req.CreditLimit)
+	if err != nil {
+		ctx.JSON(creditLineErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "credit limit changed", "credit_line": line})
+}
+
+func (c *CreditLineController) GetUserCreditLines(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	lines, err := c.creditLineService.GetUserCreditLines(userID.(uint))
+	if err != nil {
+		ctx.JSON(creditLineErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"credit_lines": lines})
+}
+
+func (c *CreditLineController) GetCreditLine(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	creditLineID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit line id"})
+		return
+	}
+
+	line, err := c.creditLineService.GetUserCreditLine(userID.(uint), uint(creditLineID))
+	if err != nil {
+		ctx.JSON(creditLineErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"credit_line": line})
+}
+
+// GetStatements возвращает ежемесячные выписки с минимальным платежом и датой платежа
+func (c *CreditLineController) GetStatements(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	creditLineID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit line id"})
+		return
+	}
+
+	statements, err := c.creditLineService.GetStatements(userID.(uint), uint(creditLineID))
+	if err != nil {
+		ctx.JSON(creditLineErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"statements": statements})
+}
+
+// creditLineErrorStatus возвращает HTTP-статус для ошибки кредитной линии
+func creditLineErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrCreditLineNotOwned):
+		return http.StatusForbidden
+	case errors.Is(err, model.ErrCreditLineNotActive):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrInvalidCreditLine):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
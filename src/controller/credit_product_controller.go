@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"FinanceGolang/src/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreditProductController struct {
+	productService service.CreditProductService
+}
+
+func CreateCreditProductController(productService service.CreditProductService) *CreditProductController {
+	return &CreditProductController{productService: productService}
+}
+
+// CreditProductRequest условия кредитного продукта
+type CreditProductRequest struct {
+	Code               string  `json:"code" binding:"required"`
+	Name               string  `json:"name" binding:"required"`
+	MinAmount          float64 `json:"min_amount" binding:"required,gt=0"`
+	MaxAmount          float64 `json:"max_amount" binding:"required,gtefield=MinAmount"`
+	MinTerm            int     `json:"min_term" binding:"required,gt=0"`
+	MaxTerm            int     `json:"max_term" binding:"required,gtefield=MinTerm,lte=360"`
+	RateType           string  `json:"rate_type" binding:"required,oneof=FIXED KEY_RATE_SPREAD FLOATING"`
+	RateSpread         float64 `json:"rate_spread" binding:"gte=0"`
+	FixedRate          float64 `json:"fixed_rate" binding:"gte=0"`
+	RepaymentType      string  `json:"repayment_type" binding:"omitempty,oneof=ANNUITY DIFFERENTIATED"`
+	IssueFee           float64 `json:"issue_fee" binding:"gte=0"`
+	IssueFeePercent    float64 `json:"issue_fee_percent" binding:"gte=0,lte=100"`
+	InsurancePercent   float64 `json:"insurance_percent" binding:"gte=0,lte=100"`
+	RequiredCollateral string  `json:"required_collateral" binding:"omitempty,oneof=VEHICLE REAL_ESTATE"`
+	MaxLoanToValue     float64 `json:"max_loan_to_value" binding:"gte=0,lte=100"`
+	IsActive           *bool   `json:"is_active"`
+}
+
+// toModel преобразует запрос в продукт. Продукт без признака is_active доступен для оформления.
+func (r *CreditProductRequest) toModel() *model.CreditProduct {
+	isActive := true
+	if r.IsActive != nil {
+		isActive = *r.IsActive
+	}
+	return &model.CreditProduct{
+		Code:               r.Code,
+		Name:               r.Name,
+		MinAmount:          r.MinAmount,
+		MaxAmount:          r.MaxAmount,
+		MinTerm:            r.MinTerm,
+		MaxTerm:            r.MaxTerm,
+		RateType:           model.CreditRateType(r.RateType),
+		RateSpread:         r.RateSpread,
+		FixedRate:          r.FixedRate,
+		RepaymentType:      model.RepaymentType(r.RepaymentType),
+		IssueFee:           r.IssueFee,
+		IssueFeePercent:    r.IssueFeePercent,
+		InsurancePercent:   r.InsurancePercent,
+		RequiredCollateral: model.CollateralType(r.RequiredCollateral),
+		MaxLoanToValue:     r.MaxLoanToValue,
+		IsActive:           isActive,
+	}
+}
+
+// GetActiveProducts возвращает продукты, доступные для оформления
+func (c *CreditProductController) GetActiveProducts(ctx *gin.Context) {
+	products, err := c.productService.GetActiveProducts()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"products": products})
+}
+
+// ListProducts возвращает весь каталог, включая отключенные продукты
+func (c *CreditProductController) ListProducts(ctx *gin.Context) {
+	products, err := c.productService.ListProducts()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"products": products})
+}
+
+func (c *CreditProductController) CreateProduct(ctx *gin.Context) {
+	var req CreditProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product := req.toModel()
+	if err := c.productService.CreateProduct(product); err != nil {
+		ctx.JSON(creditProductErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"product": product})
+}
+
+func (c *CreditProductController) UpdateProduct(ctx *gin.Context) {
+	productID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+		return
+	}
+
+	var req CreditProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	product, err := c.productService.UpdateProduct(uint(productID), req.toModel())
+	if err != nil {
+		ctx.JSON(creditProductErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"product": product})
+}
+
+// DeactivateProduct снимает продукт с продажи
+func (c *CreditProductController) DeactivateProduct(ctx *gin.Context) {
+	productID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+		return
+	}
+
+	if err := c.productService.DeactivateProduct(uint(productID)); err != nil {
+		ctx.JSON(creditProductErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "credit product deactivated"})
+}
+
+// creditProductErrorStatus возвращает HTTP-статус для ошибки операции с продуктом
+func creditProductErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, model.ErrCreditProductNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrInvalidCreditProduct), errors.Is(err, model.ErrInvalidCreditRateType),
+		errors.Is(err, model.ErrInvalidInterestRate), errors.Is(err, model.ErrInvalidCollateralType),
+		errors.Is(err, model.ErrInvalidRepaymentType):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"FinanceGolang/src/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreditRestructuringController struct {
+	restructuringService service.CreditRestructuringService
+}
+
+func CreateCreditRestructuringController(restructuringService service.CreditRestructuringService) *CreditRestructuringController {
+	return &CreditRestructuringController{restructuringService: restructuringService}
+}
+
+// RestructureCreditRequest условия реструктуризации. months задает длительность каникул
+// или увеличение срока, payment_day — новый день платежа.
+type RestructureCreditRequest struct {
+	Type         string `json:"type" binding:"required,oneof=PAYMENT_HOLIDAY TERM_EXTENSION PAYMENT_DAY_CHANGE"`
+	Months       int    `json:"months" binding:"gte=0"`
+	InterestMode string `json:"interest_mode" binding:"omitempty,oneof=CAPITALIZE DEFER"`
+	PaymentDay   int    `json:"payment_day" binding:"gte=0,lte=31"`
+	Reason       string `json:"reason" binding:"required"`
+}
+
+func (c *CreditRestructuringController) Restructure(ctx *gin.Context) {
+	operatorID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	creditID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit id"})
+		return
+	}
+
+	var req RestructureCreditRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	restructuring, schedule, err := c.restructuringService.Restructure(operatorID.(uint), uint(creditID), model.RestructuringRequest{
+		Type:         model.RestructuringType(req.Type),
+		Months:       req.Months,
+		InterestMode: model.HolidayInterestMode(req.InterestMode),
+		PaymentDay:   req.PaymentDay,
+		Reason:       req.Reason,
+	})
+	if err != nil {
+		ctx.JSON(creditRestructuringErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":       "credit restructured",
+		"restructuring": restructuring,
+		"schedule":      schedule,
+	})
+}
+
+func (c *CreditRestructuringController) GetRestructurings(ctx *gin.Context) {
+	creditID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit id"})
+		return
+	}
+
+	restructurings, err := c.restructuringService.GetRestructurings(uint(creditID))
+	if err != nil {
+		ctx.JSON(creditRestructuringErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"restructurings": restructurings})
+}
+
+// GetScheduleVersion возвращает действующую или архивную версию графика платежей
+func (c *CreditRestructuringController) GetScheduleVersion(ctx *gin.Context) {
+	creditID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit id"})
+		return
+	}
+
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule version"})
+		return
+	}
+
+	schedule, err := c.restructuringService.GetScheduleVersion(uint(creditID), version)
+	if err != nil {
+		ctx.JSON(creditRestructuringErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"version": version, "schedule": schedule})
+}
+
+// creditRestructuringErrorStatus возвращает HTTP-статус для ошибки реструктуризации
+func creditRestructuringErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, model.ErrCreditNotActive), errors.Is(err, model.ErrCreditHasOverdue):
+		return http.StatusConflict
+	case errors.Is(err, model.ErrInvalidRestructuring), errors.Is(err, model.ErrInvalidRestructuringType),
+		errors.Is(err, model.ErrInvalidHolidayInterestMode), errors.Is(err, model.ErrInvalidPaymentDay),
+		errors.Is(err, model.ErrInvalidTerm):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
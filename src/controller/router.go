@@ -1,11 +1,13 @@
 package controller
 
 import (
+	"FinanceGolang/src/config"
 	"FinanceGolang/src/database"
+	"FinanceGolang/src/document"
+	"FinanceGolang/src/model"
 	"FinanceGolang/src/repository"
 	"FinanceGolang/src/security"
 	"FinanceGolang/src/service"
-	"io/ioutil"
 	"net/http"
 	"time"
 
@@ -32,10 +34,30 @@ const (
 	APIPathTransactions = "/transactions"
 	APIPathCards        = "/cards"
 	APIPathCredits      = "/credits"
+	APIPathCreditLines  = "/credit-lines"
+	APIPathStatements   = "/statements"
+	APIPathApplications = "/applications"
+	APIPathBackOffice   = "/backoffice"
 	APIPathSchedule     = "/schedule"
 	APIPathPayment      = "/payment"
+	APIPathPayoff       = "/payoff"
+	APIPathEarlyRepay   = "/early-repayment"
+	APIPathRateHistory  = "/rate-history"
+	APIPathQuote        = "/quote"
+	APIPathCollateral   = "/collateral"
+	APIPathGuarantors   = "/guarantors"
+	APIPathGuaranteed   = "/guaranteed"
+	APIPathCollections  = "/collections"
+	APIPathAutoDebit    = "/auto-debit"
+	APIPathCreditBureau = "/credit-bureau"
+	APIPathDocuments    = "/documents"
 	APIPathAnalytics    = "/analytics"
 	APIPathForecast     = "/forecast"
+	APIPathProducts     = "/products"
+	APIPathVirtual      = "/virtual"
+	APIPathControls     = "/controls"
+	APIPathCNPPayments  = "/cnp-payments"
+	APIPathConfirm      = "/confirm"
 )
 
 // Константы для сообщений об ошибках
@@ -44,11 +66,21 @@ const (
 	ErrInternalServer = "Внутренняя ошибка сервера"
 )
 
-type Router struct{}
+type Router struct {
+	cfg            *config.Config
+	cardKeys       *security.KeyManager
+	bankKeys       *security.KeyManager
+	cardHMACSecret []byte
+}
 
 // NewRouter создает новый экземпляр маршрутизатора
-func NewRouter() *Router {
-	return &Router{}
+func NewRouter(cfg *config.Config, cardKeys *security.KeyManager, bankKeys *security.KeyManager, cardHMACSecret []byte) *Router {
+	return &Router{
+		cfg:            cfg,
+		cardKeys:       cardKeys,
+		bankKeys:       bankKeys,
+		cardHMACSecret: cardHMACSecret,
+	}
 }
 
 // createAuthService создает сервис аутентификации
@@ -74,22 +106,38 @@ func (r *Router) createAccountService() service.AccountService {
 func (r *Router) createCardService() service.CardService {
 	cardRepo := repository.CardRepositoryInstance(database.DB)
 	accountRepo := repository.AccountRepositoryInstance(database.DB)
+	productRepo := repository.CardProductRepositoryInstance(database.DB)
+	transactionRepo := repository.TransactionRepositoryInstance(database.DB)
 
-	// Читаем публичный ключ из файла
-	publicKeyBytes, err := ioutil.ReadFile("public_key.asc")
-	if err != nil {
-		logrus.WithError(err).Error("Ошибка чтения публичного ключа")
-		publicKeyBytes = []byte("card_public_key_" + time.Now().Format("20060102150405"))
-	}
+	return service.CardServiceInstance(cardRepo, accountRepo, productRepo, transactionRepo, r.cardKeys, r.cardHMACSecret)
+}
 
-	// Читаем HMAC секрет из файла
-	hmacSecretBytes, err := ioutil.ReadFile("private_key.asc")
-	if err != nil {
-		logrus.WithError(err).Error("Ошибка чтения приватного ключа")
-		hmacSecretBytes = []byte("card_hmac_secret_" + time.Now().Format("20060102150405"))
-	}
+// createCardNotPresentService создает сервис онлайн-платежей по реквизитам карты
+func (r *Router) createCardNotPresentService(cardService service.CardService) service.CardNotPresentService {
+	return service.CardNotPresentServiceInstance(
+		cardService,
+		repository.AccountRepositoryInstance(database.DB),
+		repository.TransactionRepositoryInstance(database.DB),
+		repository.PaymentChallengeRepositoryInstance(database.DB),
+		repository.UserRepositoryInstance(database.DB),
+		r.createNotifier(),
+		r.cfg.CNPChallengeThreshold,
+		r.cfg.OTPTTL,
+	)
+}
 
-	return service.CardServiceInstance(cardRepo, accountRepo, string(publicKeyBytes), hmacSecretBytes)
+// createNotifier создает отправку одноразовых кодов согласно OTP_NOTIFIER
+func (r *Router) createNotifier() service.Notifier {
+	switch r.cfg.OTPNotifier {
+	case "email":
+		return service.EmailNotifierInstance(service.NewExternalService(
+			r.cfg.SMTPHost, r.cfg.SMTPPort, r.cfg.SMTPUsername, r.cfg.SMTPPassword, r.cfg.EmailFrom,
+		))
+	case "file":
+		return service.FileNotifierInstance(r.cfg.OTPNotifierFile)
+	default:
+		return service.LogNotifierInstance()
+	}
 }
 
 // createCreditService создает сервис кредитов
@@ -99,6 +147,142 @@ func (r *Router) createCreditService() service.CreditService {
 		repository.AccountRepositoryInstance(database.DB),
 		repository.TransactionRepositoryInstance(database.DB),
 		service.NewExternalService("", 0, "", "", ""),
+		r.createOverdueService(),
+		r.createAutoDebitService(),
+		r.createCreditDocumentService(),
+	)
+}
+
+// createAutoDebitService создает сервис списания платежей по кредитам с резервных счетов
+func (r *Router) createAutoDebitService() service.AutoDebitService {
+	return service.AutoDebitServiceInstance(
+		repository.AutoDebitRepositoryInstance(database.DB),
+		repository.CreditRepositoryInstance(database.DB),
+		repository.AccountRepositoryInstance(database.DB),
+		repository.TransactionRepositoryInstance(database.DB),
+	)
+}
+
+// createOverdueService создает сервис учета просроченной задолженности
+func (r *Router) createOverdueService() service.OverdueService {
+	return service.OverdueServiceInstance(
+		repository.CreditRepositoryInstance(database.DB),
+		repository.TransactionRepositoryInstance(database.DB),
+		r.cfg.PenaltyRate,
+	)
+}
+
+// createCreditLineService создает сервис кредитных линий
+func (r *Router) createCreditLineService() service.CreditLineService {
+	return service.CreditLineServiceInstance(
+		repository.CreditLineRepositoryInstance(database.DB),
+		repository.AccountRepositoryInstance(database.DB),
+		repository.TransactionRepositoryInstance(database.DB),
+		repository.UserRepositoryInstance(database.DB),
+	)
+}
+
+// createScheduler создает шедулер платежей по кредитам, пересмотра плавающих ставок,
+// выписок по кредитным линиям и выгрузки кредитной истории
+func (r *Router) createScheduler() *service.Scheduler {
+	return service.NewScheduler(
+		r.createCreditService(),
+		r.createCreditLineService(),
+		r.createCollectionService(),
+		r.createCreditBureauService(),
+		repository.CreditRepositoryInstance(database.DB),
+		repository.AccountRepositoryInstance(database.DB),
+		service.NewExternalService(r.cfg.SMTPHost, r.cfg.SMTPPort, r.cfg.SMTPUsername, r.cfg.SMTPPassword, r.cfg.EmailFrom),
+		r.cfg.OverdueCheckInterval,
+		r.cfg.KeyRateCheckInterval,
+		r.cfg.StatementCheckInterval,
+		r.cfg.BureauExportInterval,
+	)
+}
+
+// createCreditBureauService создает сервис выгрузки кредитной истории в бюро
+func (r *Router) createCreditBureauService() service.CreditBureauService {
+	return service.CreditBureauServiceInstance(
+		repository.CreditBureauRepositoryInstance(database.DB),
+		repository.CreditRepositoryInstance(database.DB),
+		repository.UserRepositoryInstance(database.DB),
+		r.bankKeys,
+		r.cfg.BureauMemberCode,
+	)
+}
+
+// createCreditDocumentService создает сервис документов по кредитам
+func (r *Router) createCreditDocumentService() service.CreditDocumentService {
+	return service.CreditDocumentServiceInstance(
+		repository.CreditDocumentRepositoryInstance(database.DB),
+		repository.CreditRepositoryInstance(database.DB),
+		repository.UserRepositoryInstance(database.DB),
+		document.NewRenderer(r.cfg.DocumentFontPath),
+		r.bankKeys,
+		r.cfg.AppName,
+	)
+}
+
+// createCollectionService создает сервис взыскания просроченной задолженности
+func (r *Router) createCollectionService() service.CollectionService {
+	return service.CollectionServiceInstance(
+		repository.CollectionRepositoryInstance(database.DB),
+		repository.CreditRepositoryInstance(database.DB),
+	)
+}
+
+// StartScheduler запускает обработку просроченных платежей, проверку ключевой ставки, выписки
+// и выгрузку кредитной истории
+func (r *Router) StartScheduler() {
+	r.createScheduler().Start()
+}
+
+// createCreditProductService создает сервис каталога кредитных продуктов
+func (r *Router) createCreditProductService() service.CreditProductService {
+	return service.CreditProductServiceInstance(repository.CreditProductRepositoryInstance(database.DB))
+}
+
+// createCreditApplicationService создает сервис заявок на кредит
+func (r *Router) createCreditApplicationService(creditService service.CreditService) service.CreditApplicationService {
+	return service.CreditApplicationServiceInstance(
+		repository.CreditApplicationRepositoryInstance(database.DB),
+		repository.CreditRepositoryInstance(database.DB),
+		repository.AccountRepositoryInstance(database.DB),
+		repository.UserRepositoryInstance(database.DB),
+		creditService,
+		r.createCreditProductService(),
+		service.ScoringServiceInstance(
+			repository.AccountRepositoryInstance(database.DB),
+			repository.TransactionRepositoryInstance(database.DB),
+			repository.CreditRepositoryInstance(database.DB),
+			repository.UserRepositoryInstance(database.DB),
+			r.createCreditBureauClient(),
+			model.ScoreThresholds{
+				Approve: r.cfg.ScoreApproveThreshold,
+				Decline: r.cfg.ScoreDeclineThreshold,
+			},
+			r.cfg.ScoringStressRate,
+		),
+	)
+}
+
+// createCreditBureauClient создает клиент бюро кредитных историй согласно CREDIT_BUREAU_CLIENT
+// с хранением отчетов в течение CREDIT_BUREAU_CACHE_TTL
+func (r *Router) createCreditBureauClient() service.CreditBureauClient {
+	var client service.CreditBureauClient
+	// Допустимость значения проверяется при запуске (checkCreditBureauClient в main.go)
+	switch r.cfg.CreditBureauClient {
+	case model.CreditBureauSourceHTTP:
+		client = service.HTTPCreditBureauClientInstance(
+			r.cfg.CreditBureauURL, r.cfg.CreditBureauToken, r.cfg.BureauMemberCode, r.cfg.CreditBureauTimeout,
+		)
+	default:
+		client = service.StubCreditBureauClientInstance()
+	}
+	return service.CachedCreditBureauClientInstance(
+		client,
+		repository.CreditBureauRepositoryInstance(database.DB),
+		r.cfg.CreditBureauCacheTTL,
 	)
 }
 
@@ -266,7 +450,7 @@ func (r *Router) RegisterAccountRoutes(g *gin.RouterGroup) {
 func (r *Router) RegisterCardRoutes(g *gin.RouterGroup) {
 	authService := r.createAuthService()
 	cardService := r.createCardService()
-	cardController := CreateCardController(cardService)
+	cardController := CreateCardController(cardService, r.createAccountService(), r.createCardNotPresentService(cardService))
 
 	g.POST(APIPathCards, security.AuthMiddleware(security.AuthMiddlewareDeps{
 		ValidateUserFromToken: authService.ValidateUserFromToken,
@@ -274,6 +458,27 @@ func (r *Router) RegisterCardRoutes(g *gin.RouterGroup) {
 	g.GET(APIPathCards, security.AuthMiddleware(security.AuthMiddlewareDeps{
 		ValidateUserFromToken: authService.ValidateUserFromToken,
 	}), cardController.GetAllCards)
+	g.POST(APIPathCards+APIPathVirtual, security.AuthMiddleware(security.AuthMiddlewareDeps{
+		ValidateUserFromToken: authService.ValidateUserFromToken,
+	}), cardController.CreateVirtualCard)
+	g.GET(APIPathCards+APIPathProducts, security.AuthMiddleware(security.AuthMiddlewareDeps{
+		ValidateUserFromToken: authService.ValidateUserFromToken,
+	}), cardController.GetCardProducts)
+	g.POST(APIPathCards+APIPathTransfer, security.AuthMiddleware(security.AuthMiddlewareDeps{
+		ValidateUserFromToken: authService.ValidateUserFromToken,
+	}), cardController.TransferToCard)
+	g.POST(APIPathCards+"/:id"+APIPathPayment, security.AuthMiddleware(security.AuthMiddlewareDeps{
+		ValidateUserFromToken: authService.ValidateUserFromToken,
+	}), cardController.PayByCard)
+	g.PUT(APIPathCards+"/:id"+APIPathControls, security.AuthMiddleware(security.AuthMiddlewareDeps{
+		ValidateUserFromToken: authService.ValidateUserFromToken,
+	}), cardController.UpdateCardControls)
+	g.POST(APIPathCards+APIPathCNPPayments, security.AuthMiddleware(security.AuthMiddlewareDeps{
+		ValidateUserFromToken: authService.ValidateUserFromToken,
+	}), cardController.PayCardNotPresent)
+	g.POST(APIPathCards+APIPathCNPPayments+"/:id"+APIPathConfirm, security.AuthMiddleware(security.AuthMiddlewareDeps{
+		ValidateUserFromToken: authService.ValidateUserFromToken,
+	}), cardController.ConfirmCardNotPresent)
 }
 
 // RegisterKeyRateRoutes регистрирует маршруты ключевой ставки
@@ -291,18 +496,107 @@ func (r *Router) RegisterKeyRateRoutes(g *gin.RouterGroup) {
 func (r *Router) RegisterCreditRoutes(g *gin.RouterGroup) {
 	authService := r.createAuthService()
 	creditService := r.createCreditService()
-	creditController := CreateCreditController(creditService)
+	creditController := CreateCreditController(creditService, r.createCreditProductService())
+	applicationController := CreateCreditApplicationController(r.createCreditApplicationService(creditService))
+	productController := CreateCreditProductController(r.createCreditProductService())
+	autoDebitController := CreateAutoDebitController(r.createAutoDebitService())
+	documentController := CreateCreditDocumentController(r.createCreditDocumentService())
 
 	credits := g.Group(APIPathCredits)
 	credits.Use(security.AuthMiddleware(security.AuthMiddlewareDeps{
 		ValidateUserFromToken: authService.ValidateUserFromToken,
 	}))
 	{
-		credits.POST("", creditController.CreateCredit)
+		// Оформление кредита подает заявку, кредит выдается после одобрения
+		credits.POST("", applicationController.SubmitApplication)
+		credits.GET(APIPathProducts, productController.GetActiveProducts)
+		credits.POST(APIPathQuote, creditController.QuoteCredit)
+		credits.GET(APIPathApplications, applicationController.GetUserApplications)
+		credits.GET(APIPathApplications+"/:id", applicationController.GetUserApplication)
 		credits.GET("", creditController.GetUserCredits)
+		credits.GET(APIPathGuaranteed, creditController.GetGuaranteedCredits)
 		credits.GET("/:id", creditController.GetCreditByID)
 		credits.GET("/:id"+APIPathSchedule, creditController.GetPaymentSchedule)
 		credits.POST("/:id"+APIPathPayment, creditController.ProcessPayment)
+		credits.GET("/:id"+APIPathPayoff, creditController.GetPayoffQuote)
+		credits.POST("/:id"+APIPathEarlyRepay, creditController.EarlyRepayment)
+		credits.GET("/:id"+APIPathRateHistory, creditController.GetRateHistory)
+		credits.GET("/:id"+APIPathCollateral, creditController.GetCreditSecurity)
+		credits.GET("/:id"+APIPathAutoDebit, autoDebitController.GetAccounts)
+		credits.POST("/:id"+APIPathAutoDebit, autoDebitController.AuthorizeAccount)
+		credits.DELETE("/:id"+APIPathAutoDebit+"/:authorizationId", autoDebitController.RevokeAccount)
+		credits.GET("/:id"+APIPathAutoDebit+"/attempts", autoDebitController.GetAttempts)
+		credits.GET(APIPathDocuments+"/public-key", documentController.GetPublicKey)
+		credits.GET("/:id"+APIPathDocuments, documentController.GetDocuments)
+		credits.GET("/:id"+APIPathDocuments+"/:documentId", documentController.DownloadDocument)
+		credits.GET("/:id"+APIPathDocuments+"/:documentId/signature", documentController.DownloadSignature)
+	}
+}
+
+// RegisterCreditLineRoutes регистрирует маршруты кредитных линий
+func (r *Router) RegisterCreditLineRoutes(g *gin.RouterGroup) {
+	authService := r.createAuthService()
+	creditLineController := CreateCreditLineController(r.createCreditLineService())
+
+	creditLines := g.Group(APIPathCreditLines)
+	creditLines.Use(security.AuthMiddleware(security.AuthMiddlewareDeps{
+		ValidateUserFromToken: authService.ValidateUserFromToken,
+	}))
+	{
+		creditLines.GET("", creditLineController.GetUserCreditLines)
+		creditLines.GET("/:id", creditLineController.GetCreditLine)
+		creditLines.GET("/:id"+APIPathStatements, creditLineController.GetStatements)
+	}
+}
+
+// RegisterBackOfficeRoutes регистрирует маршруты для сотрудников банка
+func (r *Router) RegisterBackOfficeRoutes(g *gin.RouterGroup) {
+	authService := r.createAuthService()
+	applicationController := CreateCreditApplicationController(
+		r.createCreditApplicationService(r.createCreditService()),
+	)
+	restructuringController := CreateCreditRestructuringController(
+		service.CreditRestructuringServiceInstance(
+			repository.CreditRepositoryInstance(database.DB),
+			r.createCreditDocumentService(),
+		),
+	)
+	creditLineController := CreateCreditLineController(r.createCreditLineService())
+	collectionController := CreateCollectionController(r.createCollectionService())
+
+	backOffice := g.Group(APIPathBackOffice)
+	backOffice.Use(security.AuthMiddleware(security.AuthMiddlewareDeps{
+		ValidateUserFromToken: authService.ValidateUserFromToken,
+	}))
+	backOffice.Use(security.RoleMiddleware(model.RoleAdmin, model.RoleManager, model.RoleOperator))
+	{
+		applications := backOffice.Group("/credit-applications")
+		applications.GET("", applicationController.ListApplications)
+		applications.GET("/:id", applicationController.GetApplication)
+		applications.POST("/:id/approve", applicationController.ApproveApplication)
+		applications.POST("/:id/reject", applicationController.RejectApplication)
+
+		// Залог и поручители регистрируются до одобрения заявки
+		applications.POST("/:id"+APIPathCollateral, applicationController.AddCollateral)
+		applications.POST("/:id"+APIPathGuarantors, applicationController.AddGuarantor)
+
+		// Реструктуризация кредитов с сохранением предыдущих версий графика
+		credits := backOffice.Group(APIPathCredits)
+		credits.GET("/:id/restructurings", restructuringController.GetRestructurings)
+		credits.POST("/:id/restructurings", restructuringController.Restructure)
+		credits.GET("/:id/schedule-versions/:version", restructuringController.GetScheduleVersion)
+
+		// Кредитные линии открывают и меняют лимит сотрудники банка
+		creditLines := backOffice.Group(APIPathCreditLines)
+		creditLines.POST("", creditLineController.OpenCreditLine)
+		creditLines.PUT("/:id/limit", creditLineController.ChangeLimit)
+
+		// Взыскание просроченной задолженности
+		collections := backOffice.Group(APIPathCollections)
+		collections.GET("", collectionController.GetQueue)
+		collections.GET("/:id", collectionController.GetCase)
+		collections.POST("/:id/contacts", collectionController.LogContact)
+		collections.POST("/:id/promises", collectionController.AddPromise)
 	}
 }
 
@@ -325,13 +619,9 @@ func (r *Router) RegisterAnalyticsRoutes(g *gin.RouterGroup) {
 // RegisterAdminRoutes регистрирует маршруты админской части
 func (r *Router) RegisterAdminRoutes(g *gin.RouterGroup) {
 	authService := r.createAuthService()
-	scheduler := service.NewScheduler(
-		repository.CreditRepositoryInstance(database.DB),
-		repository.AccountRepositoryInstance(database.DB),
-		repository.TransactionRepositoryInstance(database.DB),
-		service.NewExternalService("", 0, "", "", ""),
-	)
-	adminController := CreateAdminController(scheduler)
+	adminController := CreateAdminController(r.createScheduler())
+	productController := CreateCreditProductController(r.createCreditProductService())
+	bureauController := CreateCreditBureauController(r.createCreditBureauService())
 
 	admin := g.Group("/admin")
 	admin.Use(security.AuthMiddleware(security.AuthMiddlewareDeps{
@@ -340,6 +630,25 @@ func (r *Router) RegisterAdminRoutes(g *gin.RouterGroup) {
 	{
 		admin.GET("/credits", adminController.GetAllCredits)
 		admin.POST("/scheduler/check-payments", adminController.CheckPayments)
+		admin.POST("/scheduler/check-key-rate", security.RoleMiddleware(model.RoleAdmin), adminController.CheckKeyRate)
+		admin.POST("/scheduler/process-statements", security.RoleMiddleware(model.RoleAdmin), adminController.ProcessStatements)
+
+		// Каталог кредитных продуктов ведут администраторы
+		products := admin.Group("/credit-products")
+		products.Use(security.RoleMiddleware(model.RoleAdmin))
+		products.GET("", productController.ListProducts)
+		products.POST("", productController.CreateProduct)
+		products.PUT("/:id", productController.UpdateProduct)
+		products.DELETE("/:id", productController.DeactivateProduct)
+
+		// Выгрузки кредитной истории в бюро кредитных историй
+		bureau := admin.Group(APIPathCreditBureau)
+		bureau.Use(security.RoleMiddleware(model.RoleAdmin))
+		bureau.GET("/exports", bureauController.ListExports)
+		bureau.POST("/exports", bureauController.GenerateExport)
+		bureau.GET("/exports/:id/file", bureauController.DownloadFile)
+		bureau.GET("/exports/:id/signature", bureauController.DownloadSignature)
+		bureau.GET("/public-key", bureauController.GetPublicKey)
 	}
 }
 
@@ -386,6 +695,8 @@ func (r *Router) InitRoutes() *gin.Engine {
 		r.RegisterAccountRoutes(api)
 		r.RegisterCardRoutes(api)
 		r.RegisterCreditRoutes(api)
+		r.RegisterCreditLineRoutes(api)
+		r.RegisterBackOfficeRoutes(api)
 		r.RegisterAnalyticsRoutes(api)
 		r.RegisterAdminRoutes(api)
 	}
@@ -66,7 +66,7 @@ func InitDB() (*gorm.DB, error) {
 		return nil, fmt.Errorf("неподдерживаемый тип базы данных: %s", cfg.DBType)
 	}
 
-	DB, err = gorm.Open(dialector, &gorm.Config{Logger: loggerInstance})
+	DB, err = gorm.Open(dialector, &gorm.Config{Logger: loggerInstance, TranslateError: true})
 	if err != nil {
 		return nil, fmt.Errorf("ошибка подключения к базе данных: %v", err)
 	}
@@ -105,6 +105,16 @@ func ConnectDB() (*gorm.DB, error) {
 }
 
 func CreateTables(db *gorm.DB) error {
+	if err := dropCardAuthorizationRRNIndex(db); err != nil {
+		return fmt.Errorf("ошибка при обновлении индекса RRN авторизаций: %v", err)
+	}
+	if err := dropCardNumberHashIndex(db); err != nil {
+		return fmt.Errorf("ошибка при обновлении индекса номеров карт: %v", err)
+	}
+	if err := deleteDuplicateCreditDocuments(db); err != nil {
+		return fmt.Errorf("ошибка при удалении повторных документов по кредитам: %v", err)
+	}
+
 	// Создаем таблицы
 	err := db.AutoMigrate(
 		&model.Role{},
@@ -112,9 +122,29 @@ func CreateTables(db *gorm.DB) error {
 		&model.UserRole{},
 		&model.Account{},
 		&model.Card{},
+		&model.CardProduct{},
 		&model.Transaction{},
+		&model.CardAuthorization{},
+		&model.PaymentChallenge{},
+		&model.CreditProduct{},
 		&model.Credit{},
 		&model.PaymentSchedule{},
+		&model.CreditRateHistory{},
+		&model.CreditRestructuring{},
+		&model.ArchivedPayment{},
+		&model.CreditApplication{},
+		&model.CreditApplicationEvent{},
+		&model.CreditLine{},
+		&model.CreditStatement{},
+		&model.Collateral{},
+		&model.Guarantor{},
+		&model.CollectionCase{},
+		&model.CollectionActivity{},
+		&model.AutoDebitAccount{},
+		&model.AutoDebitAttempt{},
+		&model.CreditBureauExport{},
+		&model.CreditBureauReport{},
+		&model.CreditDocument{},
 		&model.Analytics{},
 		&model.BalanceForecast{},
 	)
@@ -128,6 +158,16 @@ func CreateTables(db *gorm.DB) error {
 		return fmt.Errorf("ошибка при инициализации ролей: %v", err)
 	}
 
+	// Заполняем каталог карточных продуктов
+	if err := InitializeCardProducts(db); err != nil {
+		return fmt.Errorf("ошибка при инициализации карточных продуктов: %v", err)
+	}
+
+	// Заполняем каталог кредитных продуктов
+	if err := InitializeCreditProducts(db); err != nil {
+		return fmt.Errorf("ошибка при инициализации кредитных продуктов: %v", err)
+	}
+
 	// Создаем админа после создания всех таблиц и инициализации ролей
 	if err := createAdmin(db); err != nil {
 		return fmt.Errorf("ошибка при создании админа: %v", err)
@@ -136,11 +176,73 @@ func CreateTables(db *gorm.DB) error {
 	return nil
 }
 
+// dropCardNumberHashIndex удаляет неуникальный слепой индекс номеров карт,
+// чтобы AutoMigrate создал его заново уникальным
+func dropCardNumberHashIndex(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.Card{}) {
+		return nil
+	}
+	indexes, err := db.Migrator().GetIndexes(&model.Card{})
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if index.Name() != "idx_cards_number_hash" {
+			continue
+		}
+		if unique, ok := index.Unique(); ok && !unique {
+			return db.Migrator().DropIndex(&model.Card{}, index.Name())
+		}
+	}
+	return nil
+}
+
+// dropCardAuthorizationRRNIndex удаляет неуникальный индекс (terminal_id, rrn) авторизаций,
+// чтобы AutoMigrate создал его заново уникальным
+func dropCardAuthorizationRRNIndex(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.CardAuthorization{}) {
+		return nil
+	}
+	indexes, err := db.Migrator().GetIndexes(&model.CardAuthorization{})
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if index.Name() != "idx_card_auth_rrn" {
+			continue
+		}
+		if unique, ok := index.Unique(); ok && !unique {
+			return db.Migrator().DropIndex(&model.CardAuthorization{}, index.Name())
+		}
+	}
+	return nil
+}
+
+// deleteDuplicateCreditDocuments удаляет повторно сформированные документы одной версии графика,
+// оставляя первый комплект, чтобы AutoMigrate создал уникальный индекс idx_credit_document_version
+func deleteDuplicateCreditDocuments(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.CreditDocument{}) ||
+		db.Migrator().HasIndex(&model.CreditDocument{}, "idx_credit_document_version") {
+		return nil
+	}
+	return db.Exec("DELETE FROM credit_documents WHERE id NOT IN " +
+		"(SELECT MIN(id) FROM credit_documents GROUP BY credit_id, schedule_version, type, format)").Error
+}
+
 func createAdmin(db *gorm.DB) error {
+	adminRole := model.Role{Name: model.RoleAdmin, Description: "Администратор системы"}
+	if err := db.FirstOrCreate(&adminRole, model.Role{Name: model.RoleAdmin}).Error; err != nil {
+		return fmt.Errorf("ошибка при создании роли админа: %v", err)
+	}
+
 	// Проверяем, существует ли уже админ
 	var existingAdmin model.User
 	if err := db.Where("username = ?", "admin").First(&existingAdmin).Error; err == nil {
-		// Админ уже существует, ничего не делаем
+		// Админ уже существует, проверяем только наличие роли
+		userRole := model.UserRole{UserID: existingAdmin.ID, RoleID: adminRole.ID}
+		if err := db.FirstOrCreate(&userRole, userRole).Error; err != nil {
+			return fmt.Errorf("ошибка при назначении роли админа: %v", err)
+		}
 		return nil
 	}
 
@@ -148,16 +250,10 @@ func createAdmin(db *gorm.DB) error {
 		Username: "admin",
 		Password: "admin",
 		Email:    "admin@example.com",
+		Roles:    []model.Role{adminRole},
 	}
 
-	// Создаем роли, если их нет
-	adminRole := model.Role{Name: model.RoleAdmin, Description: "Администратор системы"}
 	userRole := model.Role{Name: model.RoleUser, Description: "Обычный пользователь"}
-
-	if err := db.FirstOrCreate(&adminRole, model.Role{Name: model.RoleAdmin}).Error; err != nil {
-		return fmt.Errorf("ошибка при создании роли админа: %v", err)
-	}
-
 	if err := db.FirstOrCreate(&userRole, model.Role{Name: model.RoleUser}).Error; err != nil {
 		return fmt.Errorf("ошибка при создании роли пользователя: %v", err)
 	}
@@ -175,6 +271,8 @@ func InitializeRoles(db *gorm.DB) error {
 	roles := []model.Role{
 		{Name: model.RoleAdmin, Description: "Администратор"},
 		{Name: model.RoleUser, Description: "Пользователь"},
+		{Name: model.RoleManager, Description: "Менеджер"},
+		{Name: model.RoleOperator, Description: "Оператор"},
 	}
 
 	// Сохраняем роли в базе данных
@@ -187,6 +285,28 @@ func InitializeRoles(db *gorm.DB) error {
 	return nil
 }
 
+// InitializeCardProducts создает карточные продукты по умолчанию
+func InitializeCardProducts(db *gorm.DB) error {
+	for _, product := range model.GetDefaultCardProducts() {
+		if err := db.FirstOrCreate(&product, model.CardProduct{Code: product.Code}).Error; err != nil {
+			return fmt.Errorf("ошибка при создании карточного продукта %s: %v", product.Code, err)
+		}
+	}
+
+	return nil
+}
+
+// InitializeCreditProducts создает кредитные продукты по умолчанию
+func InitializeCreditProducts(db *gorm.DB) error {
+	for _, product := range model.GetDefaultCreditProducts() {
+		if err := db.FirstOrCreate(&product, model.CreditProduct{Code: product.Code}).Error; err != nil {
+			return fmt.Errorf("ошибка при создании кредитного продукта %s: %v", product.Code, err)
+		}
+	}
+
+	return nil
+}
+
 func addNumberField(db *gorm.DB) error {
 	// Обновляем существующие записи
 	var accounts []model.Account
@@ -0,0 +1,150 @@
+// Package document формирует документы по кредиту — кредитный договор и график платежей —
+// из шаблонов в форматах HTML и PDF
+package document
+
+import (
+	"FinanceGolang/src/model"
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+// templateFuncs функции форматирования, доступные в шаблонах
+var templateFuncs = map[string]any{
+	"money":     formatMoney,
+	"rate":      formatRate,
+	"date":      formatDate,
+	"rateType":  describeRateType,
+	"repayment": describeRepaymentType,
+}
+
+var (
+	htmlTemplates = htmltemplate.Must(htmltemplate.New("").Funcs(templateFuncs).ParseFS(templatesFS, "templates/*.html"))
+	textTemplates = texttemplate.Must(texttemplate.New("").Funcs(templateFuncs).ParseFS(templatesFS, "templates/*.txt"))
+)
+
+// CreditData данные для заполнения документов по кредиту
+type CreditData struct {
+	BankName string
+	Date     time.Time // дата формирования документа
+	Credit   *model.Credit
+	Borrower *model.User
+	Schedule []model.PaymentSchedule
+}
+
+// Number возвращает номер договора: номер кредита и версия графика
+func (d *CreditData) Number() string {
+	return fmt.Sprintf("%d-%d", d.Credit.ID, d.Credit.ScheduleVersion)
+}
+
+// TotalAmount возвращает сумму всех платежей по графику
+func (d *CreditData) TotalAmount() float64 {
+	var total float64
+	for _, payment := range d.Schedule {
+		total += payment.TotalAmount
+	}
+	return total
+}
+
+// TotalPrincipal возвращает основной долг по графику
+func (d *CreditData) TotalPrincipal() float64 {
+	var total float64
+	for _, payment := range d.Schedule {
+		total += payment.Principal
+	}
+	return total
+}
+
+// TotalInterest возвращает проценты по графику
+func (d *CreditData) TotalInterest() float64 {
+	var total float64
+	for _, payment := range d.Schedule {
+		total += payment.Interest
+	}
+	return total
+}
+
+// Renderer заполняет шаблоны документов. PDF формируется с TTF-шрифтом из fontPath,
+// шрифт должен содержать кириллицу.
+type Renderer struct {
+	fontPath string
+}
+
+// NewRenderer создает генератор документов
+func NewRenderer(fontPath string) *Renderer {
+	return &Renderer{fontPath: fontPath}
+}
+
+// Render формирует документ вида docType в формате format
+func (r *Renderer) Render(docType model.CreditDocumentType, format model.CreditDocumentFormat, data *CreditData) ([]byte, error) {
+	name := strings.ToLower(string(docType))
+	switch format {
+	case model.CreditDocumentHTML:
+		var buf bytes.Buffer
+		if err := htmlTemplates.ExecuteTemplate(&buf, name+".html", data); err != nil {
+			return nil, fmt.Errorf("error rendering %s: %v", name, err)
+		}
+		return buf.Bytes(), nil
+	case model.CreditDocumentPDF:
+		var buf bytes.Buffer
+		if err := textTemplates.ExecuteTemplate(&buf, name+".txt", data); err != nil {
+			return nil, fmt.Errorf("error rendering %s: %v", name, err)
+		}
+		return r.renderPDF(buf.String(), data)
+	default:
+		return nil, fmt.Errorf("unsupported document format %s", format)
+	}
+}
+
+// formatMoney форматирует сумму с разделением разрядов: 1 234 567,89
+func formatMoney(amount float64) string {
+	s := fmt.Sprintf("%.2f", amount)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	integer, fraction := s[:len(s)-3], s[len(s)-2:]
+
+	var groups []string
+	for len(integer) > 3 {
+		groups = append([]string{integer[len(integer)-3:]}, groups...)
+		integer = integer[:len(integer)-3]
+	}
+	groups = append([]string{integer}, groups...)
+	return sign + strings.Join(groups, " ") + "," + fraction
+}
+
+// formatRate форматирует ставку в процентах: 12,5
+func formatRate(rate float64) string {
+	s := strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", rate), "0"), ".")
+	return strings.Replace(s, ".", ",", 1)
+}
+
+// formatDate форматирует дату: 02.01.2006
+func formatDate(date time.Time) string {
+	return date.Format("02.01.2006")
+}
+
+// describeRateType описывает способ определения ставки кредита
+func describeRateType(credit *model.Credit) string {
+	if credit.IsFloating() {
+		return fmt.Sprintf("плавающая: ключевая ставка Банка России (%s %% на дату расчета) плюс %s %%",
+			formatRate(credit.KeyRate), formatRate(credit.RateMargin))
+	}
+	return "фиксированная"
+}
+
+// describeRepaymentType описывает способ погашения кредита
+func describeRepaymentType(repaymentType model.RepaymentType) string {
+	if repaymentType == model.RepaymentTypeDifferentiated {
+		return "дифференцированные платежи: равные доли основного долга и проценты на остаток"
+	}
+	return "аннуитетные (равные) платежи"
+}
@@ -0,0 +1,124 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Разметка текстовых шаблонов PDF: строка с префиксом "# " — заголовок документа,
+// "^ " — строка по центру, "## " — заголовок раздела, строка "[schedule]" —
+// таблица графика платежей, остальные строки — абзацы
+const (
+	pdfTitlePrefix   = "# "
+	pdfCenterPrefix  = "^ "
+	pdfHeadingPrefix = "## "
+	pdfScheduleLine  = "[schedule]"
+)
+
+const (
+	pdfFontFamily = "DejaVu"
+	pdfMargin     = 15.0
+	pdfLineHeight = 5.0
+	pdfRowHeight  = 6.0
+)
+
+// pdfScheduleColumns заголовки и ширина колонок таблицы графика платежей, мм
+var pdfScheduleColumns = []struct {
+	title string
+	width float64
+}{
+	{"№", 15},
+	{"Дата платежа", 35},
+	{"Платеж, руб.", 45},
+	{"Основной долг, руб.", 45},
+	{"Проценты, руб.", 40},
+}
+
+// renderPDF верстает заполненный текстовый шаблон в PDF
+func (r *Renderer) renderPDF(text string, data *CreditData) ([]byte, error) {
+	font, err := os.ReadFile(r.fontPath)
+	if err != nil {
+		return nil, fmt.Errorf("document font not found: %v", err)
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.AddUTF8FontFromBytes(pdfFontFamily, "", font)
+	pdf.SetCreationDate(data.Date)
+	pdf.SetAuthor(data.BankName, true)
+	pdf.SetCreator(data.BankName, true)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 5)
+		pdf.SetFont(pdfFontFamily, "", 8)
+		pdf.CellFormat(0, 5, "Договор № "+data.Number()+" · стр. "+strconv.Itoa(pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \r")
+		switch {
+		case line == "":
+			pdf.Ln(2)
+		case line == pdfScheduleLine:
+			writeScheduleTable(pdf, data)
+		case strings.HasPrefix(line, pdfTitlePrefix):
+			title := strings.TrimPrefix(line, pdfTitlePrefix)
+			pdf.SetTitle(title, true)
+			pdf.SetFont(pdfFontFamily, "", 15)
+			pdf.MultiCell(0, 8, title, "", "C", false)
+		case strings.HasPrefix(line, pdfCenterPrefix):
+			pdf.SetFont(pdfFontFamily, "", 10)
+			pdf.MultiCell(0, pdfLineHeight, strings.TrimPrefix(line, pdfCenterPrefix), "", "C", false)
+		case strings.HasPrefix(line, pdfHeadingPrefix):
+			pdf.Ln(2)
+			pdf.SetFont(pdfFontFamily, "", 12)
+			pdf.MultiCell(0, 7, strings.TrimPrefix(line, pdfHeadingPrefix), "", "L", false)
+		default:
+			pdf.SetFont(pdfFontFamily, "", 10)
+			pdf.MultiCell(0, pdfLineHeight, line, "", "J", false)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("error generating pdf: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeScheduleTable выводит таблицу графика платежей, повторяя заголовок на каждой странице
+func writeScheduleTable(pdf *gofpdf.Fpdf, data *CreditData) {
+	_, pageHeight := pdf.GetPageSize()
+	writeHeader := func() {
+		pdf.SetFont(pdfFontFamily, "", 9)
+		pdf.SetFillColor(235, 235, 235)
+		for _, column := range pdfScheduleColumns {
+			pdf.CellFormat(column.width, pdfRowHeight, column.title, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(pdfRowHeight)
+	}
+	writeRow := func(cells ...string) {
+		if pdf.GetY()+pdfRowHeight > pageHeight-pdfMargin {
+			pdf.AddPage()
+			writeHeader()
+		}
+		for i, column := range pdfScheduleColumns {
+			pdf.CellFormat(column.width, pdfRowHeight, cells[i], "1", 0, "R", false, 0, "")
+		}
+		pdf.Ln(pdfRowHeight)
+	}
+
+	writeHeader()
+	for _, payment := range data.Schedule {
+		writeRow(strconv.Itoa(payment.PaymentNumber), formatDate(payment.DueDate),
+			formatMoney(payment.TotalAmount), formatMoney(payment.Principal), formatMoney(payment.Interest))
+	}
+	writeRow("", "Итого", formatMoney(data.TotalAmount()), formatMoney(data.TotalPrincipal()), formatMoney(data.TotalInterest()))
+	pdf.Ln(2)
+}
@@ -1,11 +1,18 @@
 package dto
 
+import "time"
+
 // Структура для выдачи информации о карте
 type UnsecureCard struct {
-	ID          uint   `json:"id"`
-	AccountID   uint   `json:"account_id"`
-	AccountName string `json:"account_name"`
-	Number      string `json:"number"`
-	ExpiryDate  string `json:"expiry_date"` // Срок действия карты (не зашифрованый).
-	CVV         string `json:"CVV"`         // CVV код (не хешированый).
+	ID            uint       `json:"id"`
+	AccountID     uint       `json:"account_id"`
+	AccountName   string     `json:"account_name"`
+	ProductCode   string     `json:"product_code"`
+	PaymentSystem string     `json:"payment_system"`
+	Number        string     `json:"number"`
+	ExpiryDate    string     `json:"expiry_date"` // Срок действия карты (не зашифрованый).
+	CVV           string     `json:"CVV"`         // CVV код (не хешированый).
+	SingleUse     bool       `json:"single_use,omitempty"`
+	AmountCap     float64    `json:"amount_cap,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
 }
@@ -0,0 +1,179 @@
+package iso8583
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CardRequest данные карты и операции, которые терминал отправляет в шлюз
+type CardRequest struct {
+	PAN        string
+	ExpiryDate string // MM/YY
+	CVV        string
+	Amount     float64
+	MCC        string
+	EntryMode  string // способ ввода карты, по умолчанию чип
+	Cash       bool   // выдача наличных вместо покупки
+	Merchant   string
+	Country    string // код страны ТСП, по умолчанию страна банка
+	RRN        string // пустой RRN генерируется клиентом
+}
+
+// Client простой терминал/эквайер: отправляет запросы в шлюз по одному TCP-соединению
+// и ждет ответа на каждый запрос
+type Client struct {
+	TerminalID string
+	MerchantID string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	timeout time.Duration
+	stan    int
+}
+
+// Dial подключается к шлюзу. timeout ограничивает ожидание ответа на каждый запрос.
+func Dial(addr, terminalID, merchantID string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to gateway %s: %v", addr, err)
+	}
+	return &Client{
+		TerminalID: terminalID,
+		MerchantID: merchantID,
+		conn:       conn,
+		timeout:    timeout,
+	}, nil
+}
+
+// Close закрывает соединение
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Send отправляет запрос и возвращает ответ. Поля 7, 11, 41 и 42 заполняются, если не заданы.
+func (c *Client) Send(request *Message) (*Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stan = c.stan%999999 + 1
+	now := time.Now()
+	setDefault(request, FieldTransmissionTime, now.Format("0102150405"))
+	setDefault(request, FieldSTAN, fmt.Sprintf("%06d", c.stan))
+	if request.MTI != MTINetworkRequest {
+		setDefault(request, FieldTerminalID, c.TerminalID)
+		setDefault(request, FieldMerchantID, c.MerchantID)
+	}
+
+	if err := c.conn.SetDeadline(now.Add(c.timeout)); err != nil {
+		return nil, err
+	}
+	if err := WriteMessage(c.conn, request); err != nil {
+		return nil, fmt.Errorf("error sending %s: %v", request.MTI, err)
+	}
+	response, err := ReadMessage(c.conn)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response to %s: %v", request.MTI, err)
+	}
+	if response.Get(FieldSTAN) != request.Get(FieldSTAN) {
+		return nil, fmt.Errorf("response STAN %s does not match request %s", response.Get(FieldSTAN), request.Get(FieldSTAN))
+	}
+	return response, nil
+}
+
+// SignOn отправляет сообщение входа в сеть (0800)
+func (c *Client) SignOn() (*Message, error) {
+	return c.Send(NewMessage(MTINetworkRequest).Set(FieldNetworkCode, NetworkCodeSignOn))
+}
+
+// Echo отправляет эхо-тест (0800)
+func (c *Client) Echo() (*Message, error) {
+	return c.Send(NewMessage(MTINetworkRequest).Set(FieldNetworkCode, NetworkCodeEcho))
+}
+
+// Authorize запрашивает авторизацию с блокировкой средств (0100)
+func (c *Client) Authorize(req CardRequest) (*Message, error) {
+	return c.Send(c.cardMessage(MTIAuthorizationRequest, req))
+}
+
+// Purchase отправляет финансовую операцию (0200). С RRN ранее одобренной авторизации
+// подтверждает ее списание, иначе проводит одностадийную оплату.
+func (c *Client) Purchase(req CardRequest) (*Message, error) {
+	return c.Send(c.cardMessage(MTIFinancialRequest, req))
+}
+
+// Reverse отменяет операцию терминала с указанным RRN (0400)
+func (c *Client) Reverse(rrn string, amount float64) (*Message, error) {
+	return c.Send(NewMessage(MTIReversalRequest).
+		Set(FieldProcessingCode, ProcessingCodePurchase).
+		Set(FieldAmount, formatAmount(amount)).
+		Set(FieldRRN, rrn))
+}
+
+// cardMessage собирает запрос по карте
+func (c *Client) cardMessage(mti string, req CardRequest) *Message {
+	processingCode := ProcessingCodePurchase
+	if req.Cash {
+		processingCode = ProcessingCodeCash
+	}
+	entryMode := req.EntryMode
+	if entryMode == "" {
+		entryMode = EntryModeChip
+	}
+	rrn := req.RRN
+	if rrn == "" {
+		rrn = c.nextRRN()
+	}
+
+	message := NewMessage(mti).
+		Set(FieldPAN, req.PAN).
+		Set(FieldProcessingCode, processingCode).
+		Set(FieldAmount, formatAmount(req.Amount)).
+		Set(FieldMCC, req.MCC).
+		Set(FieldPOSEntryMode, entryMode).
+		Set(FieldRRN, rrn)
+
+	// Срок действия на карте MM/YY, в сообщении YYMM
+	if parts := strings.Split(req.ExpiryDate, "/"); len(parts) == 2 {
+		message.Set(FieldExpiryDate, parts[1]+parts[0])
+	}
+	if req.CVV != "" {
+		message.Set(FieldAdditionalData, req.CVV)
+	}
+	if req.Merchant != "" || req.Country != "" {
+		message.Set(FieldCardAcceptor, formatCardAcceptor(req.Merchant, req.Country))
+	}
+	return message
+}
+
+// nextRRN генерирует RRN: год, день года, час и следующий STAN
+func (c *Client) nextRRN() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	return fmt.Sprintf("%d%03d%02d%06d", now.Year()%10, now.YearDay(), now.Hour(), c.stan%999999+1)
+}
+
+// formatAmount переводит сумму в копейки для поля 4
+func formatAmount(amount float64) string {
+	return fmt.Sprintf("%d", int64(math.Round(amount*100)))
+}
+
+// formatCardAcceptor заполняет поле 43: наименование (23), город (13), регион (2), страна (2)
+func formatCardAcceptor(merchant, country string) string {
+	if len(merchant) > 23 {
+		merchant = merchant[:23]
+	}
+	return fmt.Sprintf("%-23s%-13s%-2s%-2s", merchant, "", "", country)
+}
+
+// setDefault устанавливает поле, если оно не задано
+func setDefault(message *Message, field int, value string) {
+	if !message.Has(field) {
+		message.Set(field, value)
+	}
+}
@@ -0,0 +1,313 @@
+// Package iso8583 реализует подмножество протокола ISO 8583 (версия 1987, ASCII)
+// для симулятора карточного шлюза: кодек сообщений, TCP-сервер и клиент терминала.
+package iso8583
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidMTI       = errors.New("invalid message type indicator")
+	ErrUnsupportedField = errors.New("unsupported field")
+	ErrInvalidField     = errors.New("invalid field value")
+	ErrMessageTooLarge  = errors.New("message too large")
+)
+
+// Типы сообщений (MTI)
+const (
+	MTIAuthorizationRequest  = "0100"
+	MTIAuthorizationResponse = "0110"
+	MTIFinancialRequest      = "0200"
+	MTIFinancialResponse     = "0210"
+	MTIReversalRequest       = "0400"
+	MTIReversalResponse      = "0410"
+	MTINetworkRequest        = "0800"
+	MTINetworkResponse       = "0810"
+)
+
+// Поддерживаемые поля сообщения
+const (
+	FieldPAN              = 2
+	FieldProcessingCode   = 3
+	FieldAmount           = 4  // сумма в копейках
+	FieldTransmissionTime = 7  // MMDDhhmmss
+	FieldSTAN             = 11 // номер операции в терминале
+	FieldLocalTime        = 12 // hhmmss
+	FieldLocalDate        = 13 // MMDD
+	FieldExpiryDate       = 14 // YYMM
+	FieldMCC              = 18
+	FieldPOSEntryMode     = 22
+	FieldRRN              = 37
+	FieldAuthCode         = 38
+	FieldResponseCode     = 39
+	FieldTerminalID       = 41
+	FieldMerchantID       = 42
+	FieldCardAcceptor     = 43 // наименование и адрес ТСП, в позициях 39-40 код страны
+	FieldAdditionalData   = 48 // CVV2
+	FieldNetworkCode      = 70
+)
+
+// maxMessageLength ограничение длины сообщения с двухбайтовым заголовком
+const maxMessageLength = 1<<16 - 1
+
+type fieldType int
+
+const (
+	fieldNumeric fieldType = iota // n: цифры, дополняются нулями слева
+	fieldAlpha                    // an/ans: дополняются пробелами справа
+)
+
+type fieldSpec struct {
+	kind      fieldType
+	length    int // фиксированная или максимальная длина
+	lenDigits int // 0 для полей фиксированной длины, 2 для LLVAR, 3 для LLLVAR
+}
+
+var fieldSpecs = map[int]fieldSpec{
+	FieldPAN:              {fieldNumeric, 19, 2},
+	FieldProcessingCode:   {fieldNumeric, 6, 0},
+	FieldAmount:           {fieldNumeric, 12, 0},
+	FieldTransmissionTime: {fieldNumeric, 10, 0},
+	FieldSTAN:             {fieldNumeric, 6, 0},
+	FieldLocalTime:        {fieldNumeric, 6, 0},
+	FieldLocalDate:        {fieldNumeric, 4, 0},
+	FieldExpiryDate:       {fieldNumeric, 4, 0},
+	FieldMCC:              {fieldNumeric, 4, 0},
+	FieldPOSEntryMode:     {fieldNumeric, 3, 0},
+	FieldRRN:              {fieldAlpha, 12, 0},
+	FieldAuthCode:         {fieldAlpha, 6, 0},
+	FieldResponseCode:     {fieldAlpha, 2, 0},
+	FieldTerminalID:       {fieldAlpha, 8, 0},
+	FieldMerchantID:       {fieldAlpha, 15, 0},
+	FieldCardAcceptor:     {fieldAlpha, 40, 0},
+	FieldAdditionalData:   {fieldAlpha, 999, 3},
+	FieldNetworkCode:      {fieldNumeric, 3, 0},
+}
+
+var (
+	mtiPattern     = regexp.MustCompile(`^\d{4}$`)
+	numericPattern = regexp.MustCompile(`^\d*$`)
+)
+
+// Message сообщение ISO 8583. Значения полей хранятся без дополнения до фиксированной длины.
+type Message struct {
+	MTI    string
+	fields map[int]string
+}
+
+// NewMessage создает сообщение указанного типа
+func NewMessage(mti string) *Message {
+	return &Message{
+		MTI:    mti,
+		fields: make(map[int]string),
+	}
+}
+
+// Set устанавливает значение поля
+func (m *Message) Set(field int, value string) *Message {
+	m.fields[field] = value
+	return m
+}
+
+// Get возвращает значение поля или пустую строку
+func (m *Message) Get(field int) string {
+	return m.fields[field]
+}
+
+// Has проверяет, установлено ли поле
+func (m *Message) Has(field int) bool {
+	_, ok := m.fields[field]
+	return ok
+}
+
+// Fields возвращает номера установленных полей по возрастанию
+func (m *Message) Fields() []int {
+	fields := make([]int, 0, len(m.fields))
+	for field := range m.fields {
+		fields = append(fields, field)
+	}
+	sort.Ints(fields)
+	return fields
+}
+
+// Response создает ответ на сообщение: MTI ответа и поля, которые эквайер ожидает обратно
+func (m *Message) Response(responseCode string) *Message {
+	mti := m.MTI
+	if len(mti) == 4 {
+		mti = mti[:2] + strconv.Itoa((int(mti[2]-'0')+1)%10) + mti[3:]
+	}
+
+	response := NewMessage(mti)
+	for _, field := range []int{FieldPAN, FieldProcessingCode, FieldAmount, FieldTransmissionTime,
+		FieldSTAN, FieldRRN, FieldTerminalID, FieldMerchantID, FieldNetworkCode} {
+		if m.Has(field) {
+			response.Set(field, m.Get(field))
+		}
+	}
+	return response.Set(FieldResponseCode, responseCode)
+}
+
+// Pack кодирует сообщение: MTI, двоичный битмап (с дополнительным для полей 65-128) и поля
+func (m *Message) Pack() ([]byte, error) {
+	if !mtiPattern.MatchString(m.MTI) {
+		return nil, ErrInvalidMTI
+	}
+
+	fields := m.Fields()
+	bitmap := make([]byte, 8)
+	for _, field := range fields {
+		if field > 64 && len(bitmap) == 8 {
+			bitmap = append(bitmap, make([]byte, 8)...)
+			bitmap[0] |= 0x80
+		}
+	}
+
+	var body strings.Builder
+	for _, field := range fields {
+		spec, ok := fieldSpecs[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrUnsupportedField, field)
+		}
+		encoded, err := spec.encode(m.fields[field])
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %w", field, err)
+		}
+		bitmap[(field-1)/8] |= 0x80 >> uint((field-1)%8)
+		body.WriteString(encoded)
+	}
+
+	data := make([]byte, 0, 4+len(bitmap)+body.Len())
+	data = append(data, m.MTI...)
+	data = append(data, bitmap...)
+	data = append(data, body.String()...)
+	return data, nil
+}
+
+// Unpack декодирует сообщение, закодированное Pack
+func Unpack(data []byte) (*Message, error) {
+	if len(data) < 12 || !mtiPattern.Match(data[:4]) {
+		return nil, ErrInvalidMTI
+	}
+
+	m := NewMessage(string(data[:4]))
+	bitmap := data[4:12]
+	pos := 12
+	if bitmap[0]&0x80 != 0 {
+		if len(data) < 20 {
+			return nil, fmt.Errorf("%w: truncated secondary bitmap", ErrInvalidField)
+		}
+		bitmap = data[4:20]
+		pos = 20
+	}
+
+	// Бит 1 означает наличие дополнительного битмапа, а не поле данных
+	for field := 2; field <= len(bitmap)*8; field++ {
+		if bitmap[(field-1)/8]&(0x80>>uint((field-1)%8)) == 0 {
+			continue
+		}
+		spec, ok := fieldSpecs[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrUnsupportedField, field)
+		}
+		value, n, err := spec.decode(data[pos:])
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %w", field, err)
+		}
+		m.fields[field] = value
+		pos += n
+	}
+
+	if pos != len(data) {
+		return nil, fmt.Errorf("%w: %d unexpected trailing bytes", ErrInvalidField, len(data)-pos)
+	}
+	return m, nil
+}
+
+// ReadMessage читает сообщение с двухбайтовым заголовком длины (big-endian)
+func ReadMessage(r io.Reader) (*Message, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint16(header))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return Unpack(data)
+}
+
+// WriteMessage записывает сообщение с двухбайтовым заголовком длины (big-endian)
+func WriteMessage(w io.Writer, m *Message) error {
+	data, err := m.Pack()
+	if err != nil {
+		return err
+	}
+	if len(data) > maxMessageLength {
+		return ErrMessageTooLarge
+	}
+
+	frame := make([]byte, 2, 2+len(data))
+	binary.BigEndian.PutUint16(frame, uint16(len(data)))
+	_, err = w.Write(append(frame, data...))
+	return err
+}
+
+// encode дополняет значение до фиксированной длины или добавляет префикс длины
+func (s fieldSpec) encode(value string) (string, error) {
+	if len(value) > s.length {
+		return "", fmt.Errorf("%w: length %d exceeds %d", ErrInvalidField, len(value), s.length)
+	}
+	if s.kind == fieldNumeric && !numericPattern.MatchString(value) {
+		return "", fmt.Errorf("%w: not numeric", ErrInvalidField)
+	}
+
+	if s.lenDigits > 0 {
+		return fmt.Sprintf("%0*d%s", s.lenDigits, len(value), value), nil
+	}
+	if s.kind == fieldNumeric {
+		return strings.Repeat("0", s.length-len(value)) + value, nil
+	}
+	return value + strings.Repeat(" ", s.length-len(value)), nil
+}
+
+// decode читает значение поля и возвращает количество прочитанных байт
+func (s fieldSpec) decode(data []byte) (string, int, error) {
+	length := s.length
+	offset := 0
+	if s.lenDigits > 0 {
+		if len(data) < s.lenDigits {
+			return "", 0, fmt.Errorf("%w: truncated length prefix", ErrInvalidField)
+		}
+		// Префикс длины состоит только из цифр: strconv.Atoi пропустил бы знак
+		prefix := data[:s.lenDigits]
+		if !numericPattern.Match(prefix) {
+			return "", 0, fmt.Errorf("%w: bad length prefix", ErrInvalidField)
+		}
+		n, err := strconv.Atoi(string(prefix))
+		if err != nil || n > s.length {
+			return "", 0, fmt.Errorf("%w: bad length prefix", ErrInvalidField)
+		}
+		length = n
+		offset = s.lenDigits
+	}
+	if len(data) < offset+length {
+		return "", 0, fmt.Errorf("%w: truncated value", ErrInvalidField)
+	}
+
+	value := string(data[offset : offset+length])
+	if s.kind == fieldNumeric && !numericPattern.MatchString(value) {
+		return "", 0, fmt.Errorf("%w: not numeric", ErrInvalidField)
+	}
+	if s.kind == fieldAlpha && s.lenDigits == 0 {
+		value = strings.TrimRight(value, " ")
+	}
+	return value, offset + length, nil
+}
@@ -0,0 +1,138 @@
+package iso8583
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPackUnpackRoundTrip(t *testing.T) {
+	request := NewMessage(MTIAuthorizationRequest).
+		Set(FieldPAN, "4276123456789012").
+		Set(FieldProcessingCode, ProcessingCodePurchase).
+		Set(FieldAmount, "150000").
+		Set(FieldSTAN, "42").
+		Set(FieldExpiryDate, "2812").
+		Set(FieldMCC, "5411").
+		Set(FieldPOSEntryMode, EntryModeChip).
+		Set(FieldRRN, "612345000042").
+		Set(FieldTerminalID, "TERM0001").
+		Set(FieldMerchantID, "MERCHANT1").
+		Set(FieldCardAcceptor, formatCardAcceptor("SHOP", "RU")).
+		Set(FieldAdditionalData, "123").
+		Set(FieldNetworkCode, NetworkCodeEcho)
+
+	data, err := request.Pack()
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if data[4]&0x80 == 0 {
+		t.Fatalf("secondary bitmap bit is not set for field 70")
+	}
+
+	decoded, err := Unpack(data)
+	if err != nil {
+		t.Fatalf("Unpack: %v", err)
+	}
+	if decoded.MTI != request.MTI {
+		t.Errorf("MTI = %q, want %q", decoded.MTI, request.MTI)
+	}
+
+	// Числовые поля фиксированной длины возвращаются дополненными нулями
+	want := map[int]string{FieldSTAN: "000042", FieldAmount: "000000150000"}
+	for _, field := range request.Fields() {
+		expected, ok := want[field]
+		if !ok {
+			expected = request.Get(field)
+		}
+		if got := decoded.Get(field); got != expected {
+			t.Errorf("field %d = %q, want %q", field, got, expected)
+		}
+	}
+	if len(decoded.Fields()) != len(request.Fields()) {
+		t.Errorf("decoded fields %v, want %v", decoded.Fields(), request.Fields())
+	}
+}
+
+func TestReadWriteMessage(t *testing.T) {
+	var buf bytes.Buffer
+	request := NewMessage(MTINetworkRequest).Set(FieldSTAN, "000001").Set(FieldNetworkCode, NetworkCodeSignOn)
+	if err := WriteMessage(&buf, request); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+
+	decoded, err := ReadMessage(&buf)
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if decoded.MTI != MTINetworkRequest || decoded.Get(FieldNetworkCode) != NetworkCodeSignOn {
+		t.Errorf("decoded %s with network code %q", decoded.MTI, decoded.Get(FieldNetworkCode))
+	}
+}
+
+func TestPackInvalidField(t *testing.T) {
+	tests := map[string]*Message{
+		"invalid MTI":       NewMessage("01A0"),
+		"too long":          NewMessage(MTIAuthorizationRequest).Set(FieldPAN, "12345678901234567890"),
+		"not numeric":       NewMessage(MTIAuthorizationRequest).Set(FieldAmount, "10.00"),
+		"unsupported field": NewMessage(MTIAuthorizationRequest).Set(5, "1"),
+	}
+	for name, message := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := message.Pack(); err == nil {
+				t.Fatal("Pack succeeded, want error")
+			}
+		})
+	}
+}
+
+// bitmap возвращает первичный битмап с указанными полями
+func bitmap(fields ...int) []byte {
+	b := make([]byte, 8)
+	for _, field := range fields {
+		b[(field-1)/8] |= 0x80 >> uint((field-1)%8)
+	}
+	return b
+}
+
+// frame собирает тело сообщения из MTI, битмапа и данных полей
+func frame(mti string, bitmap []byte, body string) []byte {
+	data := append([]byte(mti), bitmap...)
+	return append(data, body...)
+}
+
+func TestUnpackMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrInvalidMTI},
+		{"short header", []byte("0100"), ErrInvalidMTI},
+		{"non-numeric MTI", frame("01X0", bitmap(), ""), ErrInvalidMTI},
+		{"negative LLVAR length", frame("0100", bitmap(FieldPAN), "-1X"), ErrInvalidField},
+		{"signed LLVAR length", frame("0100", bitmap(FieldPAN), "+1X"), ErrInvalidField},
+		{"non-digit LLVAR length", frame("0100", bitmap(FieldPAN), "1X"), ErrInvalidField},
+		{"negative LLLVAR length", frame("0100", bitmap(FieldAdditionalData), "-01X"), ErrInvalidField},
+		{"LLVAR length above maximum", frame("0100", bitmap(FieldPAN), "20"+"12345678901234567890"), ErrInvalidField},
+		{"truncated length prefix", frame("0100", bitmap(FieldPAN), "1"), ErrInvalidField},
+		{"truncated LLVAR value", frame("0100", bitmap(FieldPAN), "164276"), ErrInvalidField},
+		{"truncated fixed value", frame("0100", bitmap(FieldAmount), "1500"), ErrInvalidField},
+		{"non-numeric fixed value", frame("0100", bitmap(FieldMCC), "54A1"), ErrInvalidField},
+		{"trailing bytes", frame("0100", bitmap(FieldMCC), "5411XX"), ErrInvalidField},
+		{"truncated secondary bitmap", frame("0800", []byte{0x80, 0, 0, 0, 0, 0, 0, 0}, "0000"), ErrInvalidField},
+		{"unsupported field", frame("0100", bitmap(5), "000000000001"), ErrUnsupportedField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := Unpack(tt.data)
+			if err == nil {
+				t.Fatalf("Unpack succeeded with fields %v, want error", message.Fields())
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Unpack error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package iso8583
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/service"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Коды ответа (поле 39)
+const (
+	ResponseApproved           = "00"
+	ResponseDoNotHonor         = "05"
+	ResponseInvalidTransaction = "12"
+	ResponseInvalidAmount      = "13"
+	ResponseInvalidCardNumber  = "14"
+	ResponseOriginalNotFound   = "25"
+	ResponseFormatError        = "30"
+	ResponseInsufficientFunds  = "51"
+	ResponseExpiredCard        = "54"
+	ResponseNotPermitted       = "57"
+	ResponseExceedsLimit       = "61"
+	ResponseRestrictedCard     = "62"
+	ResponseInvalidCVV         = "82"
+	ResponseDuplicate          = "94"
+	ResponseSystemMalfunction  = "96"
+)
+
+// Коды обработки (первые две цифры поля 3) и коды управления сетью (поле 70)
+const (
+	ProcessingCodePurchase = "000000"
+	ProcessingCodeCash     = "010000"
+	NetworkCodeSignOn      = "001"
+	NetworkCodeSignOff     = "002"
+	NetworkCodeEcho        = "301"
+)
+
+// Способы ввода карты (поле 22)
+const (
+	EntryModeManual      = "012"
+	EntryModeMagStripe   = "902"
+	EntryModeChip        = "051"
+	EntryModeContactless = "071"
+	EntryModeECommerce   = "812"
+)
+
+// cardAcceptorCountryPosition позиция кода страны в поле 43
+const cardAcceptorCountryPosition = 38
+
+// declineResponseCodes соответствие причин отказа кодам ответа
+var declineResponseCodes = map[model.DeclineReason]string{
+	model.DeclineCardNotFound:         ResponseInvalidCardNumber,
+	model.DeclineCardInactive:         ResponseRestrictedCard,
+	model.DeclineCardExpired:          ResponseExpiredCard,
+	model.DeclineInvalidExpiry:        ResponseExpiredCard,
+	model.DeclineInvalidCVV:           ResponseInvalidCVV,
+	model.DeclineOnlineDisabled:       ResponseNotPermitted,
+	model.DeclineContactlessDisabled:  ResponseNotPermitted,
+	model.DeclineCashDisabled:         ResponseNotPermitted,
+	model.DeclineForeignDisabled:      ResponseNotPermitted,
+	model.DeclineMCCBlocked:           ResponseNotPermitted,
+	model.DeclineAmountCapExceeded:    ResponseExceedsLimit,
+	model.DeclineDailyLimitExceeded:   ResponseExceedsLimit,
+	model.DeclineMonthlyLimitExceeded: ResponseExceedsLimit,
+	model.DeclineInsufficientFunds:    ResponseInsufficientFunds,
+}
+
+// Server TCP-шлюз, принимающий сообщения ISO 8583 от эквайеров и терминалов
+// и проводящий их через CardProcessingService
+type Server struct {
+	addr       string
+	processing service.CardProcessingService
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// NewServer создает шлюз, который будет слушать адрес addr
+func NewServer(addr string, processing service.CardProcessingService) *Server {
+	return &Server{
+		addr:       addr,
+		processing: processing,
+		conns:      make(map[net.Conn]struct{}),
+	}
+}
+
+// ListenAndServe открывает TCP-порт и обрабатывает соединения до вызова Close
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve обрабатывает соединения на открытом listener до вызова Close
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return net.ErrClosed
+	}
+	s.listener = listener
+	s.mu.Unlock()
+
+	logrus.WithField("addr", listener.Addr().String()).Info("Шлюз ISO 8583 запущен")
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+// Addr возвращает адрес, на котором слушает шлюз, или nil до запуска
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close останавливает прием соединений и закрывает открытые соединения
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// serveConn последовательно обрабатывает запросы одного соединения
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	// Ошибка в обработке одного сообщения закрывает только это соединение,
+	// а не весь процесс вместе с API банка
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.WithFields(logrus.Fields{
+				"panic":  r,
+				"remote": conn.RemoteAddr().String(),
+			}).Error("Паника при обработке соединения ISO 8583")
+		}
+	}()
+
+	for {
+		request, err := ReadMessage(conn)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				logrus.WithError(err).Warn("Ошибка чтения сообщения ISO 8583")
+			}
+			return
+		}
+
+		response := s.Handle(request)
+		if err := WriteMessage(conn, response); err != nil {
+			logrus.WithError(err).Warn("Ошибка отправки ответа ISO 8583")
+			return
+		}
+	}
+}
+
+// Handle обрабатывает запрос и возвращает ответ
+func (s *Server) Handle(request *Message) *Message {
+	logger := logrus.WithFields(logrus.Fields{
+		"mti":      request.MTI,
+		"stan":     request.Get(FieldSTAN),
+		"rrn":      request.Get(FieldRRN),
+		"terminal": request.Get(FieldTerminalID),
+	})
+
+	var response *Message
+	switch request.MTI {
+	case MTIAuthorizationRequest:
+		response = s.handleCardOperation(request, s.processing.Authorize)
+	case MTIFinancialRequest:
+		response = s.handleCardOperation(request, s.processing.Capture)
+	case MTIReversalRequest:
+		response = s.handleReversal(request)
+	case MTINetworkRequest:
+		response = s.handleNetwork(request)
+	default:
+		response = request.Response(ResponseInvalidTransaction)
+	}
+
+	logger.WithField("response_code", response.Get(FieldResponseCode)).Info("Обработано сообщение ISO 8583")
+	return response
+}
+
+// handleCardOperation проводит авторизацию (0100) или финансовую операцию (0200)
+func (s *Server) handleCardOperation(
+	request *Message,
+	process func(op *service.CardOperation) (*model.CardAuthorization, model.DeclineReason, error),
+) *Message {
+	op, code := parseCardOperation(request)
+	if code != ResponseApproved {
+		return request.Response(code)
+	}
+
+	authorization, reason, err := process(op)
+	if err != nil {
+		return request.Response(errorResponseCode(err))
+	}
+	if reason != model.DeclineNone {
+		code, ok := declineResponseCodes[reason]
+		if !ok {
+			code = ResponseDoNotHonor
+		}
+		return request.Response(code)
+	}
+
+	return request.Response(ResponseApproved).Set(FieldAuthCode, authorization.AuthCode)
+}
+
+// handleReversal отменяет операцию (0400), найденную по терминалу и RRN
+func (s *Server) handleReversal(request *Message) *Message {
+	if request.Get(FieldTerminalID) == "" || request.Get(FieldRRN) == "" {
+		return request.Response(ResponseFormatError)
+	}
+
+	if _, err := s.processing.Reverse(request.Get(FieldTerminalID), request.Get(FieldRRN)); err != nil {
+		return request.Response(errorResponseCode(err))
+	}
+	return request.Response(ResponseApproved)
+}
+
+// handleNetwork отвечает на сообщения управления сетью (0800): вход, выход и эхо-тест
+func (s *Server) handleNetwork(request *Message) *Message {
+	switch request.Get(FieldNetworkCode) {
+	case NetworkCodeSignOn, NetworkCodeSignOff, NetworkCodeEcho:
+		return request.Response(ResponseApproved)
+	default:
+		return request.Response(ResponseInvalidTransaction)
+	}
+}
+
+// parseCardOperation переводит поля запроса в операцию по карте.
+// Второе значение - код ответа, если запрос некорректен.
+func parseCardOperation(request *Message) (*service.CardOperation, string) {
+	for _, field := range []int{FieldPAN, FieldProcessingCode, FieldAmount, FieldMCC, FieldRRN, FieldTerminalID} {
+		if request.Get(field) == "" {
+			return nil, ResponseFormatError
+		}
+	}
+
+	processingCode := request.Get(FieldProcessingCode)
+	if len(processingCode) != 6 {
+		return nil, ResponseFormatError
+	}
+	isCash := processingCode[:2] == ProcessingCodeCash[:2]
+	if processingCode[:2] != ProcessingCodePurchase[:2] && !isCash {
+		return nil, ResponseInvalidTransaction
+	}
+
+	minorUnits, err := strconv.ParseInt(request.Get(FieldAmount), 10, 64)
+	if err != nil || minorUnits <= 0 {
+		return nil, ResponseInvalidAmount
+	}
+
+	op := &service.CardOperation{
+		PAN:        request.Get(FieldPAN),
+		CVV:        request.Get(FieldAdditionalData),
+		TerminalID: request.Get(FieldTerminalID),
+		RRN:        request.Get(FieldRRN),
+		STAN:       request.Get(FieldSTAN),
+		Payment: model.CardPayment{
+			Amount:   float64(minorUnits) / 100,
+			MCC:      request.Get(FieldMCC),
+			Channel:  channelFromRequest(isCash, request.Get(FieldPOSEntryMode)),
+			Country:  model.HomeCountry,
+			Merchant: request.Get(FieldMerchantID),
+		},
+	}
+
+	// Срок действия передается как YYMM, на карте хранится MM/YY
+	if expiry := request.Get(FieldExpiryDate); len(expiry) == 4 {
+		op.ExpiryDate = expiry[2:] + "/" + expiry[:2]
+	}
+
+	if acceptor := request.Get(FieldCardAcceptor); acceptor != "" {
+		if name := strings.TrimSpace(acceptor[:min(len(acceptor), 23)]); name != "" {
+			op.Payment.Merchant = name
+		}
+		if len(acceptor) >= cardAcceptorCountryPosition+2 {
+			op.Payment.Country = strings.ToUpper(acceptor[cardAcceptorCountryPosition : cardAcceptorCountryPosition+2])
+		}
+	}
+
+	return op, ResponseApproved
+}
+
+// channelFromRequest определяет канал операции по типу операции и способу ввода карты (поле 22)
+func channelFromRequest(isCash bool, entryMode string) model.CardChannel {
+	if isCash {
+		return model.CardChannelATM
+	}
+	if len(entryMode) < 2 {
+		return model.CardChannelChip
+	}
+	switch entryMode[:2] {
+	case EntryModeManual[:2], EntryModeECommerce[:2]:
+		return model.CardChannelOnline
+	case EntryModeContactless[:2]:
+		return model.CardChannelContactless
+	default:
+		return model.CardChannelChip
+	}
+}
+
+// errorResponseCode подбирает код ответа для ошибки обработки
+func errorResponseCode(err error) string {
+	switch {
+	case errors.Is(err, model.ErrDuplicateAuthorization):
+		return ResponseDuplicate
+	case errors.Is(err, model.ErrAuthorizationNotFound):
+		return ResponseOriginalNotFound
+	case errors.Is(err, model.ErrCaptureExceedsHold), errors.Is(err, model.ErrInvalidAmount):
+		return ResponseInvalidAmount
+	case errors.Is(err, model.ErrAuthorizationNotHeld):
+		return ResponseInvalidTransaction
+	case errors.Is(err, model.ErrInvalidMCC), errors.Is(err, model.ErrInvalidChannel),
+		errors.Is(err, model.ErrInvalidCountry):
+		return ResponseFormatError
+	default:
+		logrus.WithError(err).Error("Ошибка обработки операции ISO 8583")
+		return ResponseSystemMalfunction
+	}
+}
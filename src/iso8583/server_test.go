@@ -0,0 +1,191 @@
+package iso8583
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/service"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testPAN        = "4276123456789012"
+	testPanicPAN   = "4276000000000000"
+	testPoorPAN    = "4276999999999999"
+	testTerminalID = "TERM0001"
+)
+
+// fakeProcessing проводит операции без базы данных: одобряет авторизации,
+// отклоняет карту testPoorPAN и паникует на карте testPanicPAN
+type fakeProcessing struct {
+	mu         sync.Mutex
+	operations []service.CardOperation
+	held       map[string]bool
+}
+
+func (p *fakeProcessing) Authorize(op *service.CardOperation) (*model.CardAuthorization, model.DeclineReason, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch op.PAN {
+	case testPanicPAN:
+		panic("processing failure")
+	case testPoorPAN:
+		return nil, model.DeclineInsufficientFunds, nil
+	}
+	p.operations = append(p.operations, *op)
+	p.held[op.TerminalID+"/"+op.RRN] = true
+	return &model.CardAuthorization{AuthCode: "A1B2C3"}, model.DeclineNone, nil
+}
+
+func (p *fakeProcessing) Capture(op *service.CardOperation) (*model.CardAuthorization, model.DeclineReason, error) {
+	return p.Authorize(op)
+}
+
+func (p *fakeProcessing) Reverse(terminalID, rrn string) (*model.CardAuthorization, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.held[terminalID+"/"+rrn] {
+		return nil, model.ErrAuthorizationNotFound
+	}
+	delete(p.held, terminalID+"/"+rrn)
+	return &model.CardAuthorization{}, nil
+}
+
+// startServer запускает шлюз на свободном порту и останавливает его по завершении теста
+func startServer(t *testing.T) (*Server, *fakeProcessing) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	processing := &fakeProcessing{held: make(map[string]bool)}
+	server := NewServer(listener.Addr().String(), processing)
+
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(listener) }()
+	for deadline := time.Now().Add(2 * time.Second); server.Addr() == nil; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("gateway did not start")
+		}
+	}
+	t.Cleanup(func() {
+		server.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return server, processing
+}
+
+func dialServer(t *testing.T, server *Server) *Client {
+	t.Helper()
+
+	client, err := Dial(server.Addr().String(), testTerminalID, "MERCHANT1", 2*time.Second)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func expectResponse(t *testing.T, response *Message, err error, mti, code string) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if response.MTI != mti || response.Get(FieldResponseCode) != code {
+		t.Fatalf("response %s with code %q, want %s with code %q",
+			response.MTI, response.Get(FieldResponseCode), mti, code)
+	}
+}
+
+func TestServerClientEndToEnd(t *testing.T) {
+	server, processing := startServer(t)
+	client := dialServer(t, server)
+
+	response, err := client.SignOn()
+	expectResponse(t, response, err, MTINetworkResponse, ResponseApproved)
+	response, err = client.Echo()
+	expectResponse(t, response, err, MTINetworkResponse, ResponseApproved)
+
+	response, err = client.Authorize(CardRequest{
+		PAN:        testPAN,
+		ExpiryDate: "12/28",
+		CVV:        "123",
+		Amount:     1500.50,
+		MCC:        "5411",
+		EntryMode:  EntryModeContactless,
+		Merchant:   "SHOP",
+		Country:    "KZ",
+		RRN:        "612345000001",
+	})
+	expectResponse(t, response, err, MTIAuthorizationResponse, ResponseApproved)
+	if response.Get(FieldAuthCode) != "A1B2C3" {
+		t.Errorf("auth code = %q, want A1B2C3", response.Get(FieldAuthCode))
+	}
+	if response.Get(FieldRRN) != "612345000001" || response.Get(FieldTerminalID) != testTerminalID {
+		t.Errorf("response RRN %q, terminal %q are not echoed", response.Get(FieldRRN), response.Get(FieldTerminalID))
+	}
+
+	op := processing.operations[0]
+	if op.PAN != testPAN || op.ExpiryDate != "12/28" || op.CVV != "123" || op.Payment.Amount != 1500.50 {
+		t.Errorf("operation card data = %+v", op)
+	}
+	if op.Payment.Channel != model.CardChannelContactless || op.Payment.Country != "KZ" || op.Payment.Merchant != "SHOP" {
+		t.Errorf("operation payment = %+v", op.Payment)
+	}
+
+	response, err = client.Authorize(CardRequest{PAN: testPoorPAN, Amount: 10, MCC: "5411"})
+	expectResponse(t, response, err, MTIAuthorizationResponse, ResponseInsufficientFunds)
+
+	response, err = client.Reverse("612345000001", 1500.50)
+	expectResponse(t, response, err, MTIReversalResponse, ResponseApproved)
+	response, err = client.Reverse("612345000001", 1500.50)
+	expectResponse(t, response, err, MTIReversalResponse, ResponseOriginalNotFound)
+}
+
+func TestServerRejectsMalformedFrame(t *testing.T) {
+	server, _ := startServer(t)
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Поле 2 (LLVAR) с отрицательным префиксом длины
+	data := frame("0100", bitmap(FieldPAN), "-1X")
+	header := make([]byte, 2)
+	binary.BigEndian.PutUint16(header, uint16(len(data)))
+	if _, err := conn.Write(append(header, data...)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("read after malformed frame: %v, want connection closed", err)
+	}
+
+	// Шлюз продолжает обслуживать другие соединения
+	response, err := dialServer(t, server).Echo()
+	expectResponse(t, response, err, MTINetworkResponse, ResponseApproved)
+}
+
+func TestServerRecoversFromPanic(t *testing.T) {
+	server, _ := startServer(t)
+
+	client := dialServer(t, server)
+	if _, err := client.Authorize(CardRequest{PAN: testPanicPAN, Amount: 10, MCC: "5411"}); err == nil {
+		t.Fatal("request that panicked in processing got a response")
+	}
+
+	response, err := dialServer(t, server).Echo()
+	expectResponse(t, response, err, MTINetworkResponse, ResponseApproved)
+}
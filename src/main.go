@@ -4,10 +4,22 @@ import (
 	"FinanceGolang/src/config"
 	"FinanceGolang/src/controller"
 	"FinanceGolang/src/database"
+	"FinanceGolang/src/document"
+	"FinanceGolang/src/iso8583"
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"FinanceGolang/src/security"
+	"FinanceGolang/src/service"
 	"fmt"
 	"log"
+	"os"
+
+	"gorm.io/gorm"
 )
 
+// devCardHMACSecret используется вне production, если CARD_HMAC_SECRET не задан
+const devCardHMACSecret = "dev_card_hmac_secret"
+
 func main() {
 	// Загрузка конфигурации
 	if err := config.Init(); err != nil {
@@ -15,6 +27,27 @@ func main() {
 	}
 	cfg := config.Get()
 
+	// Загрузка ключей шифрования карт. В production ключи не генерируются автоматически
+	cardKeys, err := security.LoadKeyManager(cfg.CardKeysDir, !cfg.IsProduction())
+	if err != nil {
+		log.Fatalf("Ошибка загрузки ключей шифрования карт: %v", err)
+	}
+	// Ключи подписи банка для выгрузок в бюро кредитных историй
+	bankKeys, err := security.LoadSigningKeyManager(cfg.BankKeysDir, !cfg.IsProduction())
+	if err != nil {
+		log.Fatalf("Ошибка загрузки ключей подписи банка: %v", err)
+	}
+	cardHMACSecret, err := loadCardHMACSecret(cfg)
+	if err != nil {
+		log.Fatalf("Ошибка загрузки секрета индекса карт: %v", err)
+	}
+	if err := checkOTPNotifier(cfg); err != nil {
+		log.Fatalf("Ошибка настройки доставки одноразовых кодов: %v", err)
+	}
+	if err := checkCreditBureauClient(cfg); err != nil {
+		log.Fatalf("Ошибка настройки бюро кредитных историй: %v", err)
+	}
+
 	// Инициализация базы данных
 	db, err := database.InitDB()
 	if err != nil {
@@ -28,11 +61,61 @@ func main() {
 		log.Fatalf("Ошибка миграции базы данных: %v", err)
 	}
 
+	// Заполняем слепой индекс для карт, выпущенных до его появления
+	failed, err := newCardService(db, cardKeys, cardHMACSecret).BackfillNumberHashes()
+	if err != nil {
+		log.Fatalf("Ошибка заполнения индекса номеров карт: %v", err)
+	}
+	if failed > 0 {
+		log.Printf("Индекс номера не заполнен для карт: %d", failed)
+	}
+
+	// Сохраняем график платежей для кредитов, оформленных до его хранения
+	backfilled, err := newCreditService(db).BackfillPaymentSchedules()
+	if err != nil {
+		log.Fatalf("Ошибка заполнения графиков платежей по кредитам: %v", err)
+	}
+	if backfilled > 0 {
+		log.Printf("Сохранен график платежей для кредитов: %d", backfilled)
+	}
+
+	// Формируем документы по кредитам, выданным до их формирования при выдаче
+	documented, err := newCreditDocumentService(db, cfg, bankKeys).BackfillDocuments()
+	if err != nil {
+		log.Fatalf("Ошибка формирования документов по кредитам: %v", err)
+	}
+	if documented > 0 {
+		log.Printf("Сформированы документы по кредитам: %d", documented)
+	}
+
+	// Ротация ключей шифрования карт: go run src/main.go rotate-card-keys
+	if len(os.Args) > 1 && os.Args[1] == "rotate-card-keys" {
+		cardService := newCardService(db, cardKeys, cardHMACSecret)
+		if err := rotateCardKeys(cardKeys, cardService, cfg.CardKeyRotationBatch); err != nil {
+			log.Fatalf("Ошибка ротации ключей шифрования карт: %v", err)
+		}
+		return
+	}
+
+	// Симулятор карточного шлюза ISO 8583 для сквозного тестирования операций по картам
+	if cfg.ISO8583Enabled {
+		gateway := iso8583.NewServer(cfg.ISO8583Addr, newCardProcessingService(db, cardKeys, cardHMACSecret))
+		go func() {
+			if err := gateway.ListenAndServe(); err != nil {
+				log.Fatalf("Ошибка запуска шлюза ISO 8583: %v", err)
+			}
+		}()
+		defer gateway.Close()
+	}
+
 	// Инициализация контроллеров напрямую через Router
 	// Router создает все необходимые репозитории и сервисы внутри себя
 
 	// Инициализация контроллеров
-	router := controller.NewRouter()
+	router := controller.NewRouter(cfg, cardKeys, bankKeys, cardHMACSecret)
+
+	// Начисление неустойки и списание просроченных платежей по кредитам
+	router.StartScheduler()
 
 	// Настройка Gin и middleware
 	r := router.InitRoutes()
@@ -44,3 +127,114 @@ func main() {
 		log.Fatalf("Ошибка запуска сервера: %v", err)
 	}
 }
+
+// newCardService создает сервис карт для команд и шлюза, работающих вне Router
+func newCardService(db *gorm.DB, cardKeys *security.KeyManager, cardHMACSecret []byte) service.CardService {
+	return service.CardServiceInstance(
+		repository.CardRepositoryInstance(db),
+		repository.AccountRepositoryInstance(db),
+		repository.CardProductRepositoryInstance(db),
+		repository.TransactionRepositoryInstance(db),
+		cardKeys,
+		cardHMACSecret,
+	)
+}
+
+// newCardProcessingService создает сервис проведения операций по картам от терминалов
+func newCardProcessingService(db *gorm.DB, cardKeys *security.KeyManager, cardHMACSecret []byte) service.CardProcessingService {
+	return service.CardProcessingServiceInstance(
+		newCardService(db, cardKeys, cardHMACSecret),
+		repository.CardRepositoryInstance(db),
+		repository.AccountRepositoryInstance(db),
+		repository.TransactionRepositoryInstance(db),
+		repository.CardAuthorizationRepositoryInstance(db),
+	)
+}
+
+// newCreditService создает сервис кредитов для шагов запуска, работающих вне Router.
+// Ключевая ставка, неустойка, автосписание и документы этим шагам не нужны.
+func newCreditService(db *gorm.DB) service.CreditService {
+	return service.CreditServiceInstance(
+		repository.CreditRepositoryInstance(db),
+		repository.AccountRepositoryInstance(db),
+		repository.TransactionRepositoryInstance(db),
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+}
+
+// newCreditDocumentService создает сервис документов по кредитам для шагов запуска
+func newCreditDocumentService(db *gorm.DB, cfg *config.Config, bankKeys *security.KeyManager) service.CreditDocumentService {
+	return service.CreditDocumentServiceInstance(
+		repository.CreditDocumentRepositoryInstance(db),
+		repository.CreditRepositoryInstance(db),
+		repository.UserRepositoryInstance(db),
+		document.NewRenderer(cfg.DocumentFontPath),
+		bankKeys,
+		cfg.AppName,
+	)
+}
+
+// loadCardHMACSecret возвращает секрет слепого индекса номеров карт.
+// В production секрет обязателен, так как при его смене индекс перестает совпадать.
+func loadCardHMACSecret(cfg *config.Config) ([]byte, error) {
+	if cfg.CardHMACSecret != "" {
+		return []byte(cfg.CardHMACSecret), nil
+	}
+	if cfg.IsProduction() {
+		return nil, fmt.Errorf("CARD_HMAC_SECRET is not set")
+	}
+	log.Printf("CARD_HMAC_SECRET не задан, используется секрет для разработки")
+	return []byte(devCardHMACSecret), nil
+}
+
+// checkOTPNotifier проверяет доставку одноразовых кодов. В production коды отправляются
+// только по email: доставка в лог не передает код клиенту, а файл хранит коды открытым текстом.
+func checkOTPNotifier(cfg *config.Config) error {
+	if cfg.IsProduction() && cfg.OTPNotifier != "email" {
+		return fmt.Errorf("OTP_NOTIFIER=%q is not allowed in production, use email", cfg.OTPNotifier)
+	}
+	return nil
+}
+
+// checkCreditBureauClient проверяет клиент бюро кредитных историй. В production кредитные отчеты
+// запрашиваются только у бюро: скоринг по тестовым данным заглушки недопустим.
+func checkCreditBureauClient(cfg *config.Config) error {
+	switch cfg.CreditBureauClient {
+	case model.CreditBureauSourceHTTP:
+		if cfg.CreditBureauURL == "" {
+			return fmt.Errorf("CREDIT_BUREAU_URL is not set")
+		}
+	case model.CreditBureauSourceStub:
+		if cfg.IsProduction() {
+			return fmt.Errorf("CREDIT_BUREAU_CLIENT=%q is not allowed in production, use http", cfg.CreditBureauClient)
+		}
+	default:
+		return fmt.Errorf("unknown CREDIT_BUREAU_CLIENT=%q, use http or stub", cfg.CreditBureauClient)
+	}
+	return nil
+}
+
+// rotateCardKeys генерирует новую версию ключа и перешифровывает ей все карты.
+// Если часть карт осталась на старых ключах, возвращает ошибку: старые ключи
+// нельзя выводить из обращения, иначе данные этих карт будут потеряны.
+func rotateCardKeys(cardKeys *security.KeyManager, cardService service.CardService, batchSize int) error {
+	keyID, err := cardKeys.Rotate()
+	if err != nil {
+		return err
+	}
+	log.Printf("Сгенерирован новый ключ шифрования карт: %s", keyID)
+
+	count, failed, err := cardService.ReencryptCards(batchSize)
+	if err != nil {
+		return err
+	}
+	log.Printf("Перешифровано карт: %d", count)
+	if failed > 0 {
+		return fmt.Errorf("%d cards are still encrypted with old key versions", failed)
+	}
+
+	return nil
+}
@@ -23,38 +23,83 @@ const (
 
 type Account struct {
 	gorm.Model
-	Number        string     `json:"number" gorm:"unique;not null;default:''"`
-	Balance       float64    `json:"balance" gorm:"type:decimal(20,2);not null;default:0"`
-	UserID        uint       `json:"user_id" gorm:"not null"`
-	IsActive      bool       `json:"is_active" gorm:"default:true"`
-	InterestRate  float64    `json:"interest_rate" gorm:"type:decimal(5,2);default:0"`
-	LastOperation *time.Time `json:"last_operation"`
-	DailyLimit    float64    `json:"daily_limit" gorm:"type:decimal(20,2);default:100000"`
-	MonthlyLimit  float64    `json:"monthly_limit" gorm:"type:decimal(20,2);default:1000000"`
+	Number        string      `json:"number" gorm:"unique;not null;default:''"`
+	Balance       float64     `json:"balance" gorm:"type:decimal(20,2);not null;default:0"`
+	UserID        uint        `json:"user_id" gorm:"not null"`
+	IsActive      bool        `json:"is_active" gorm:"default:true"`
+	Type          AccountType `json:"type" gorm:"type:varchar(20);not null;default:'DEBIT'"`
+	CreditLimit   float64     `json:"credit_limit" gorm:"type:decimal(20,2);default:0"` // кредитный лимит счета типа CREDIT
+	InterestRate  float64     `json:"interest_rate" gorm:"type:decimal(5,2);default:0"`
+	LastOperation *time.Time  `json:"last_operation"`
+	DailyLimit    float64     `json:"daily_limit" gorm:"type:decimal(20,2);default:100000"`
+	MonthlyLimit  float64     `json:"monthly_limit" gorm:"type:decimal(20,2);default:1000000"`
 }
 
 // Validate проверяет все поля счета
 func (a *Account) Validate() error {
+	if err := a.ValidateType(); err != nil {
+		return err
+	}
 	if err := a.ValidateBalance(); err != nil {
 		return err
 	}
 	return nil
 }
 
-// ValidateBalance проверяет корректность баланса
+// ValidateType проверяет тип счета. Кредитный лимит устанавливается только для счета типа CREDIT.
+func (a *Account) ValidateType() error {
+	switch a.Type {
+	case "", AccountTypeDebit, AccountTypeSavings:
+		if a.CreditLimit != 0 {
+			return ErrInvalidAccountType
+		}
+	case AccountTypeCredit:
+		if a.CreditLimit < 0 {
+			return ErrInvalidBalance
+		}
+	default:
+		return ErrInvalidAccountType
+	}
+	return nil
+}
+
+// ValidateBalance проверяет корректность баланса. Отрицательный баланс кредитного счета —
+// задолженность по кредитному лимиту.
 func (a *Account) ValidateBalance() error {
-	if a.Balance < 0 {
+	if a.Balance < 0 && !a.IsCredit() {
 		return ErrInvalidBalance
 	}
 	return nil
 }
 
+// IsCredit проверяет, является ли счет кредитным
+func (a *Account) IsCredit() bool {
+	return a.Type == AccountTypeCredit
+}
+
+// AvailableBalance возвращает сумму, доступную для расходных операций:
+// для кредитного счета — остаток вместе с неиспользованным кредитным лимитом
+func (a *Account) AvailableBalance() float64 {
+	if a.IsCredit() {
+		return a.Balance + a.CreditLimit
+	}
+	return a.Balance
+}
+
+// Debt возвращает задолженность по кредитному лимиту
+func (a *Account) Debt() float64 {
+	if a.Balance >= 0 {
+		return 0
+	}
+	return -a.Balance
+}
+
 // CanWithdraw проверяет возможность снятия средств
 func (a *Account) CanWithdraw(amount float64) error {
 	if amount <= 0 {
 		return ErrInvalidBalance
 	}
-	if a.Balance < amount {
+	if a.AvailableBalance() < amount {
 		return ErrInsufficientFunds
 	}
 	return nil
@@ -87,6 +132,9 @@ func (a *Account) BeforeCreate(tx *gorm.DB) error {
 	if a.Number == "" {
 		a.Number = GenerateAccountNumber()
 	}
+	if a.Type == "" {
+		a.Type = AccountTypeDebit
+	}
 	return a.Validate()
 }
 
@@ -101,16 +149,19 @@ func (a *Account) BeforeUpdate(tx *gorm.DB) error {
 // ToDTO преобразует модель в DTO
 func (a *Account) ToDTO() map[string]interface{} {
 	return map[string]interface{}{
-		"id":             a.ID,
-		"number":         a.Number,
-		"balance":        a.Balance,
-		"is_active":      a.IsActive,
-		"interest_rate":  a.InterestRate,
-		"last_operation": a.LastOperation,
-		"daily_limit":    a.DailyLimit,
-		"monthly_limit":  a.MonthlyLimit,
-		"created_at":     a.CreatedAt,
-		"updated_at":     a.UpdatedAt,
+		"id":                a.ID,
+		"number":            a.Number,
+		"type":              a.Type,
+		"balance":           a.Balance,
+		"credit_limit":      a.CreditLimit,
+		"available_balance": a.AvailableBalance(),
+		"is_active":         a.IsActive,
+		"interest_rate":     a.InterestRate,
+		"last_operation":    a.LastOperation,
+		"daily_limit":       a.DailyLimit,
+		"monthly_limit":     a.MonthlyLimit,
+		"created_at":        a.CreatedAt,
+		"updated_at":        a.UpdatedAt,
 	}
 }
 
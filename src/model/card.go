@@ -13,20 +13,41 @@ var (
 	ErrInvalidExpiryDate = errors.New("invalid expiry date")
 	ErrInvalidCVV        = errors.New("invalid CVV")
 	ErrCardExpired       = errors.New("card has expired")
+	ErrCardNotActive     = errors.New("card is not active")
+	ErrCardCapExceeded   = errors.New("card amount cap exceeded")
 )
 
 // Card представляет модель данных банковской карты.
 type Card struct {
 	gorm.Model
-	Number       string    `json:"number" gorm:"type:text;not null" validate:"required"`
-	ExpiryDate   string    `json:"expiry_date" gorm:"type:text;not null" validate:"required"`
-	CVV          string    `json:"-" gorm:"type:text;not null" validate:"required"`
-	UserID       uint      `json:"user_id" gorm:"not null"`
-	AccountID    uint      `json:"account_id" gorm:"not null"`
-	IsActive     bool      `json:"is_active" gorm:"default:true"`
-	DailyLimit   float64   `json:"daily_limit" gorm:"default:100000"`
-	MonthlyLimit float64   `json:"monthly_limit" gorm:"default:1000000"`
-	LastUsed     time.Time `json:"last_used"`
+	Number        string          `json:"number" gorm:"type:text;not null" validate:"required"`
+	NumberHash    string          `json:"-" gorm:"type:varchar(64);uniqueIndex"` // слепой индекс номера (HMAC)
+	NumberKeyID   string          `json:"-" gorm:"type:varchar(16)"`             // версия ключа, которым зашифрован номер
+	ExpiryDate    string          `json:"expiry_date" gorm:"type:text;not null" validate:"required"`
+	ExpiryKeyID   string          `json:"-" gorm:"type:varchar(16)"` // версия ключа, которым зашифрован срок действия
+	CVV           string          `json:"-" gorm:"type:text;not null" validate:"required"`
+	UserID        uint            `json:"user_id" gorm:"not null"`
+	AccountID     uint            `json:"account_id" gorm:"not null"`
+	ProductID     uint            `json:"product_id"`
+	PaymentSystem PaymentSystem   `json:"payment_system" gorm:"type:varchar(20)"`
+	FundingType   CardFundingType `json:"funding_type" gorm:"type:varchar(20)"`
+	Format        CardFormat      `json:"format" gorm:"type:varchar(20)"`
+	IsActive      bool            `json:"is_active" gorm:"default:true"`
+	DailyLimit    float64         `json:"daily_limit" gorm:"default:100000"`
+	MonthlyLimit  float64         `json:"monthly_limit" gorm:"default:1000000"`
+	LastUsed      time.Time       `json:"last_used"`
+	SingleUse     bool            `json:"single_use" gorm:"default:false"`                  // закрывается после первой успешной оплаты
+	AmountCap     float64         `json:"amount_cap" gorm:"type:decimal(20,2);default:0"`   // лимит суммарных трат по карте, 0 - без лимита
+	SpentAmount   float64         `json:"spent_amount" gorm:"type:decimal(20,2);default:0"` // сумма успешных оплат картой
+	ExpiresAt     *time.Time      `json:"expires_at"`                                       // точный срок действия виртуальной карты
+	ClosedAt      *time.Time      `json:"closed_at"`
+
+	// Ограничения операций по карте
+	AllowOnline      bool     `json:"allow_online" gorm:"default:true"`
+	AllowContactless bool     `json:"allow_contactless" gorm:"default:true"`
+	AllowCash        bool     `json:"allow_cash" gorm:"default:true"`
+	AllowForeign     bool     `json:"allow_foreign" gorm:"default:true"`
+	BlockedMCCs      []string `json:"blocked_mccs" gorm:"column:blocked_mccs;type:text;serializer:json"`
 }
 
 // Validate проверяет все поля карты
@@ -96,8 +117,8 @@ func (c *Card) ValidateExpiryDate() error {
 		return ErrInvalidExpiryDate
 	}
 
-	// Проверка на истечение срока
-	if expiryTime.Before(time.Now()) {
+	// Карта действует до конца указанного месяца
+	if expiryTime.AddDate(0, 1, 0).Before(time.Now()) {
 		return ErrCardExpired
 	}
 
@@ -121,11 +142,92 @@ func (c *Card) IsExpired() bool {
 	if err != nil {
 		return true
 	}
-	return expiryTime.Before(time.Now())
+	if c.ExpiresAt != nil && c.ExpiresAt.Before(time.Now()) {
+		return true
+	}
+	return expiryTime.AddDate(0, 1, 0).Before(time.Now())
+}
+
+// CanPay проверяет, можно ли оплатить картой указанную сумму
+func (c *Card) CanPay(amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if !c.IsActive || c.ClosedAt != nil {
+		return ErrCardNotActive
+	}
+	if c.ExpiresAt != nil && c.ExpiresAt.Before(time.Now()) {
+		return ErrCardExpired
+	}
+	if c.AmountCap > 0 && c.SpentAmount+amount > c.AmountCap {
+		return ErrCardCapExceeded
+	}
+	return nil
+}
+
+// RegisterPayment учитывает успешную оплату картой.
+// Одноразовая карта закрывается после первой оплаты.
+func (c *Card) RegisterPayment(amount float64) {
+	now := time.Now()
+	c.SpentAmount += amount
+	c.LastUsed = now
+	if c.SingleUse {
+		c.Close()
+	}
+}
+
+// CheckControls проверяет операцию по ограничениям карты и возвращает код отказа
+func (c *Card) CheckControls(payment *CardPayment) DeclineReason {
+	switch {
+	case payment.Channel == CardChannelOnline && !c.AllowOnline:
+		return DeclineOnlineDisabled
+	case payment.Channel == CardChannelContactless && !c.AllowContactless:
+		return DeclineContactlessDisabled
+	case payment.IsCashWithdrawal() && !c.AllowCash:
+		return DeclineCashDisabled
+	case payment.IsForeign() && !c.AllowForeign:
+		return DeclineForeignDisabled
+	}
+	for _, mcc := range c.BlockedMCCs {
+		if mcc == payment.MCC {
+			return DeclineMCCBlocked
+		}
+	}
+	return DeclineNone
+}
+
+// ApplyControls применяет новые настройки ограничений
+func (c *Card) ApplyControls(controls *CardControls) {
+	if controls.AllowOnline != nil {
+		c.AllowOnline = *controls.AllowOnline
+	}
+	if controls.AllowContactless != nil {
+		c.AllowContactless = *controls.AllowContactless
+	}
+	if controls.AllowCash != nil {
+		c.AllowCash = *controls.AllowCash
+	}
+	if controls.AllowForeign != nil {
+		c.AllowForeign = *controls.AllowForeign
+	}
+	if controls.BlockedMCCs != nil {
+		c.BlockedMCCs = controls.BlockedMCCs
+	}
+}
+
+// Close закрывает карту
+func (c *Card) Close() {
+	now := time.Now()
+	c.IsActive = false
+	c.ClosedAt = &now
 }
 
 // GetCardType определяет тип карты по номеру
 func (c *Card) GetCardType() string {
+	// Номер хранится зашифрованным, поэтому для сохраненных карт берем платежную систему продукта
+	if c.PaymentSystem != "" {
+		return string(c.PaymentSystem)
+	}
 	// Visa
 	if matched, _ := regexp.MatchString(`^4[0-9]{12}(?:[0-9]{3})?$`, c.Number); matched {
 		return "VISA"
@@ -149,16 +251,32 @@ func (c *Card) BeforeUpdate(tx *gorm.DB) error {
 // ToDTO преобразует модель в DTO
 func (c *Card) ToDTO() map[string]interface{} {
 	return map[string]interface{}{
-		"id":            c.ID,
-		"number":        c.MaskNumber(),
-		"expiry_date":   c.ExpiryDate,
-		"is_active":     c.IsActive,
-		"daily_limit":   c.DailyLimit,
-		"monthly_limit": c.MonthlyLimit,
-		"last_used":     c.LastUsed,
-		"created_at":    c.CreatedAt,
-		"updated_at":    c.UpdatedAt,
-		"account_id":    c.AccountID,
+		"id":             c.ID,
+		"number":         c.MaskNumber(),
+		"expiry_date":    c.ExpiryDate,
+		"is_active":      c.IsActive,
+		"daily_limit":    c.DailyLimit,
+		"monthly_limit":  c.MonthlyLimit,
+		"last_used":      c.LastUsed,
+		"created_at":     c.CreatedAt,
+		"updated_at":     c.UpdatedAt,
+		"account_id":     c.AccountID,
+		"product_id":     c.ProductID,
+		"payment_system": c.PaymentSystem,
+		"funding_type":   c.FundingType,
+		"format":         c.Format,
+		"single_use":     c.SingleUse,
+		"amount_cap":     c.AmountCap,
+		"spent_amount":   c.SpentAmount,
+		"expires_at":     c.ExpiresAt,
+		"closed_at":      c.ClosedAt,
+		"controls": map[string]interface{}{
+			"allow_online":      c.AllowOnline,
+			"allow_contactless": c.AllowContactless,
+			"allow_cash":        c.AllowCash,
+			"allow_foreign":     c.AllowForeign,
+			"blocked_mccs":      c.BlockedMCCs,
+		},
 	}
 }
 
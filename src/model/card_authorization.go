@@ -0,0 +1,99 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAuthorizationNotHeld      = errors.New("authorization is not held")
+	ErrCaptureExceedsHold        = errors.New("capture amount exceeds held amount")
+	ErrAuthorizationNotFound     = errors.New("original authorization not found")
+	ErrDuplicateAuthorization    = errors.New("duplicate authorization")
+	ErrInvalidAuthorizationState = errors.New("invalid authorization status")
+)
+
+// CardAuthorizationStatus статус авторизации по карте
+type CardAuthorizationStatus string
+
+const (
+	// CardAuthorizationHeld средства заблокированы на счете до подтверждения
+	CardAuthorizationHeld CardAuthorizationStatus = "HELD"
+	// CardAuthorizationCaptured средства окончательно списаны
+	CardAuthorizationCaptured CardAuthorizationStatus = "CAPTURED"
+	// CardAuthorizationReversed авторизация отменена, средства возвращены
+	CardAuthorizationReversed CardAuthorizationStatus = "REVERSED"
+)
+
+// CardAuthorization авторизация операции по карте, полученная от терминала.
+// Связывает блокировку средств на счете с последующим списанием или отменой.
+type CardAuthorization struct {
+	gorm.Model
+	CardID        uint                    `json:"card_id" gorm:"index;not null"`
+	AccountID     uint                    `json:"account_id" gorm:"not null"`
+	TransactionID uint                    `json:"transaction_id"`
+	TerminalID    string                  `json:"terminal_id" gorm:"type:varchar(8);uniqueIndex:idx_card_auth_rrn"`
+	RRN           string                  `json:"rrn" gorm:"type:varchar(12);uniqueIndex:idx_card_auth_rrn"` // ссылочный номер операции
+	STAN          string                  `json:"stan" gorm:"type:varchar(6)"`                               // номер операции в терминале
+	AuthCode      string                  `json:"auth_code" gorm:"type:varchar(6)"`
+	Amount        float64                 `json:"amount" gorm:"type:decimal(20,2);not null"`
+	Status        CardAuthorizationStatus `json:"status" gorm:"type:varchar(20);not null"`
+	CapturedAt    *time.Time              `json:"captured_at"`
+	ReversedAt    *time.Time              `json:"reversed_at"`
+}
+
+// Validate проверяет все поля авторизации
+func (a *CardAuthorization) Validate() error {
+	if a.CardID == 0 || a.AccountID == 0 {
+		return errors.New("card and account are required")
+	}
+	if a.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	switch a.Status {
+	case CardAuthorizationHeld, CardAuthorizationCaptured, CardAuthorizationReversed:
+	default:
+		return ErrInvalidAuthorizationState
+	}
+	return nil
+}
+
+// Capture подтверждает списание суммы не больше заблокированной.
+// Возвращает сумму, которую нужно вернуть на счет.
+func (a *CardAuthorization) Capture(amount float64) (float64, error) {
+	if a.Status != CardAuthorizationHeld {
+		return 0, ErrAuthorizationNotHeld
+	}
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	if amount > a.Amount {
+		return 0, ErrCaptureExceedsHold
+	}
+
+	released := a.Amount - amount
+	now := time.Now()
+	a.Amount = amount
+	a.Status = CardAuthorizationCaptured
+	a.CapturedAt = &now
+	return released, nil
+}
+
+// Reverse отменяет авторизацию
+func (a *CardAuthorization) Reverse() {
+	now := time.Now()
+	a.Status = CardAuthorizationReversed
+	a.ReversedAt = &now
+}
+
+// BeforeCreate хук для валидации перед созданием
+func (a *CardAuthorization) BeforeCreate(tx *gorm.DB) error {
+	return a.Validate()
+}
+
+// BeforeUpdate хук для валидации перед обновлением
+func (a *CardAuthorization) BeforeUpdate(tx *gorm.DB) error {
+	return a.Validate()
+}
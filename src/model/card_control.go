@@ -0,0 +1,118 @@
+package model
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidMCC     = errors.New("invalid MCC code")
+	ErrInvalidChannel = errors.New("invalid payment channel")
+	ErrInvalidCountry = errors.New("invalid country code")
+)
+
+// HomeCountry страна банка: операции в других странах считаются зарубежными
+const HomeCountry = "RU"
+
+// CardChannel канал проведения операции по карте
+type CardChannel string
+
+const (
+	CardChannelOnline      CardChannel = "ONLINE"
+	CardChannelContactless CardChannel = "CONTACTLESS"
+	CardChannelChip        CardChannel = "CHIP"
+	CardChannelATM         CardChannel = "ATM"
+)
+
+// DeclineReason код причины отказа в операции по карте
+type DeclineReason string
+
+const (
+	DeclineNone                 DeclineReason = ""
+	DeclineCardNotFound         DeclineReason = "CARD_NOT_FOUND"
+	DeclineCardInactive         DeclineReason = "CARD_INACTIVE"
+	DeclineCardExpired          DeclineReason = "CARD_EXPIRED"
+	DeclineInvalidExpiry        DeclineReason = "INVALID_EXPIRY_DATE"
+	DeclineInvalidCVV           DeclineReason = "INVALID_CVV"
+	DeclineOnlineDisabled       DeclineReason = "ONLINE_DISABLED"
+	DeclineContactlessDisabled  DeclineReason = "CONTACTLESS_DISABLED"
+	DeclineCashDisabled         DeclineReason = "CASH_WITHDRAWAL_DISABLED"
+	DeclineForeignDisabled      DeclineReason = "FOREIGN_DISABLED"
+	DeclineMCCBlocked           DeclineReason = "MCC_BLOCKED"
+	DeclineAmountCapExceeded    DeclineReason = "AMOUNT_CAP_EXCEEDED"
+	DeclineDailyLimitExceeded   DeclineReason = "DAILY_LIMIT_EXCEEDED"
+	DeclineMonthlyLimitExceeded DeclineReason = "MONTHLY_LIMIT_EXCEEDED"
+	DeclineInsufficientFunds    DeclineReason = "INSUFFICIENT_FUNDS"
+	DeclineChallengeExpired     DeclineReason = "CHALLENGE_EXPIRED"
+	DeclineChallengeFailed      DeclineReason = "CHALLENGE_FAILED"
+)
+
+// cashMCCs коды категорий, означающие выдачу наличных
+var cashMCCs = map[string]bool{
+	"6010": true, // выдача наличных в отделении
+	"6011": true, // выдача наличных в банкомате
+}
+
+var (
+	mccPattern     = regexp.MustCompile(`^\d{4}$`)
+	countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// CardPayment параметры операции оплаты картой
+type CardPayment struct {
+	Amount      float64
+	MCC         string
+	Channel     CardChannel
+	Country     string
+	Merchant    string
+	Description string
+}
+
+// Validate проверяет параметры операции
+func (p *CardPayment) Validate() error {
+	if p.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if !mccPattern.MatchString(p.MCC) {
+		return ErrInvalidMCC
+	}
+	switch p.Channel {
+	case CardChannelOnline, CardChannelContactless, CardChannelChip, CardChannelATM:
+	default:
+		return ErrInvalidChannel
+	}
+	if !countryPattern.MatchString(p.Country) {
+		return ErrInvalidCountry
+	}
+	return nil
+}
+
+// IsCashWithdrawal проверяет, является ли операция выдачей наличных
+func (p *CardPayment) IsCashWithdrawal() bool {
+	return p.Channel == CardChannelATM || cashMCCs[p.MCC]
+}
+
+// IsForeign проверяет, проводится ли операция за рубежом
+func (p *CardPayment) IsForeign() bool {
+	return !strings.EqualFold(p.Country, HomeCountry)
+}
+
+// CardControls настройки ограничений по карте, nil поля не меняются
+type CardControls struct {
+	AllowOnline      *bool
+	AllowContactless *bool
+	AllowCash        *bool
+	AllowForeign     *bool
+	BlockedMCCs      []string
+}
+
+// Validate проверяет коды категорий в настройках
+func (c *CardControls) Validate() error {
+	for _, mcc := range c.BlockedMCCs {
+		if !mccPattern.MatchString(mcc) {
+			return ErrInvalidMCC
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPaymentSystem = errors.New("invalid payment system")
+	ErrInvalidBINRange      = errors.New("invalid BIN range")
+	ErrInvalidFundingType   = errors.New("invalid card funding type")
+	ErrInvalidCardFormat    = errors.New("invalid card format")
+	ErrCardProductNotFound  = errors.New("card product not found")
+	ErrCardProductInactive  = errors.New("card product is not active")
+)
+
+type PaymentSystem string
+
+const (
+	PaymentSystemVisa       PaymentSystem = "VISA"
+	PaymentSystemMastercard PaymentSystem = "MASTERCARD"
+	PaymentSystemMir        PaymentSystem = "MIR"
+)
+
+type CardFundingType string
+
+const (
+	CardFundingDebit  CardFundingType = "DEBIT"
+	CardFundingCredit CardFundingType = "CREDIT"
+)
+
+type CardFormat string
+
+const (
+	CardFormatPhysical CardFormat = "PHYSICAL"
+	CardFormatVirtual  CardFormat = "VIRTUAL"
+)
+
+const (
+	// DefaultCardProductCode продукт, который выпускается, если в запросе продукт не указан
+	DefaultCardProductCode = "VISA_DEBIT"
+	// VirtualCardProductCode продукт по умолчанию для виртуальных карт
+	VirtualCardProductCode = "VISA_VIRTUAL"
+)
+
+// binPattern BIN банка - первые 6 цифр номера карты
+var binPattern = regexp.MustCompile(`^\d{6}$`)
+
+// CardProduct описывает карточный продукт банка из каталога
+type CardProduct struct {
+	gorm.Model
+	Code          string          `json:"code" gorm:"unique;not null"`
+	Name          string          `json:"name" gorm:"not null"`
+	PaymentSystem PaymentSystem   `json:"payment_system" gorm:"type:varchar(20);not null"`
+	BINFrom       string          `json:"bin_from" gorm:"type:varchar(6);not null"` // начало диапазона BIN банка
+	BINTo         string          `json:"bin_to" gorm:"type:varchar(6);not null"`   // конец диапазона BIN банка
+	FundingType   CardFundingType `json:"funding_type" gorm:"type:varchar(20);not null"`
+	Format        CardFormat      `json:"format" gorm:"type:varchar(20);not null"`
+	DailyLimit    float64         `json:"daily_limit" gorm:"type:decimal(20,2);default:100000"`
+	MonthlyLimit  float64         `json:"monthly_limit" gorm:"type:decimal(20,2);default:1000000"`
+	ValidityYears int             `json:"validity_years" gorm:"not null;default:5"`
+	IsActive      bool            `json:"is_active" gorm:"default:true"`
+}
+
+// Validate проверяет все поля продукта
+func (p *CardProduct) Validate() error {
+	if strings.TrimSpace(p.Code) == "" || strings.TrimSpace(p.Name) == "" {
+		return errors.New("code and name are required")
+	}
+	if err := p.ValidateBINRange(); err != nil {
+		return err
+	}
+	switch p.FundingType {
+	case CardFundingDebit, CardFundingCredit:
+	default:
+		return ErrInvalidFundingType
+	}
+	switch p.Format {
+	case CardFormatPhysical, CardFormatVirtual:
+	default:
+		return ErrInvalidCardFormat
+	}
+	if p.DailyLimit <= 0 || p.MonthlyLimit < p.DailyLimit {
+		return errors.New("invalid card limits")
+	}
+	if p.ValidityYears < 1 || p.ValidityYears > 10 {
+		return errors.New("invalid card validity")
+	}
+	return nil
+}
+
+// ValidateBINRange проверяет, что диапазон BIN корректен и относится к платежной системе продукта
+func (p *CardProduct) ValidateBINRange() error {
+	if !binPattern.MatchString(p.BINFrom) || !binPattern.MatchString(p.BINTo) || p.BINFrom > p.BINTo {
+		return ErrInvalidBINRange
+	}
+	for _, bin := range []string{p.BINFrom, p.BINTo} {
+		system, err := PaymentSystemByBIN(bin)
+		if err != nil || system != p.PaymentSystem {
+			return fmt.Errorf("%w: %s does not belong to %s", ErrInvalidBINRange, bin, p.PaymentSystem)
+		}
+	}
+	return nil
+}
+
+// ContainsBIN проверяет, входит ли BIN номера карты в диапазон продукта
+func (p *CardProduct) ContainsBIN(number string) bool {
+	if len(number) < 6 {
+		return false
+	}
+	bin := number[:6]
+	return bin >= p.BINFrom && bin <= p.BINTo
+}
+
+// RandomBIN выбирает случайный BIN из диапазона продукта
+func (p *CardProduct) RandomBIN() string {
+	from, _ := strconv.Atoi(p.BINFrom)
+	to, _ := strconv.Atoi(p.BINTo)
+	return fmt.Sprintf("%06d", from+rand.Intn(to-from+1))
+}
+
+// BeforeCreate хук для валидации перед созданием
+func (p *CardProduct) BeforeCreate(tx *gorm.DB) error {
+	return p.Validate()
+}
+
+// BeforeUpdate хук для валидации перед обновлением
+func (p *CardProduct) BeforeUpdate(tx *gorm.DB) error {
+	return p.Validate()
+}
+
+// PaymentSystemByBIN определяет платежную систему по BIN
+func PaymentSystemByBIN(bin string) (PaymentSystem, error) {
+	if len(bin) < 4 {
+		return "", ErrInvalidPaymentSystem
+	}
+	prefix, err := strconv.Atoi(bin[:4])
+	if err != nil {
+		return "", ErrInvalidPaymentSystem
+	}
+	switch {
+	case bin[0] == '4':
+		return PaymentSystemVisa, nil
+	case prefix >= 5100 && prefix <= 5599, prefix >= 2221 && prefix <= 2720:
+		return PaymentSystemMastercard, nil
+	case prefix >= 2200 && prefix <= 2204:
+		return PaymentSystemMir, nil
+	default:
+		return "", ErrInvalidPaymentSystem
+	}
+}
+
+// GetDefaultCardProducts возвращает каталог карточных продуктов по умолчанию
+func GetDefaultCardProducts() []CardProduct {
+	return []CardProduct{
+		{
+			Code:          "VISA_DEBIT",
+			Name:          "Visa Classic дебетовая",
+			PaymentSystem: PaymentSystemVisa,
+			BINFrom:       "446100",
+			BINTo:         "446149",
+			FundingType:   CardFundingDebit,
+			Format:        CardFormatPhysical,
+			DailyLimit:    100000,
+			MonthlyLimit:  1000000,
+			ValidityYears: 5,
+			IsActive:      true,
+		},
+		{
+			Code:          "VISA_VIRTUAL",
+			Name:          "Visa виртуальная",
+			PaymentSystem: PaymentSystemVisa,
+			BINFrom:       "446150",
+			BINTo:         "446199",
+			FundingType:   CardFundingDebit,
+			Format:        CardFormatVirtual,
+			DailyLimit:    50000,
+			MonthlyLimit:  300000,
+			ValidityYears: 3,
+			IsActive:      true,
+		},
+		{
+			Code:          "MASTERCARD_CREDIT",
+			Name:          "Mastercard Gold кредитная",
+			PaymentSystem: PaymentSystemMastercard,
+			BINFrom:       "539100",
+			BINTo:         "539199",
+			FundingType:   CardFundingCredit,
+			Format:        CardFormatPhysical,
+			DailyLimit:    200000,
+			MonthlyLimit:  1500000,
+			ValidityYears: 4,
+			IsActive:      true,
+		},
+		{
+			Code:          "MIR_DEBIT",
+			Name:          "Мир дебетовая",
+			PaymentSystem: PaymentSystemMir,
+			BINFrom:       "220070",
+			BINTo:         "220074",
+			FundingType:   CardFundingDebit,
+			Format:        CardFormatPhysical,
+			DailyLimit:    100000,
+			MonthlyLimit:  1000000,
+			ValidityYears: 5,
+			IsActive:      true,
+		},
+		{
+			Code:          "MIR_VIRTUAL",
+			Name:          "Мир виртуальная",
+			PaymentSystem: PaymentSystemMir,
+			BINFrom:       "220075",
+			BINTo:         "220079",
+			FundingType:   CardFundingDebit,
+			Format:        CardFormatVirtual,
+			DailyLimit:    50000,
+			MonthlyLimit:  300000,
+			ValidityYears: 3,
+			IsActive:      true,
+		},
+	}
+}
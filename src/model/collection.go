@@ -0,0 +1,306 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCollectionCaseClosed    = errors.New("collection case is closed")
+	ErrInvalidContactAttempt   = errors.New("invalid contact attempt")
+	ErrInvalidPromiseToPay     = errors.New("invalid promise to pay")
+	ErrPromiseToPayPending     = errors.New("collection case already has a pending promise to pay")
+	ErrInvalidCollectionFilter = errors.New("invalid collection queue filter")
+)
+
+// MaxPromiseToPayDays наибольший срок, на который принимается обещание оплаты
+const MaxPromiseToPayDays = 30
+
+// CollectionStage стадия взыскания просроченной задолженности
+type CollectionStage string
+
+const (
+	// CollectionStageReminder напоминание заемщику о просроченном платеже
+	CollectionStageReminder CollectionStage = "REMINDER"
+	// CollectionStageCall задача оператору позвонить заемщику
+	CollectionStageCall CollectionStage = "CALL"
+	// CollectionStageDemand требование о погашении просроченной задолженности (просрочка более 60 дней, 353-ФЗ)
+	CollectionStageDemand CollectionStage = "DEMAND"
+	// CollectionStageWriteOff кандидат на списание задолженности
+	CollectionStageWriteOff CollectionStage = "WRITE_OFF_CANDIDATE"
+)
+
+// collectionStages стадии по порядку эскалации и число дней просрочки, с которого стадия наступает
+var collectionStages = []struct {
+	stage          CollectionStage
+	minDaysPastDue int
+}{
+	{CollectionStageReminder, 1},
+	{CollectionStageCall, 8},
+	{CollectionStageDemand, 61},
+	{CollectionStageWriteOff, 180},
+}
+
+// CollectionBucket группа просроченных кредитов по числу дней просрочки
+type CollectionBucket string
+
+const (
+	CollectionBucket1To30   CollectionBucket = "DPD_1_30"
+	CollectionBucket31To60  CollectionBucket = "DPD_31_60"
+	CollectionBucket61To90  CollectionBucket = "DPD_61_90"
+	CollectionBucketOver90  CollectionBucket = "DPD_90_PLUS"
+	collectionBucketCurrent CollectionBucket = ""
+)
+
+// CollectionBuckets группы просрочки по возрастанию
+var CollectionBuckets = []CollectionBucket{
+	CollectionBucket1To30, CollectionBucket31To60, CollectionBucket61To90, CollectionBucketOver90,
+}
+
+type CollectionCaseStatus string
+
+const (
+	CollectionCaseOpen   CollectionCaseStatus = "OPEN"
+	CollectionCaseClosed CollectionCaseStatus = "CLOSED" // просроченная задолженность погашена
+)
+
+// CollectionActivityType вид записи в истории взыскания
+type CollectionActivityType string
+
+const (
+	CollectionActivityStageChanged CollectionActivityType = "STAGE_CHANGED"
+	CollectionActivityContact      CollectionActivityType = "CONTACT_ATTEMPT"
+	CollectionActivityPromise      CollectionActivityType = "PROMISE_TO_PAY"
+	CollectionActivityClosed       CollectionActivityType = "CLOSED"
+)
+
+// ContactChannel способ связи с заемщиком
+type ContactChannel string
+
+const (
+	ContactChannelPhone ContactChannel = "PHONE"
+	ContactChannelSMS   ContactChannel = "SMS"
+	ContactChannelEmail ContactChannel = "EMAIL"
+	ContactChannelVisit ContactChannel = "VISIT"
+)
+
+// ContactResult результат попытки связаться с заемщиком
+type ContactResult string
+
+const (
+	ContactResultReached     ContactResult = "REACHED"
+	ContactResultNoAnswer    ContactResult = "NO_ANSWER"
+	ContactResultWrongNumber ContactResult = "WRONG_NUMBER"
+	ContactResultRefused     ContactResult = "REFUSED_TO_PAY"
+)
+
+// PromiseStatus статус обещания оплаты
+type PromiseStatus string
+
+const (
+	PromiseStatusPending PromiseStatus = "PENDING"
+	PromiseStatusKept    PromiseStatus = "KEPT"
+	PromiseStatusBroken  PromiseStatus = "BROKEN"
+)
+
+// CollectionCase дело о взыскании по просроченному кредиту. Открывается при первой проверке
+// с просрочкой и закрывается, когда просроченная задолженность погашена. Стадия только повышается
+// по мере роста просрочки.
+type CollectionCase struct {
+	gorm.Model
+	CreditID       uint                 `json:"credit_id" gorm:"not null;index"`
+	UserID         uint                 `json:"user_id" gorm:"not null;index"`
+	Status         CollectionCaseStatus `json:"status" gorm:"type:varchar(20);not null;default:'OPEN';index"`
+	Stage          CollectionStage      `json:"stage" gorm:"type:varchar(30);not null"`
+	Bucket         CollectionBucket     `json:"bucket" gorm:"type:varchar(20)"`
+	DaysPastDue    int                  `json:"days_past_due"`
+	OverdueAmount  float64              `json:"overdue_amount" gorm:"type:decimal(20,2)"`
+	StageChangedAt time.Time            `json:"stage_changed_at"`
+	CallTaskOpen   bool                 `json:"call_task_open"` // оператору нужно связаться с заемщиком
+	ClosedAt       *time.Time           `json:"closed_at,omitempty"`
+}
+
+// CollectionActivity запись в истории взыскания: смена стадии, попытка связаться с заемщиком
+// или обещание оплаты. ActorID 0 — запись сделана автоматически.
+type CollectionActivity struct {
+	gorm.Model
+	CaseID         uint                   `json:"case_id" gorm:"not null;index"`
+	CreditID       uint                   `json:"credit_id" gorm:"not null"`
+	Type           CollectionActivityType `json:"type" gorm:"type:varchar(20);not null"`
+	Stage          CollectionStage        `json:"stage" gorm:"type:varchar(30)"`
+	ActorID        uint                   `json:"actor_id"`
+	Channel        ContactChannel         `json:"channel,omitempty" gorm:"type:varchar(20)"`
+	Result         ContactResult          `json:"result,omitempty" gorm:"type:varchar(20)"`
+	Comment        string                 `json:"comment,omitempty" gorm:"type:text"`
+	PromisedAmount float64                `json:"promised_amount,omitempty" gorm:"type:decimal(20,2)"`
+	PromisedDate   *time.Time             `json:"promised_date,omitempty"`
+	PaidBase       float64                `json:"-" gorm:"type:decimal(20,2)"` // внесено по кредиту на момент обещания
+	PromiseStatus  PromiseStatus          `json:"promise_status,omitempty" gorm:"type:varchar(20)"`
+}
+
+// CollectionBucketSummary число дел и просроченная задолженность в группе просрочки
+type CollectionBucketSummary struct {
+	Bucket        CollectionBucket `json:"bucket"`
+	Cases         int              `json:"cases"`
+	OverdueAmount float64          `json:"overdue_amount"`
+}
+
+// CollectionQueue очередь взыскания: открытые дела по убыванию стадии и просрочки и сводка по группам
+type CollectionQueue struct {
+	Cases     []CollectionCase          `json:"cases"`
+	Summary   []CollectionBucketSummary `json:"summary"`
+	CallTasks int                       `json:"call_tasks"`
+}
+
+// CollectionStageFor возвращает стадию взыскания для числа дней просрочки
+func CollectionStageFor(daysPastDue int) CollectionStage {
+	stage := CollectionStageReminder
+	for _, s := range collectionStages {
+		if daysPastDue >= s.minDaysPastDue {
+			stage = s.stage
+		}
+	}
+	return stage
+}
+
+// CollectionBucketFor возвращает группу просрочки для числа дней просрочки
+func CollectionBucketFor(daysPastDue int) CollectionBucket {
+	switch {
+	case daysPastDue <= 0:
+		return collectionBucketCurrent
+	case daysPastDue <= 30:
+		return CollectionBucket1To30
+	case daysPastDue <= 60:
+		return CollectionBucket31To60
+	case daysPastDue <= 90:
+		return CollectionBucket61To90
+	default:
+		return CollectionBucketOver90
+	}
+}
+
+// Rank возвращает порядковый номер стадии в эскалации, для неизвестной стадии -1
+func (s CollectionStage) Rank() int {
+	for i, stage := range collectionStages {
+		if stage.stage == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// Validate проверяет стадию взыскания
+func (s CollectionStage) Validate() error {
+	if s.Rank() < 0 {
+		return fmt.Errorf("%w: stage %s", ErrInvalidCollectionFilter, s)
+	}
+	return nil
+}
+
+// Validate проверяет группу просрочки
+func (b CollectionBucket) Validate() error {
+	for _, bucket := range CollectionBuckets {
+		if b == bucket {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: bucket %s", ErrInvalidCollectionFilter, b)
+}
+
+// NewCollectionCase открывает дело о взыскании по просроченному кредиту
+func NewCollectionCase(credit *Credit) *CollectionCase {
+	return &CollectionCase{
+		CreditID: credit.ID,
+		UserID:   credit.UserID,
+		Status:   CollectionCaseOpen,
+	}
+}
+
+// IsOpen проверяет, ведется ли взыскание по делу
+func (c *CollectionCase) IsOpen() bool {
+	return c.Status == CollectionCaseOpen
+}
+
+// Refresh обновляет просрочку по кредиту и повышает стадию, если просрочка достигла следующей стадии.
+// На стадиях звонка и требования оператору ставится задача связаться с заемщиком.
+// Возвращает true, если стадия изменилась.
+func (c *CollectionCase) Refresh(credit *Credit, now time.Time) bool {
+	c.DaysPastDue = credit.DaysPastDue
+	c.OverdueAmount = credit.OverdueAmount
+	// Платеж просрочен с сегодняшнего дня — первая группа просрочки
+	c.Bucket = CollectionBucketFor(max(credit.DaysPastDue, 1))
+
+	stage := CollectionStageFor(credit.DaysPastDue)
+	if stage.Rank() <= c.Stage.Rank() {
+		return false
+	}
+	c.Stage = stage
+	c.StageChangedAt = now
+	if stage == CollectionStageCall || stage == CollectionStageDemand {
+		c.CallTaskOpen = true
+	}
+	return true
+}
+
+// Close закрывает дело после погашения просроченной задолженности
+func (c *CollectionCase) Close(now time.Time) {
+	c.Status = CollectionCaseClosed
+	c.ClosedAt = &now
+	c.CallTaskOpen = false
+	c.DaysPastDue = 0
+	c.OverdueAmount = 0
+	c.Bucket = collectionBucketCurrent
+}
+
+// ValidateContact проверяет попытку связаться с заемщиком
+func (a *CollectionActivity) ValidateContact() error {
+	switch a.Channel {
+	case ContactChannelPhone, ContactChannelSMS, ContactChannelEmail, ContactChannelVisit:
+	default:
+		return fmt.Errorf("%w: channel %s", ErrInvalidContactAttempt, a.Channel)
+	}
+	switch a.Result {
+	case ContactResultReached, ContactResultNoAnswer, ContactResultWrongNumber, ContactResultRefused:
+	default:
+		return fmt.Errorf("%w: result %s", ErrInvalidContactAttempt, a.Result)
+	}
+	return nil
+}
+
+// ValidatePromise проверяет обещание оплаты: сумма положительна, дата не раньше сегодняшней
+// и не позже MaxPromiseToPayDays дней
+func (a *CollectionActivity) ValidatePromise(now time.Time) error {
+	if a.PromisedAmount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidPromiseToPay)
+	}
+	if a.PromisedDate == nil {
+		return fmt.Errorf("%w: date is required", ErrInvalidPromiseToPay)
+	}
+	today := dateOnly(now)
+	date := dateOnly(*a.PromisedDate)
+	if date.Before(today) || date.After(today.AddDate(0, 0, MaxPromiseToPayDays)) {
+		return fmt.Errorf("%w: date must be within %d days", ErrInvalidPromiseToPay, MaxPromiseToPayDays)
+	}
+	return nil
+}
+
+// ResolvePromise подводит итог обещания оплаты. totalPaid — внесено по кредиту на дату now.
+// Обещание выполнено, если с момента обещания внесена обещанная сумма или просрочка погашена,
+// нарушено — если после дня оплаты сумма не внесена. Возвращает true, если статус изменился.
+func (a *CollectionActivity) ResolvePromise(totalPaid float64, caseClosed bool, now time.Time) bool {
+	if a.PromiseStatus != PromiseStatusPending {
+		return false
+	}
+	switch {
+	case caseClosed || roundMoney(totalPaid-a.PaidBase) >= a.PromisedAmount:
+		a.PromiseStatus = PromiseStatusKept
+	case a.PromisedDate != nil && !now.Before(dateOnly(*a.PromisedDate).AddDate(0, 0, 1)):
+		a.PromiseStatus = PromiseStatusBroken
+	default:
+		return false
+	}
+	return true
+}
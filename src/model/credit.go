@@ -2,6 +2,7 @@ package model
 
 import (
 	"errors"
+	"math"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,6 +16,7 @@ var (
 	ErrCreditAlreadyActive  = errors.New("credit is already active")
 	ErrCreditNotActive      = errors.New("credit is not active")
 	ErrInvalidPaymentAmount = errors.New("invalid payment amount")
+	ErrInvalidRepaymentType = errors.New("invalid repayment type")
 )
 
 type CreditStatus string
@@ -27,6 +29,26 @@ const (
 	CreditStatusCancelled CreditStatus = "CANCELLED"
 )
 
+// RepaymentType способ погашения кредита
+type RepaymentType string
+
+const (
+	// RepaymentTypeAnnuity равные ежемесячные платежи
+	RepaymentTypeAnnuity RepaymentType = "ANNUITY"
+	// RepaymentTypeDifferentiated равные доли основного долга и проценты на остаток,
+	// платежи уменьшаются к концу срока
+	RepaymentTypeDifferentiated RepaymentType = "DIFFERENTIATED"
+)
+
+// RepaymentComparison итоги погашения кредита одним из способов
+type RepaymentComparison struct {
+	RepaymentType RepaymentType `json:"repayment_type"`
+	FirstPayment  float64       `json:"first_payment"`
+	LastPayment   float64       `json:"last_payment"`
+	TotalAmount   float64       `json:"total_amount"`
+	Overpayment   float64       `json:"overpayment"`
+}
+
 type PaymentStatus string
 
 const (
@@ -37,20 +59,35 @@ const (
 
 type Credit struct {
 	gorm.Model
-	AccountID     uint         `json:"account_id" gorm:"not null"`
-	UserID        uint         `json:"user_id" gorm:"not null"`
-	Amount        float64      `json:"amount" gorm:"type:decimal(20,2);not null"`
-	Term          int          `json:"term" gorm:"not null"` // в месяцах
-	InterestRate  float64      `json:"interest_rate" gorm:"type:decimal(5,2);not null"`
-	Status        CreditStatus `json:"status" gorm:"type:varchar(20);not null;default:'PENDING'"`
-	StartDate     time.Time    `json:"start_date"`
-	EndDate       time.Time    `json:"end_date"`
-	PaymentDay    int          `json:"payment_day" gorm:"not null"` // день месяца для платежа
-	NextPayment   time.Time    `json:"next_payment"`
-	TotalPaid     float64      `json:"total_paid" gorm:"type:decimal(20,2);default:0"`
-	RemainingDebt float64      `json:"remaining_debt" gorm:"type:decimal(20,2);not null"`
-	OverdueAmount float64      `json:"overdue_amount" gorm:"type:decimal(20,2);default:0"`
-	LastPayment   time.Time    `json:"last_payment"`
+	AccountID        uint           `json:"account_id" gorm:"not null"`
+	UserID           uint           `json:"user_id" gorm:"not null"`
+	ProductID        *uint          `json:"product_id,omitempty"`
+	Amount           float64        `json:"amount" gorm:"type:decimal(20,2);not null"`
+	IssueFee         float64        `json:"issue_fee" gorm:"type:decimal(20,2);default:0"`
+	InsurancePremium float64        `json:"insurance_premium" gorm:"type:decimal(20,2);default:0"`
+	Term             int            `json:"term" gorm:"not null"` // в месяцах
+	InterestRate     float64        `json:"interest_rate" gorm:"type:decimal(5,2);not null"`
+	FullCostRate     float64        `json:"full_cost_rate" gorm:"type:decimal(7,3);default:0"`    // ПСК в процентах годовых на дату выдачи
+	FullCostAmount   float64        `json:"full_cost_amount" gorm:"type:decimal(20,2);default:0"` // ПСК в денежном выражении
+	RateType         CreditRateType `json:"rate_type,omitempty" gorm:"type:varchar(20)"`
+	RateMargin       float64        `json:"rate_margin" gorm:"type:decimal(5,2);default:0"` // надбавка к ключевой ставке для плавающей ставки
+	KeyRate          float64        `json:"key_rate" gorm:"type:decimal(5,2);default:0"`    // ключевая ставка на дату установки ставки
+	RepaymentType    RepaymentType  `json:"repayment_type" gorm:"type:varchar(20);not null;default:'ANNUITY'"`
+	Status           CreditStatus   `json:"status" gorm:"type:varchar(20);not null;default:'PENDING'"`
+	StartDate        time.Time      `json:"start_date"`
+	EndDate          time.Time      `json:"end_date"`
+	PaymentDay       int            `json:"payment_day" gorm:"not null"` // день месяца для платежа
+	NextPayment      time.Time      `json:"next_payment"`
+	MonthlyPayment   float64        `json:"monthly_payment" gorm:"type:decimal(20,2);default:0"` // сумма ближайшего платежа по графику
+	TotalPaid        float64        `json:"total_paid" gorm:"type:decimal(20,2);default:0"`
+	RemainingDebt    float64        `json:"remaining_debt" gorm:"type:decimal(20,2);not null"`
+	OverdueAmount    float64        `json:"overdue_amount" gorm:"type:decimal(20,2);default:0"`
+	PenaltyDebt      float64        `json:"penalty_debt" gorm:"type:decimal(20,2);default:0"`
+	DaysPastDue      int            `json:"days_past_due" gorm:"default:0"`
+	LastPayment      time.Time      `json:"last_payment"`
+	ScheduleVersion  int            `json:"schedule_version" gorm:"not null;default:1"`       // растет при каждом изменении графика
+	HolidayUntil     time.Time      `json:"holiday_until"`                                    // окончание кредитных каникул, проценты после каникул начисляются с этой даты
+	LoanToValue      float64        `json:"loan_to_value" gorm:"type:decimal(5,2);default:0"` // отношение суммы кредита к стоимости залога на дату одобрения, %
 }
 
 // Validate проверяет все поля кредита
@@ -67,6 +104,9 @@ func (c *Credit) Validate() error {
 	if err := c.ValidatePaymentDay(); err != nil {
 		return err
 	}
+	if err := c.ValidateRepaymentType(); err != nil {
+		return err
+	}
 	if err := c.ValidateStatus(); err != nil {
 		return err
 	}
@@ -105,6 +145,16 @@ func (c *Credit) ValidatePaymentDay() error {
 	return nil
 }
 
+// ValidateRepaymentType проверяет корректность способа погашения
+func (c *Credit) ValidateRepaymentType() error {
+	switch c.RepaymentType {
+	case RepaymentTypeAnnuity, RepaymentTypeDifferentiated:
+		return nil
+	default:
+		return ErrInvalidRepaymentType
+	}
+}
+
 // ValidateStatus проверяет корректность статуса
 func (c *Credit) ValidateStatus() error {
 	switch c.Status {
@@ -116,17 +166,59 @@ func (c *Credit) ValidateStatus() error {
 	}
 }
 
-// CalculateMonthlyPayment рассчитывает ежемесячный платеж
+// CalculateMonthlyPayment рассчитывает ежемесячный платеж.
+// Для дифференцированного погашения возвращает первый, самый большой платеж.
 func (c *Credit) CalculateMonthlyPayment() float64 {
+	if c.RepaymentType == RepaymentTypeDifferentiated {
+		return c.Amount/float64(c.Term) + c.Amount*c.InterestRate/12/100
+	}
+	return c.calculateAnnuityPayment()
+}
+
+// calculateAnnuityPayment рассчитывает аннуитетный платеж
+func (c *Credit) calculateAnnuityPayment() float64 {
 	// Формула аннуитетного платежа
 	monthlyRate := c.InterestRate / 12 / 100
 	denominator := 1 - 1/pow(1+monthlyRate, float64(c.Term))
 	return c.Amount * monthlyRate / denominator
 }
 
-// CalculateTotalAmount рассчитывает общую сумму к возврату
+// CalculateTotalAmount рассчитывает общую сумму к возврату по графику платежей
 func (c *Credit) CalculateTotalAmount() float64 {
-	return c.CalculateMonthlyPayment() * float64(c.Term)
+	total := 0.0
+	for _, payment := range c.GeneratePaymentSchedule() {
+		total += payment.TotalAmount
+	}
+	return roundMoney(total)
+}
+
+// CalculateOverpayment рассчитывает переплату по кредиту
+func (c *Credit) CalculateOverpayment() float64 {
+	return roundMoney(c.CalculateTotalAmount() - c.Amount)
+}
+
+// CompareRepaymentTypes рассчитывает платежи и переплату при каждом способе погашения
+func (c *Credit) CompareRepaymentTypes() []RepaymentComparison {
+	types := []RepaymentType{RepaymentTypeAnnuity, RepaymentTypeDifferentiated}
+	comparison := make([]RepaymentComparison, 0, len(types))
+	for _, repaymentType := range types {
+		variant := *c
+		variant.RepaymentType = repaymentType
+		schedule := variant.GeneratePaymentSchedule()
+		if len(schedule) == 0 {
+			continue
+		}
+
+		total := variant.CalculateTotalAmount()
+		comparison = append(comparison, RepaymentComparison{
+			RepaymentType: repaymentType,
+			FirstPayment:  schedule[0].TotalAmount,
+			LastPayment:   schedule[len(schedule)-1].TotalAmount,
+			TotalAmount:   total,
+			Overpayment:   roundMoney(total - c.Amount),
+		})
+	}
+	return comparison
 }
 
 // CalculateRemainingDebt рассчитывает оставшийся долг
@@ -203,23 +295,38 @@ func (c *Credit) BeforeUpdate(tx *gorm.DB) error {
 // ToDTO преобразует модель в DTO
 func (c *Credit) ToDTO() map[string]interface{} {
 	return map[string]interface{}{
-		"id":             c.ID,
-		"account_id":     c.AccountID,
-		"user_id":        c.UserID,
-		"amount":         c.Amount,
-		"term":           c.Term,
-		"interest_rate":  c.InterestRate,
-		"status":         c.Status,
-		"start_date":     c.StartDate,
-		"end_date":       c.EndDate,
-		"payment_day":    c.PaymentDay,
-		"next_payment":   c.NextPayment,
-		"total_paid":     c.TotalPaid,
-		"remaining_debt": c.RemainingDebt,
-		"overdue_amount": c.OverdueAmount,
-		"last_payment":   c.LastPayment,
-		"created_at":     c.CreatedAt,
-		"updated_at":     c.UpdatedAt,
+		"id":                c.ID,
+		"account_id":        c.AccountID,
+		"user_id":           c.UserID,
+		"product_id":        c.ProductID,
+		"amount":            c.Amount,
+		"issue_fee":         c.IssueFee,
+		"insurance_premium": c.InsurancePremium,
+		"term":              c.Term,
+		"interest_rate":     c.InterestRate,
+		"full_cost_rate":    c.FullCostRate,
+		"full_cost_amount":  c.FullCostAmount,
+		"rate_type":         c.RateType,
+		"rate_margin":       c.RateMargin,
+		"key_rate":          c.KeyRate,
+		"repayment_type":    c.RepaymentType,
+		"status":            c.Status,
+		"start_date":        c.StartDate,
+		"end_date":          c.EndDate,
+		"payment_day":       c.PaymentDay,
+		"next_payment":      c.NextPayment,
+		"monthly_payment":   c.MonthlyPayment,
+		"total_paid":        c.TotalPaid,
+		"remaining_debt":    c.RemainingDebt,
+		"overdue_amount":    c.OverdueAmount,
+		"penalty_debt":      c.PenaltyDebt,
+		"days_past_due":     c.DaysPastDue,
+		"last_payment":      c.LastPayment,
+		"schedule_version":  c.ScheduleVersion,
+		"holiday_until":     c.HolidayUntil,
+		"loan_to_value":     c.LoanToValue,
+		"created_at":        c.CreatedAt,
+		"updated_at":        c.UpdatedAt,
 	}
 }
 
@@ -238,18 +345,158 @@ func pow(x float64, n float64) float64 {
 }
 
 type PaymentSchedule struct {
-	ID            uint          `json:"id" gorm:"primaryKey"`
-	CreditID      uint          `json:"credit_id"`
-	PaymentNumber int           `json:"payment_number"`
-	DueDate       time.Time     `json:"due_date"`
-	Amount        float64       `json:"amount"`
-	Interest      float64       `json:"interest"`
-	Principal     float64       `json:"principal"`
-	TotalAmount   float64       `json:"total_amount"`
-	Status        PaymentStatus `json:"status" gorm:"type:varchar(20)"`
-	PaidAt        *time.Time    `json:"paid_at,omitempty"`
-	CreatedAt     time.Time     `json:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at"`
+	ID               uint          `json:"id" gorm:"primaryKey"`
+	CreditID         uint          `json:"credit_id" gorm:"index;not null"`
+	PaymentNumber    int           `json:"payment_number" gorm:"not null"`
+	DueDate          time.Time     `json:"due_date" gorm:"index"`
+	Amount           float64       `json:"amount" gorm:"type:decimal(20,2)"`
+	Interest         float64       `json:"interest" gorm:"type:decimal(20,2)"`
+	DeferredInterest float64       `json:"deferred_interest" gorm:"type:decimal(20,2);default:0"` // отсроченные проценты за кредитные каникулы в составе Interest
+	Principal        float64       `json:"principal" gorm:"type:decimal(20,2)"`
+	TotalAmount      float64       `json:"total_amount" gorm:"type:decimal(20,2)"`
+	PaidAmount       float64       `json:"paid_amount" gorm:"type:decimal(20,2);default:0"`
+	InterestPaid     float64       `json:"interest_paid" gorm:"type:decimal(20,2);default:0"`
+	PrincipalPaid    float64       `json:"principal_paid" gorm:"type:decimal(20,2);default:0"`
+	PenaltyAccrued   float64       `json:"penalty_accrued" gorm:"type:decimal(20,2);default:0"`
+	PenaltyPaid      float64       `json:"penalty_paid" gorm:"type:decimal(20,2);default:0"`
+	PenaltyAccruedAt *time.Time    `json:"penalty_accrued_at,omitempty"`
+	DaysPastDue      int           `json:"days_past_due" gorm:"default:0"`
+	Status           PaymentStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	PaidAt           *time.Time    `json:"paid_at,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+
+	Credit Credit `gorm:"foreignKey:CreditID" json:"-"`
+}
+
+// Remaining возвращает неоплаченную часть платежа
+func (p *PaymentSchedule) Remaining() float64 {
+	remaining := roundMoney(p.TotalAmount - p.PaidAmount)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// IsPaid проверяет, оплачен ли платеж
+func (p *PaymentSchedule) IsPaid() bool {
+	return p.Status == PaymentStatusPaid
+}
+
+// IsDue проверяет, наступил ли срок неоплаченного платежа
+func (p *PaymentSchedule) IsDue(now time.Time) bool {
+	return !p.IsPaid() && p.DueDate.Before(now)
+}
+
+// MarkOverdue помечает неоплаченный платеж просроченным
+func (p *PaymentSchedule) MarkOverdue() {
+	if !p.IsPaid() {
+		p.Status = PaymentStatusOverdue
+	}
+}
+
+// PaymentDueDate возвращает дату n-го платежа. Если в месяце нет дня платежа,
+// платеж переносится на последний день месяца.
+func (c *Credit) PaymentDueDate(n int) time.Time {
+	return c.dueDateInMonth(c.StartDate, n)
+}
+
+// dueDateInMonth возвращает дату платежа в месяце, отстоящем от date на months месяцев
+func (c *Credit) dueDateInMonth(date time.Time, months int) time.Time {
+	firstOfMonth := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := c.PaymentDay
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, 0, 0, 0, 0, date.Location())
+}
+
+// GeneratePaymentSchedule строит график платежей по способу погашения кредита.
+// Суммы округляются до копеек, остаток округления учитывается в последнем платеже.
+func (c *Credit) GeneratePaymentSchedule() []PaymentSchedule {
+	if c.Term <= 0 {
+		return nil
+	}
+
+	schedule := make([]PaymentSchedule, 0, c.Term)
+	annuityPayment := roundMoney(c.calculateAnnuityPayment())
+	equalPrincipal := roundMoney(c.Amount / float64(c.Term))
+	monthlyRate := c.InterestRate / 12 / 100
+	remaining := c.Amount
+
+	for i := 1; i <= c.Term; i++ {
+		interest := roundMoney(remaining * monthlyRate)
+		principal := equalPrincipal
+		if c.RepaymentType != RepaymentTypeDifferentiated {
+			principal = roundMoney(annuityPayment - interest)
+		}
+		if i == c.Term || principal > remaining {
+			principal = roundMoney(remaining)
+		}
+		remaining = roundMoney(remaining - principal)
+		total := roundMoney(principal + interest)
+
+		schedule = append(schedule, PaymentSchedule{
+			CreditID:      c.ID,
+			PaymentNumber: i,
+			DueDate:       c.PaymentDueDate(i),
+			Amount:        total,
+			Interest:      interest,
+			Principal:     principal,
+			TotalAmount:   total,
+			Status:        PaymentStatusPending,
+		})
+	}
+	return schedule
+}
+
+// ApplySchedule пересчитывает остаток долга, просроченную задолженность и неустойку, дату и сумму
+// следующего платежа и статус кредита по графику платежей
+func (c *Credit) ApplySchedule(schedule []PaymentSchedule, now time.Time) {
+	remaining := 0.0
+	overdue := 0.0
+	penalty := 0.0
+	daysPastDue := 0
+	var next *PaymentSchedule
+	for i := range schedule {
+		payment := &schedule[i]
+		if payment.IsPaid() {
+			continue
+		}
+		remaining += payment.Remaining()
+		penalty += payment.PenaltyDue()
+		if payment.IsDue(now) {
+			overdue += payment.AmountDue()
+			if days := wholeDays(payment.DueDate, now); days > daysPastDue {
+				daysPastDue = days
+			}
+		}
+		if next == nil {
+			next = payment
+		}
+	}
+
+	c.RemainingDebt = roundMoney(remaining)
+	c.OverdueAmount = roundMoney(overdue)
+	c.PenaltyDebt = roundMoney(penalty)
+	c.DaysPastDue = daysPastDue
+	switch {
+	case next == nil:
+		c.MonthlyPayment = 0
+		c.Status = CreditStatusPaid
+	case overdue > 0:
+		c.NextPayment = next.DueDate
+		c.MonthlyPayment = next.TotalAmount
+		c.Status = CreditStatusOverdue
+	default:
+		c.NextPayment = next.DueDate
+		c.MonthlyPayment = next.TotalAmount
+		c.Status = CreditStatusActive
+	}
+}
 
-	Credit Credit `gorm:"foreignKey:CreditID" json:"credit"`
+// roundMoney округляет сумму до копеек
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
 }
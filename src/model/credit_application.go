@@ -0,0 +1,142 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrApplicationNotPending = errors.New("credit application is not pending review")
+	ErrReviewCommentRequired = errors.New("review comment is required")
+)
+
+// CreditApplicationStatus статус заявки на кредит
+type CreditApplicationStatus string
+
+const (
+	CreditApplicationSubmitted     CreditApplicationStatus = "SUBMITTED"
+	CreditApplicationPendingReview CreditApplicationStatus = "PENDING_REVIEW"
+	CreditApplicationApproved      CreditApplicationStatus = "APPROVED"
+	CreditApplicationRejected      CreditApplicationStatus = "REJECTED"
+)
+
+// Автоматические проверки заявки
+const (
+	PrecheckNoOverdueCredits  = "NO_OVERDUE_CREDITS"
+	PrecheckActiveCredits     = "ACTIVE_CREDITS_LIMIT"
+	PrecheckNoOpenApplication = "NO_OPEN_APPLICATION"
+)
+
+// MaxActiveCredits максимальное число непогашенных кредитов у клиента
+const MaxActiveCredits = 3
+
+// PrecheckResult результат автоматической проверки заявки
+type PrecheckResult struct {
+	Check   string `json:"check"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// CreditApplication заявка клиента на кредит. Кредит выдается только после одобрения
+// по скорингу, менеджером или оператором.
+type CreditApplication struct {
+	gorm.Model
+	UserID         uint                    `json:"user_id" gorm:"index;not null"`
+	AccountID      uint                    `json:"account_id" gorm:"not null"`
+	ProductID      uint                    `json:"product_id"`
+	Amount         float64                 `json:"amount" gorm:"type:decimal(20,2);not null"`
+	Term           int                     `json:"term" gorm:"not null"`
+	RepaymentType  RepaymentType           `json:"repayment_type" gorm:"type:varchar(20);not null;default:'ANNUITY'"`
+	Description    string                  `json:"description"`
+	Status         CreditApplicationStatus `json:"status" gorm:"type:varchar(20);index;not null"`
+	Prechecks      []PrecheckResult        `json:"prechecks" gorm:"type:text;serializer:json"`
+	Score          *int                    `json:"score,omitempty"`
+	ScoreDecision  ScoreDecision           `json:"score_decision,omitempty" gorm:"type:varchar(20)"`
+	ScoreFactors   []ScoreFactor           `json:"score_factors,omitempty" gorm:"type:text;serializer:json"`
+	RateSpread     float64                 `json:"rate_spread" gorm:"type:decimal(5,2)"`
+	BureauReportID *uint                   `json:"bureau_report_id,omitempty"` // кредитный отчет, использованный при скоринге
+	ReviewerID     *uint                   `json:"reviewer_id,omitempty"`
+	ReviewComment  string                  `json:"review_comment,omitempty" gorm:"type:text"`
+	ReviewedAt     *time.Time              `json:"reviewed_at,omitempty"`
+	CreditID       *uint                   `json:"credit_id,omitempty"`
+}
+
+// Validate проверяет все поля заявки
+func (a *CreditApplication) Validate() error {
+	if a.UserID == 0 || a.AccountID == 0 {
+		return errors.New("user and account are required")
+	}
+	credit := Credit{Amount: a.Amount, Term: a.Term, RepaymentType: a.RepaymentType}
+	if err := credit.ValidateAmount(); err != nil {
+		return err
+	}
+	if err := credit.ValidateTerm(); err != nil {
+		return err
+	}
+	if err := credit.ValidateRepaymentType(); err != nil {
+		return err
+	}
+	switch a.Status {
+	case CreditApplicationSubmitted, CreditApplicationPendingReview,
+		CreditApplicationApproved, CreditApplicationRejected:
+		return nil
+	default:
+		return errors.New("invalid credit application status")
+	}
+}
+
+// PrechecksPassed проверяет, пройдены ли все автоматические проверки
+func (a *CreditApplication) PrechecksPassed() bool {
+	for _, check := range a.Prechecks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyScore сохраняет в заявке результат скоринга
+func (a *CreditApplication) ApplyScore(score *CreditScore) {
+	a.Score = &score.Score
+	a.ScoreDecision = score.Decision
+	a.ScoreFactors = score.Factors
+	a.RateSpread = score.RateSpread
+	a.BureauReportID = score.BureauReportID
+}
+
+// RateDiscount возвращает скидку к ставке продукта по результату скоринга
+func (a *CreditApplication) RateDiscount() float64 {
+	if a.RateSpread <= 0 {
+		return 0
+	}
+	return DefaultRateSpread - a.RateSpread
+}
+
+// IsOpen проверяет, ожидает ли заявка решения
+func (a *CreditApplication) IsOpen() bool {
+	return a.Status == CreditApplicationSubmitted || a.Status == CreditApplicationPendingReview
+}
+
+// BeforeCreate хук для валидации перед созданием
+func (a *CreditApplication) BeforeCreate(tx *gorm.DB) error {
+	return a.Validate()
+}
+
+// BeforeUpdate хук для валидации перед обновлением
+func (a *CreditApplication) BeforeUpdate(tx *gorm.DB) error {
+	return a.Validate()
+}
+
+// CreditApplicationEvent запись об изменении статуса заявки.
+// ActorID равен нулю для автоматических переходов.
+type CreditApplicationEvent struct {
+	ID            uint                    `json:"id" gorm:"primaryKey"`
+	ApplicationID uint                    `json:"application_id" gorm:"index;not null"`
+	FromStatus    CreditApplicationStatus `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus      CreditApplicationStatus `json:"to_status" gorm:"type:varchar(20);not null"`
+	ActorID       uint                    `json:"actor_id"`
+	Comment       string                  `json:"comment,omitempty" gorm:"type:text"`
+	CreatedAt     time.Time               `json:"created_at"`
+}
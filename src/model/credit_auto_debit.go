@@ -0,0 +1,65 @@
+package model
+
+import (
+	"errors"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidAutoDebitAccount = errors.New("account cannot be used for auto debit")
+	ErrAutoDebitAccountExists  = errors.New("account is already authorized for auto debit")
+)
+
+// AutoDebitStatus результат попытки списания платежа по кредиту
+type AutoDebitStatus string
+
+const (
+	AutoDebitStatusSuccess AutoDebitStatus = "SUCCESS" // платеж погашен полностью
+	AutoDebitStatusPartial AutoDebitStatus = "PARTIAL" // списана часть платежа
+	AutoDebitStatusFailed  AutoDebitStatus = "FAILED"  // на счете нет средств
+)
+
+// AutoDebitAccount резервный счет заемщика, с которого разрешено списывать платежи по кредиту,
+// если на счете кредита недостаточно средств. Счета используются по возрастанию приоритета.
+type AutoDebitAccount struct {
+	gorm.Model
+	CreditID  uint `json:"credit_id" gorm:"not null;index"`
+	UserID    uint `json:"user_id" gorm:"not null"`
+	AccountID uint `json:"account_id" gorm:"not null"`
+	Priority  int  `json:"priority" gorm:"not null;default:1"`
+}
+
+// AutoDebitAttempt попытка списать платеж по графику со счета кредита или резервного счета
+type AutoDebitAttempt struct {
+	gorm.Model
+	CreditID      uint            `json:"credit_id" gorm:"not null;index"`
+	UserID        uint            `json:"user_id" gorm:"not null"`
+	PaymentNumber int             `json:"payment_number" gorm:"not null"`
+	AccountID     uint            `json:"account_id" gorm:"not null"`
+	Fallback      bool            `json:"fallback"`                                     // списание с резервного счета
+	Requested     float64         `json:"requested" gorm:"type:decimal(20,2);not null"` // задолженность по платежу перед попыткой
+	Debited       float64         `json:"debited" gorm:"type:decimal(20,2);not null"`
+	Status        AutoDebitStatus `json:"status" gorm:"type:varchar(20);not null"`
+	TransactionID *uint           `json:"transaction_id,omitempty"`
+}
+
+// Validate проверяет приоритет резервного счета
+func (a *AutoDebitAccount) Validate() error {
+	if a.CreditID == 0 || a.AccountID == 0 || a.Priority < 1 {
+		return ErrInvalidAutoDebitAccount
+	}
+	return nil
+}
+
+// BeforeCreate хук для валидации перед созданием
+func (a *AutoDebitAccount) BeforeCreate(tx *gorm.DB) error {
+	return a.Validate()
+}
+
+// DebitAmount возвращает сумму, которую можно списать со счета в погашение задолженности due:
+// положительный остаток счета, но не больше задолженности. Кредитный лимит для погашения не используется.
+func (a *Account) DebitAmount(due float64) float64 {
+	return math.Max(0, roundMoney(math.Min(a.Balance, due)))
+}
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreditBureauFormatVersion версия формата файла кредитной истории
+const CreditBureauFormatVersion = "1.0"
+
+// CreditBureauEvent событие кредитной истории, передаваемое в бюро
+type CreditBureauEvent string
+
+const (
+	CreditBureauEventOpened      CreditBureauEvent = "OPENED"      // выдача кредита
+	CreditBureauEventPayment     CreditBureauEvent = "PAYMENT"     // оплата платежа по графику
+	CreditBureauEventDelinquency CreditBureauEvent = "DELINQUENCY" // просроченная задолженность на дату выгрузки
+	CreditBureauEventClosed      CreditBureauEvent = "CLOSED"      // полное погашение кредита
+)
+
+// CreditBureauRecord запись файла кредитной истории по одному событию кредита
+type CreditBureauRecord struct {
+	CreditID      uint              `json:"credit_id"`
+	UserID        uint              `json:"user_id"`
+	Fio           string            `json:"fio"`
+	Event         CreditBureauEvent `json:"event"`
+	Date          time.Time         `json:"date"`
+	PaymentNumber int               `json:"payment_number,omitempty"`
+	Amount        float64           `json:"amount"`         // сумма кредита или оплаченная сумма платежа
+	Balance       float64           `json:"balance"`        // остаток задолженности на дату выгрузки
+	OverdueAmount float64           `json:"overdue_amount"` // просроченная задолженность с неустойкой
+	DaysPastDue   int               `json:"days_past_due"`  // дни просрочки
+	Status        CreditStatus      `json:"status"`         // статус кредита на дату выгрузки
+}
+
+// CreditBureauExport выгрузка кредитной истории за период (PeriodFrom, PeriodTo].
+// Выгрузки инкрементальные: каждая следующая начинается с конца предыдущей.
+type CreditBureauExport struct {
+	gorm.Model
+	PeriodFrom  time.Time `json:"period_from"`
+	PeriodTo    time.Time `json:"period_to" gorm:"not null;index"`
+	FileName    string    `json:"file_name" gorm:"not null"`
+	RecordCount int       `json:"record_count" gorm:"not null"`
+	Content     string    `json:"-" gorm:"type:text;not null"`
+	Signature   string    `json:"-" gorm:"type:text;not null"` // отсоединенная PGP-подпись файла
+	KeyID       string    `json:"key_id" gorm:"type:varchar(20);not null"`
+}
+
+// SignatureFileName возвращает имя файла подписи выгрузки
+func (e *CreditBureauExport) SignatureFileName() string {
+	return e.FileName + ".asc"
+}
+
+// CreditBureauFileName возвращает имя файла выгрузки участника memberCode на дату periodTo
+func CreditBureauFileName(memberCode string, periodTo time.Time) string {
+	return fmt.Sprintf("%s_%s.txt", memberCode, periodTo.UTC().Format("20060102_150405"))
+}
+
+// FormatCreditBureauFile формирует файл кредитной истории: строки с полями через "|",
+// заголовок HD (участник, версия формата, период), по строке TR на каждое событие
+// и завершающая строка TL с числом событий. Даты в формате YYYYMMDD, суммы с двумя знаками.
+func FormatCreditBureauFile(memberCode string, from, to time.Time, records []CreditBureauRecord) string {
+	var b strings.Builder
+
+	periodFrom := ""
+	if !from.IsZero() {
+		periodFrom = bureauDate(from)
+	}
+	writeBureauLine(&b, "HD", memberCode, CreditBureauFormatVersion, periodFrom, bureauDate(to))
+
+	for _, record := range records {
+		paymentNumber := ""
+		if record.PaymentNumber > 0 {
+			paymentNumber = fmt.Sprintf("%d", record.PaymentNumber)
+		}
+		writeBureauLine(&b, "TR",
+			fmt.Sprintf("%d", record.CreditID),
+			fmt.Sprintf("%d", record.UserID),
+			record.Fio,
+			string(record.Event),
+			bureauDate(record.Date),
+			paymentNumber,
+			bureauAmount(record.Amount),
+			bureauAmount(record.Balance),
+			bureauAmount(record.OverdueAmount),
+			fmt.Sprintf("%d", record.DaysPastDue),
+			string(record.Status),
+		)
+	}
+
+	writeBureauLine(&b, "TL", fmt.Sprintf("%d", len(records)))
+	return b.String()
+}
+
+// writeBureauLine записывает строку файла, убирая из полей разделители
+func writeBureauLine(b *strings.Builder, fields ...string) {
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteByte('|')
+		}
+		b.WriteString(strings.NewReplacer("|", " ", "\r", " ", "\n", " ").Replace(field))
+	}
+	b.WriteString("\r\n")
+}
+
+func bureauDate(t time.Time) string {
+	return t.UTC().Format("20060102")
+}
+
+func bureauAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", roundMoney(amount))
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Источники кредитных отчетов
+const (
+	CreditBureauSourceHTTP = "http" // бюро кредитных историй
+	CreditBureauSourceStub = "stub" // тестовые данные для разработки
+)
+
+// CreditBureauReport кредитный отчет заемщика из бюро кредитных историй: обязательства
+// и платежная дисциплина по кредитам в других банках. Отчеты сохраняются и повторно
+// используются для скоринга, пока не устарели.
+type CreditBureauReport struct {
+	gorm.Model
+	UserID          uint      `json:"user_id" gorm:"not null;index"`
+	Source          string    `json:"source" gorm:"type:varchar(20);not null"`
+	Found           bool      `json:"found"`                                                // кредитная история найдена
+	BureauScore     int       `json:"bureau_score"`                                         // балл бюро, 0 — нет данных
+	ActiveCredits   int       `json:"active_credits"`                                       // непогашенные кредиты в других банках
+	MonthlyPayments float64   `json:"monthly_payments" gorm:"type:decimal(20,2);default:0"` // ежемесячные платежи по ним
+	OverdueAmount   float64   `json:"overdue_amount" gorm:"type:decimal(20,2);default:0"`   // текущая просроченная задолженность
+	LatePayments    int       `json:"late_payments"`                                        // платежи, внесенные с просрочкой
+	MaxDaysPastDue  int       `json:"max_days_past_due"`
+	ReceivedAt      time.Time `json:"received_at" gorm:"not null"`
+}
+
+// IsFresh проверяет, можно ли использовать отчет на момент now при сроке хранения ttl
+func (r *CreditBureauReport) IsFresh(ttl time.Duration, now time.Time) bool {
+	return now.Before(r.ReceivedAt.Add(ttl))
+}
+
+// HasDelinquency проверяет, есть ли у заемщика текущая просрочка в других банках
+func (r *CreditBureauReport) HasDelinquency() bool {
+	return r.OverdueAmount > 0
+}
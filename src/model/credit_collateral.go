@@ -0,0 +1,168 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCollateral          = errors.New("invalid collateral")
+	ErrInvalidGuarantor           = errors.New("invalid guarantor")
+	ErrCollateralRequired         = errors.New("collateral is required for the credit product")
+	ErrCollateralAppraisalExpired = errors.New("collateral appraisal is out of date")
+	ErrLoanToValueExceeded        = errors.New("loan-to-value ratio exceeds the credit product limit")
+)
+
+// MaxAppraisalAgeMonths срок действия оценки залога на дату одобрения кредита
+const MaxAppraisalAgeMonths = 6
+
+// CollateralStatus статус залога
+type CollateralStatus string
+
+const (
+	CollateralStatusRegistered CollateralStatus = "REGISTERED" // принят по заявке
+	CollateralStatusPledged    CollateralStatus = "PLEDGED"    // в залоге по выданному кредиту
+	CollateralStatusReleased   CollateralStatus = "RELEASED"   // обременение снято после погашения
+)
+
+// GuarantorStatus статус поручительства
+type GuarantorStatus string
+
+const (
+	GuarantorStatusRegistered GuarantorStatus = "REGISTERED" // принято по заявке
+	GuarantorStatusActive     GuarantorStatus = "ACTIVE"     // действует по выданному кредиту
+	GuarantorStatusReleased   GuarantorStatus = "RELEASED"   // прекращено после погашения
+)
+
+// CollateralDocument документ по предмету залога: ПТС, выписка ЕГРН, отчет оценщика
+type CollateralDocument struct {
+	Name   string `json:"name"`
+	Number string `json:"number,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+// Collateral предмет залога. Регистрируется по заявке, при выдаче кредита переходит в залог,
+// после погашения кредита обременение снимается.
+type Collateral struct {
+	gorm.Model
+	ApplicationID  uint                 `json:"application_id" gorm:"not null;index"`
+	CreditID       *uint                `json:"credit_id,omitempty" gorm:"index"`
+	Type           CollateralType       `json:"type" gorm:"type:varchar(20);not null"`
+	Description    string               `json:"description" gorm:"not null"`
+	AppraisedValue float64              `json:"appraised_value" gorm:"type:decimal(20,2);not null"`
+	AppraisalDate  time.Time            `json:"appraisal_date"`
+	Documents      []CollateralDocument `json:"documents" gorm:"type:text;serializer:json"`
+	Status         CollateralStatus     `json:"status" gorm:"type:varchar(20);not null;default:'REGISTERED'"`
+	ReleasedAt     *time.Time           `json:"released_at,omitempty"`
+}
+
+// Guarantor поручитель по кредиту — клиент банка. LiabilityAmount ограничивает ответственность
+// поручителя, 0 — ответственность в полном объеме долга.
+type Guarantor struct {
+	gorm.Model
+	ApplicationID   uint            `json:"application_id" gorm:"not null;index"`
+	CreditID        *uint           `json:"credit_id,omitempty" gorm:"index"`
+	UserID          uint            `json:"user_id" gorm:"not null;index"`
+	LiabilityAmount float64         `json:"liability_amount" gorm:"type:decimal(20,2);default:0"`
+	Status          GuarantorStatus `json:"status" gorm:"type:varchar(20);not null;default:'REGISTERED'"`
+	ReleasedAt      *time.Time      `json:"released_at,omitempty"`
+}
+
+// CreditSecurity обеспечение по заявке или кредиту
+type CreditSecurity struct {
+	Collateral []Collateral `json:"collateral"`
+	Guarantors []Guarantor  `json:"guarantors"`
+}
+
+// Validate проверяет все поля залога
+func (c *Collateral) Validate() error {
+	switch c.Type {
+	case CollateralVehicle, CollateralRealEstate:
+	default:
+		return ErrInvalidCollateralType
+	}
+	if strings.TrimSpace(c.Description) == "" {
+		return fmt.Errorf("%w: description is required", ErrInvalidCollateral)
+	}
+	if c.AppraisedValue <= 0 {
+		return fmt.Errorf("%w: appraised value", ErrInvalidCollateral)
+	}
+	if c.AppraisalDate.IsZero() || c.AppraisalDate.After(time.Now()) {
+		return fmt.Errorf("%w: appraisal date", ErrInvalidCollateral)
+	}
+	for _, document := range c.Documents {
+		if strings.TrimSpace(document.Name) == "" {
+			return fmt.Errorf("%w: document name is required", ErrInvalidCollateral)
+		}
+	}
+	return nil
+}
+
+// BeforeCreate хук для валидации перед созданием
+func (c *Collateral) BeforeCreate(tx *gorm.DB) error {
+	if c.Status == "" {
+		c.Status = CollateralStatusRegistered
+	}
+	return c.Validate()
+}
+
+// IsAppraisalCurrent проверяет, действует ли оценка залога на дату now
+func (c *Collateral) IsAppraisalCurrent(now time.Time) bool {
+	return now.Before(c.AppraisalDate.AddDate(0, MaxAppraisalAgeMonths, 0))
+}
+
+// Validate проверяет все поля поручительства
+func (g *Guarantor) Validate() error {
+	if g.UserID == 0 {
+		return fmt.Errorf("%w: user is required", ErrInvalidGuarantor)
+	}
+	if g.LiabilityAmount < 0 {
+		return fmt.Errorf("%w: liability amount", ErrInvalidGuarantor)
+	}
+	return nil
+}
+
+// BeforeCreate хук для валидации перед созданием
+func (g *Guarantor) BeforeCreate(tx *gorm.DB) error {
+	if g.Status == "" {
+		g.Status = GuarantorStatusRegistered
+	}
+	return g.Validate()
+}
+
+// CheckCollateral проверяет обеспечение кредита на сумму amount на дату now и возвращает
+// отношение суммы кредита к стоимости залога в процентах. Учитывается залог требуемого продуктом
+// вида с действующей оценкой. Для продукта без требования к залогу возвращает 0.
+func (p *CreditProduct) CheckCollateral(amount float64, collateral []Collateral, now time.Time) (float64, error) {
+	if p.RequiredCollateral == CollateralNone {
+		return 0, nil
+	}
+
+	value := 0.0
+	found := false
+	for _, item := range collateral {
+		if item.Type != p.RequiredCollateral {
+			continue
+		}
+		found = true
+		if item.IsAppraisalCurrent(now) {
+			value += item.AppraisedValue
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("%w: %s", ErrCollateralRequired, p.RequiredCollateral)
+	}
+	if value == 0 {
+		return 0, fmt.Errorf("%w: older than %d months", ErrCollateralAppraisalExpired, MaxAppraisalAgeMonths)
+	}
+
+	ltv := roundMoney(amount / value * 100)
+	if p.MaxLoanToValue > 0 && ltv > p.MaxLoanToValue {
+		return ltv, fmt.Errorf("%w: %.2f%% > %.2f%%", ErrLoanToValueExceeded, ltv, p.MaxLoanToValue)
+	}
+	return ltv, nil
+}
@@ -0,0 +1,156 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// fullCostBasePeriods число базовых периодов в году: базовый период — месяц,
+// самый частый период между платежами по графику
+const fullCostBasePeriods = 12
+
+// CashFlow денежный поток по кредиту. Выдача кредита заемщику учитывается со знаком минус,
+// платежи заемщика — со знаком плюс.
+type CashFlow struct {
+	Date   time.Time `json:"date"`
+	Amount float64   `json:"amount"`
+}
+
+// FullCost полная стоимость кредита (ПСК)
+type FullCost struct {
+	Rate   float64 `json:"full_cost_rate"`   // в процентах годовых
+	Amount float64 `json:"full_cost_amount"` // в денежном выражении: все платежи заемщика сверх суммы кредита
+}
+
+// CreditQuote предварительный расчет кредита по продукту без его выдачи
+type CreditQuote struct {
+	ProductCode      string            `json:"product_code"`
+	ProductName      string            `json:"product_name"`
+	Amount           float64           `json:"amount"`
+	TermMonths       int               `json:"term_months"`
+	RepaymentType    RepaymentType     `json:"repayment_type"`
+	RateType         CreditRateType    `json:"rate_type"`
+	KeyRate          float64           `json:"key_rate"`
+	InterestRate     float64           `json:"interest_rate"`
+	MonthlyPayment   float64           `json:"monthly_payment"` // первый платеж, для дифференцированного погашения самый большой
+	LastPayment      float64           `json:"last_payment"`
+	TotalAmount      float64           `json:"total_amount"` // сумма платежей по графику
+	Overpayment      float64           `json:"overpayment"`  // проценты по графику
+	IssueFee         float64           `json:"issue_fee"`
+	InsurancePremium float64           `json:"insurance_premium"`
+	FullCostRate     float64           `json:"full_cost_rate"`
+	FullCostAmount   float64           `json:"full_cost_amount"`
+	Schedule         []PaymentSchedule `json:"schedule"`
+}
+
+// NewCreditQuote составляет расчет по рассчитанному, но не выданному кредиту
+func NewCreditQuote(product *CreditProduct, credit *Credit, schedule []PaymentSchedule) *CreditQuote {
+	quote := &CreditQuote{
+		ProductCode:      product.Code,
+		ProductName:      product.Name,
+		Amount:           credit.Amount,
+		TermMonths:       credit.Term,
+		RepaymentType:    credit.RepaymentType,
+		RateType:         credit.RateType,
+		KeyRate:          credit.KeyRate,
+		InterestRate:     credit.InterestRate,
+		IssueFee:         credit.IssueFee,
+		InsurancePremium: credit.InsurancePremium,
+		FullCostRate:     credit.FullCostRate,
+		FullCostAmount:   credit.FullCostAmount,
+		Schedule:         schedule,
+	}
+	for _, payment := range schedule {
+		quote.TotalAmount += payment.TotalAmount
+	}
+	if len(schedule) > 0 {
+		quote.MonthlyPayment = schedule[0].TotalAmount
+		quote.LastPayment = schedule[len(schedule)-1].TotalAmount
+	}
+	quote.TotalAmount = roundMoney(quote.TotalAmount)
+	quote.Overpayment = roundMoney(quote.TotalAmount - credit.Amount)
+	return quote
+}
+
+// CashFlows возвращает денежные потоки по графику платежей: выдачу кредита за вычетом
+// комиссии и страховой премии, оплаченных в дату выдачи, и платежи по графику.
+// Неустойка в полную стоимость кредита не включается.
+func (c *Credit) CashFlows(schedule []PaymentSchedule) []CashFlow {
+	flows := make([]CashFlow, 0, len(schedule)+1)
+	flows = append(flows, CashFlow{
+		Date:   c.StartDate,
+		Amount: roundMoney(-c.Amount + c.IssueFee + c.InsurancePremium),
+	})
+	for _, payment := range schedule {
+		flows = append(flows, CashFlow{Date: payment.DueDate, Amount: payment.TotalAmount})
+	}
+	return flows
+}
+
+// CalculateFullCost рассчитывает полную стоимость кредита по графику платежей
+func (c *Credit) CalculateFullCost(schedule []PaymentSchedule) FullCost {
+	flows := c.CashFlows(schedule)
+	amount := 0.0
+	for _, flow := range flows {
+		amount += flow.Amount
+	}
+	return FullCost{Rate: FullCostRate(flows), Amount: roundMoney(amount)}
+}
+
+// FullCostRate рассчитывает полную стоимость кредита в процентах годовых с точностью до
+// третьего знака по методике ч. 2 ст. 6 Федерального закона № 353-ФЗ: ПСК = i × ЧБП × 100,
+// где i — процентная ставка базового периода, при которой
+// Σ ДПk / ((1 + ek × i)(1 + i)^qk) = 0;
+// qk — число полных базовых периодов с даты первого потока до k-го потока,
+// ek — срок от окончания qk-го базового периода до k-го потока в долях базового периода.
+func FullCostRate(flows []CashFlow) float64 {
+	if len(flows) < 2 {
+		return 0
+	}
+
+	start := dateOnly(flows[0].Date)
+	periodDays := 365.0 / fullCostBasePeriods
+	q := make([]float64, len(flows))
+	e := make([]float64, len(flows))
+	for k, flow := range flows {
+		date := dateOnly(flow.Date)
+		periods := 0
+		for !start.AddDate(0, periods+1, 0).After(date) {
+			periods++
+		}
+		q[k] = float64(periods)
+		e[k] = date.Sub(start.AddDate(0, periods, 0)).Hours() / 24 / periodDays
+	}
+
+	discounted := func(i float64) float64 {
+		sum := 0.0
+		for k, flow := range flows {
+			sum += flow.Amount / ((1 + e[k]*i) * math.Pow(1+i, q[k]))
+		}
+		return sum
+	}
+
+	// Сумма дисконтированных потоков убывает с ростом ставки: ищем ноль делением отрезка
+	if discounted(0) <= 0 {
+		return 0
+	}
+	low, high := 0.0, 1.0
+	for discounted(high) > 0 && high < 1e6 {
+		high *= 2
+	}
+	for n := 0; n < 200; n++ {
+		middle := (low + high) / 2
+		if discounted(middle) > 0 {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+
+	return math.Round(low*fullCostBasePeriods*100*1000) / 1000
+}
+
+// dateOnly отбрасывает время: потоки считаются по календарным дням
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
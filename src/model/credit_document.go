@@ -0,0 +1,66 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// CreditDocumentType вид документа по кредиту
+type CreditDocumentType string
+
+const (
+	CreditDocumentAgreement CreditDocumentType = "AGREEMENT" // кредитный договор
+	CreditDocumentSchedule  CreditDocumentType = "SCHEDULE"  // график платежей
+)
+
+// CreditDocumentTypes виды документов, формируемых по каждому кредиту
+var CreditDocumentTypes = []CreditDocumentType{CreditDocumentAgreement, CreditDocumentSchedule}
+
+// CreditDocumentFormat формат файла документа
+type CreditDocumentFormat string
+
+const (
+	CreditDocumentHTML CreditDocumentFormat = "HTML"
+	CreditDocumentPDF  CreditDocumentFormat = "PDF"
+)
+
+// CreditDocumentFormats форматы, в которых формируется каждый документ
+var CreditDocumentFormats = []CreditDocumentFormat{CreditDocumentPDF, CreditDocumentHTML}
+
+// CreditDocument документ по кредиту, сформированный из шаблона и подписанный
+// отсоединенной PGP-подписью банка. Документы формируются для каждой версии графика:
+// после реструктуризации заемщик получает новый договор и график. Каждый документ
+// формируется для версии графика один раз.
+type CreditDocument struct {
+	gorm.Model
+	CreditID        uint                 `json:"credit_id" gorm:"not null;uniqueIndex:idx_credit_document_version"`
+	UserID          uint                 `json:"user_id" gorm:"not null"`
+	Type            CreditDocumentType   `json:"type" gorm:"type:varchar(20);not null;uniqueIndex:idx_credit_document_version"`
+	Format          CreditDocumentFormat `json:"format" gorm:"type:varchar(10);not null;uniqueIndex:idx_credit_document_version"`
+	ScheduleVersion int                  `json:"schedule_version" gorm:"not null;uniqueIndex:idx_credit_document_version"`
+	FileName        string               `json:"file_name" gorm:"not null"`
+	Content         []byte               `json:"-" gorm:"not null"`
+	Signature       string               `json:"-" gorm:"type:text;not null"` // ASCII-armored подпись файла
+	KeyID           string               `json:"key_id" gorm:"type:varchar(20);not null"`
+}
+
+// ContentType возвращает MIME-тип файла документа
+func (d *CreditDocument) ContentType() string {
+	if d.Format == CreditDocumentPDF {
+		return "application/pdf"
+	}
+	return "text/html; charset=utf-8"
+}
+
+// SignatureFileName возвращает имя файла подписи документа
+func (d *CreditDocument) SignatureFileName() string {
+	return d.FileName + ".asc"
+}
+
+// CreditDocumentFileName возвращает имя файла документа, например credit_9_agreement_v1.pdf
+func CreditDocumentFileName(creditID uint, scheduleVersion int, docType CreditDocumentType, format CreditDocumentFormat) string {
+	return fmt.Sprintf("credit_%d_%s_v%d.%s", creditID,
+		strings.ToLower(string(docType)), scheduleVersion, strings.ToLower(string(format)))
+}
@@ -0,0 +1,157 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	ErrInvalidEarlyRepaymentMode = errors.New("invalid early repayment mode")
+	ErrCreditHasOverdue          = errors.New("credit has overdue payments")
+	ErrAmountBelowAccrued        = errors.New("amount does not cover accrued interest")
+)
+
+// EarlyRepaymentMode способ пересчета графика после частичного досрочного погашения
+type EarlyRepaymentMode string
+
+const (
+	// EarlyRepaymentReduceTerm сохраняет размер платежа и сокращает срок
+	EarlyRepaymentReduceTerm EarlyRepaymentMode = "REDUCE_TERM"
+	// EarlyRepaymentReducePayment сохраняет срок и уменьшает платежи
+	EarlyRepaymentReducePayment EarlyRepaymentMode = "REDUCE_PAYMENT"
+)
+
+// Validate проверяет способ пересчета графика
+func (m EarlyRepaymentMode) Validate() error {
+	switch m {
+	case EarlyRepaymentReduceTerm, EarlyRepaymentReducePayment:
+		return nil
+	default:
+		return ErrInvalidEarlyRepaymentMode
+	}
+}
+
+// PayoffQuote сумма полного досрочного погашения кредита на дату
+type PayoffQuote struct {
+	CreditID             uint      `json:"credit_id"`
+	AsOf                 time.Time `json:"as_of"`
+	OutstandingPrincipal float64   `json:"outstanding_principal"`
+	AccruedInterest      float64   `json:"accrued_interest"`
+	DeferredInterest     float64   `json:"deferred_interest"`
+	PayoffAmount         float64   `json:"payoff_amount"`
+	NextPaymentNumber    int       `json:"next_payment_number"`
+	// Дата, с которой начисляются проценты текущего периода
+	InterestFrom time.Time `json:"interest_from"`
+}
+
+// CalculatePayoff рассчитывает сумму полного погашения по сохраненному графику на дату now.
+// Проценты текущего периода начисляются по дням с последней даты платежа по графику
+// или с даты последнего внесенного платежа или окончания кредитных каникул, если они были позже.
+func (c *Credit) CalculatePayoff(schedule []PaymentSchedule, now time.Time) (*PayoffQuote, error) {
+	quote := &PayoffQuote{CreditID: c.ID, AsOf: now, InterestFrom: c.StartDate}
+	for _, payment := range schedule {
+		if payment.IsPaid() {
+			if payment.DueDate.After(quote.InterestFrom) {
+				quote.InterestFrom = payment.DueDate
+			}
+			continue
+		}
+		if payment.IsDue(now) {
+			return nil, ErrCreditHasOverdue
+		}
+		if quote.NextPaymentNumber == 0 {
+			quote.NextPaymentNumber = payment.PaymentNumber
+		}
+		quote.OutstandingPrincipal += payment.Principal
+		quote.DeferredInterest += payment.DeferredInterest
+	}
+	if quote.NextPaymentNumber == 0 {
+		return nil, ErrCreditNotActive
+	}
+	if c.LastPayment.After(quote.InterestFrom) {
+		quote.InterestFrom = c.LastPayment
+	}
+	if c.HolidayUntil.After(quote.InterestFrom) {
+		quote.InterestFrom = c.HolidayUntil
+	}
+
+	quote.OutstandingPrincipal = roundMoney(quote.OutstandingPrincipal)
+	quote.AccruedInterest = c.accrueInterest(quote.OutstandingPrincipal, quote.InterestFrom, now)
+	quote.DeferredInterest = roundMoney(quote.DeferredInterest)
+	quote.PayoffAmount = roundMoney(quote.OutstandingPrincipal + quote.AccruedInterest + quote.DeferredInterest)
+	return quote, nil
+}
+
+// RecalculateSchedule строит неоплаченную часть графика после частичного досрочного погашения.
+// remaining — неоплаченные платежи по порядку, principal — остаток основного долга после погашения,
+// interestFrom — дата, с которой начисляются проценты первого пересчитанного периода.
+// Даты платежей сохраняются, при сокращении срока последние платежи отбрасываются.
+// Отсроченные проценты неоплаченных платежей распределяются по пересчитанным платежам.
+func (c *Credit) RecalculateSchedule(remaining []PaymentSchedule, principal float64, mode EarlyRepaymentMode, interestFrom time.Time) []PaymentSchedule {
+	if len(remaining) == 0 || principal <= 0 {
+		return nil
+	}
+
+	periods := len(remaining)
+	deferred := 0.0
+	for _, payment := range remaining {
+		deferred += payment.DeferredInterest
+	}
+	monthlyRate := c.InterestRate / 12 / 100
+	first := remaining[0]
+
+	// Уровень платежа: аннуитетный платеж или доля основного долга для дифференцированного
+	level := first.TotalAmount
+	if c.RepaymentType == RepaymentTypeDifferentiated {
+		level = first.Principal
+	}
+	if mode == EarlyRepaymentReducePayment {
+		if c.RepaymentType == RepaymentTypeDifferentiated {
+			level = principal / float64(periods)
+		} else {
+			level = principal * monthlyRate / (1 - 1/pow(1+monthlyRate, float64(periods)))
+		}
+	}
+	level = roundMoney(level)
+
+	schedule := make([]PaymentSchedule, 0, periods)
+	for i := 0; i < periods && principal > 0; i++ {
+		interest := roundMoney(principal * monthlyRate)
+		if i == 0 {
+			interest = c.accrueInterest(principal, interestFrom, remaining[i].DueDate)
+		}
+
+		part := level
+		if c.RepaymentType != RepaymentTypeDifferentiated {
+			part = roundMoney(level - interest)
+		}
+		if i == periods-1 || part > principal || part <= 0 {
+			part = roundMoney(principal)
+		}
+		principal = roundMoney(principal - part)
+		total := roundMoney(part + interest)
+
+		schedule = append(schedule, PaymentSchedule{
+			CreditID:      c.ID,
+			PaymentNumber: remaining[i].PaymentNumber,
+			DueDate:       remaining[i].DueDate,
+			Amount:        total,
+			Interest:      interest,
+			Principal:     part,
+			TotalAmount:   total,
+			Status:        PaymentStatusPending,
+		})
+	}
+	spreadDeferredInterest(schedule, roundMoney(deferred))
+	return schedule
+}
+
+// accrueInterest начисляет проценты на основной долг по дням между from и to
+func (c *Credit) accrueInterest(principal float64, from, to time.Time) float64 {
+	days := math.Floor(to.Sub(from).Hours() / 24)
+	if days <= 0 {
+		return 0
+	}
+	return roundMoney(principal * c.InterestRate / 100 / 365 * days)
+}
@@ -0,0 +1,252 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCreditLine   = errors.New("invalid credit line terms")
+	ErrCreditLineNotActive = errors.New("credit line is not active")
+)
+
+const (
+	// MaxGracePeriodDays наибольшее число дней от даты выписки до даты платежа: итог по выписке
+	// подводится раньше, чем формируется следующая выписка
+	MaxGracePeriodDays = 25
+	// DefaultGracePeriodDays дней от даты выписки до даты платежа
+	DefaultGracePeriodDays = 20
+	// DefaultMinPaymentPercent минимальный платеж, процентов от задолженности по выписке
+	DefaultMinPaymentPercent = 5.0
+	// DefaultMinPaymentAmount минимальный платеж, не меньше этой суммы
+	DefaultMinPaymentAmount = 300.0
+)
+
+type CreditLineStatus string
+
+const (
+	CreditLineStatusActive CreditLineStatus = "ACTIVE"
+	CreditLineStatusClosed CreditLineStatus = "CLOSED"
+)
+
+type CreditStatementStatus string
+
+const (
+	CreditStatementStatusOpen        CreditStatementStatus = "OPEN"         // дата платежа не наступила
+	CreditStatementStatusPaid        CreditStatementStatus = "PAID"         // задолженность по выписке погашена полностью
+	CreditStatementStatusMinimumPaid CreditStatementStatus = "MINIMUM_PAID" // внесен минимальный платеж
+	CreditStatementStatusOverdue     CreditStatementStatus = "OVERDUE"      // минимальный платеж не внесен
+)
+
+// CreditLine возобновляемая кредитная линия по счету типа CREDIT. Лимит хранится на счете
+// и уменьшается тратами со счета, погашение восстанавливает лимит. Раз в месяц в день выписки
+// формируется выписка с минимальным платежом. Льготный период — расчетный период и GracePeriodDays
+// дней до даты платежа: если задолженность по выписке погашена полностью, проценты за период не начисляются.
+type CreditLine struct {
+	gorm.Model
+	UserID            uint             `json:"user_id" gorm:"not null;index"`
+	AccountID         uint             `json:"account_id" gorm:"not null;uniqueIndex"`
+	InterestRate      float64          `json:"interest_rate" gorm:"type:decimal(5,2);not null"` // процентов годовых
+	GracePeriodDays   int              `json:"grace_period_days" gorm:"not null"`
+	MinPaymentPercent float64          `json:"min_payment_percent" gorm:"type:decimal(5,2);not null"`
+	MinPaymentAmount  float64          `json:"min_payment_amount" gorm:"type:decimal(20,2);not null"`
+	StatementDay      int              `json:"statement_day" gorm:"not null"`
+	NextStatementDate time.Time        `json:"next_statement_date"`
+	Status            CreditLineStatus `json:"status" gorm:"type:varchar(20);not null;default:'ACTIVE'"`
+	// Account счет с лимитом и задолженностью, заполняется сервисом
+	Account *Account `json:"account,omitempty" gorm:"-"`
+}
+
+// CreditStatement выписка по кредитной линии за расчетный период [PeriodStart, PeriodEnd).
+// Балансы указаны по счету: отрицательный баланс — задолженность.
+type CreditStatement struct {
+	gorm.Model
+	CreditLineID   uint                  `json:"credit_line_id" gorm:"not null;index"`
+	AccountID      uint                  `json:"account_id" gorm:"not null"`
+	UserID         uint                  `json:"user_id" gorm:"not null"`
+	PeriodStart    time.Time             `json:"period_start"`
+	PeriodEnd      time.Time             `json:"period_end"` // дата выписки
+	OpeningBalance float64               `json:"opening_balance" gorm:"type:decimal(20,2)"`
+	Purchases      float64               `json:"purchases" gorm:"type:decimal(20,2)"`
+	Interest       float64               `json:"interest" gorm:"type:decimal(20,2)"` // проценты, списанные в периоде
+	Payments       float64               `json:"payments" gorm:"type:decimal(20,2)"`
+	ClosingBalance float64               `json:"closing_balance" gorm:"type:decimal(20,2)"`
+	Debt           float64               `json:"debt" gorm:"type:decimal(20,2)"` // задолженность по выписке
+	MinimumPayment float64               `json:"minimum_payment" gorm:"type:decimal(20,2)"`
+	DueDate        time.Time             `json:"due_date"`
+	PaidAmount     float64               `json:"paid_amount" gorm:"type:decimal(20,2)"`     // зачислено с даты выписки по дату платежа
+	GracePeriod    bool                  `json:"grace_period"`                              // проценты за период не начислены
+	PeriodInterest float64               `json:"period_interest" gorm:"type:decimal(20,2)"` // проценты за период, если льготный период не сохранен
+	Status         CreditStatementStatus `json:"status" gorm:"type:varchar(20);not null;default:'OPEN'"`
+}
+
+// Validate проверяет условия кредитной линии
+func (l *CreditLine) Validate() error {
+	if l.InterestRate <= 0 || l.InterestRate > 100 {
+		return ErrInvalidCreditLine
+	}
+	if l.GracePeriodDays < 1 || l.GracePeriodDays > MaxGracePeriodDays {
+		return ErrInvalidCreditLine
+	}
+	if l.MinPaymentPercent <= 0 || l.MinPaymentPercent > 100 || l.MinPaymentAmount < 0 {
+		return ErrInvalidCreditLine
+	}
+	// День выписки есть в каждом месяце
+	if l.StatementDay < 1 || l.StatementDay > 28 {
+		return ErrInvalidCreditLine
+	}
+	return nil
+}
+
+// BeforeCreate хук для валидации перед созданием
+func (l *CreditLine) BeforeCreate(tx *gorm.DB) error {
+	return l.Validate()
+}
+
+// BeforeUpdate хук для валидации перед обновлением
+func (l *CreditLine) BeforeUpdate(tx *gorm.DB) error {
+	return l.Validate()
+}
+
+// IsActive проверяет, действует ли кредитная линия
+func (l *CreditLine) IsActive() bool {
+	return l.Status == CreditLineStatusActive
+}
+
+// StatementDateAfter возвращает первую дату выписки после date
+func (l *CreditLine) StatementDateAfter(date time.Time) time.Time {
+	day := dateOnly(date)
+	next := time.Date(day.Year(), day.Month(), l.StatementDay, 0, 0, 0, 0, time.UTC)
+	if !next.After(day) {
+		next = next.AddDate(0, 1, 0)
+	}
+	return next
+}
+
+// PeriodStart возвращает начало очередного расчетного периода: дату предыдущей выписки
+// или день открытия кредитной линии
+func (l *CreditLine) PeriodStart(previous *CreditStatement) time.Time {
+	if previous != nil {
+		return previous.PeriodEnd
+	}
+	return dateOnly(l.CreatedAt)
+}
+
+// MinimumPayment рассчитывает минимальный платеж: процент от задолженности по выписке,
+// но не меньше MinPaymentAmount и не больше самой задолженности
+func (l *CreditLine) MinimumPayment(debt float64) float64 {
+	if debt <= 0 {
+		return 0
+	}
+	payment := math.Max(roundMoney(debt*l.MinPaymentPercent/100), l.MinPaymentAmount)
+	return math.Min(payment, roundMoney(debt))
+}
+
+// IssueStatement формирует выписку за период [periodStart, periodEnd). closingBalance — баланс
+// счета на дату выписки, transactions — операции по счету за период.
+func (l *CreditLine) IssueStatement(periodStart, periodEnd time.Time, closingBalance float64, transactions []Transaction) *CreditStatement {
+	statement := &CreditStatement{
+		CreditLineID:   l.ID,
+		AccountID:      l.AccountID,
+		UserID:         l.UserID,
+		PeriodStart:    periodStart,
+		PeriodEnd:      periodEnd,
+		ClosingBalance: roundMoney(closingBalance),
+		DueDate:        periodEnd.AddDate(0, 0, l.GracePeriodDays),
+		Status:         CreditStatementStatusOpen,
+	}
+
+	opening := closingBalance
+	for _, transaction := range transactions {
+		change := BalanceChange(l.AccountID, transaction)
+		switch {
+		case change > 0:
+			statement.Payments += change
+		case transaction.Type == TransactionTypeInterest:
+			statement.Interest -= change
+		default:
+			statement.Purchases -= change
+		}
+		opening -= change
+	}
+	statement.OpeningBalance = roundMoney(opening)
+	statement.Purchases = roundMoney(statement.Purchases)
+	statement.Interest = roundMoney(statement.Interest)
+	statement.Payments = roundMoney(statement.Payments)
+	statement.Debt = roundMoney(math.Max(0, -closingBalance))
+	statement.MinimumPayment = l.MinimumPayment(statement.Debt)
+	return statement
+}
+
+// Settle подводит итог выписки на дату платежа. paid — зачисления на счет с даты выписки по дату
+// платежа включительно, previous — предыдущая выписка или nil, transactions — операции за период выписки.
+// Льготный период сохраняется, если полностью погашена задолженность и по этой, и по предыдущей выписке.
+// Иначе начисляются проценты на задолженность на конец каждого дня расчетного периода.
+// Возвращает проценты к списанию.
+func (l *CreditLine) Settle(statement *CreditStatement, previous *CreditStatement, paid float64, transactions []Transaction) float64 {
+	statement.PaidAmount = roundMoney(paid)
+	switch {
+	case statement.PaidAmount >= statement.Debt:
+		statement.Status = CreditStatementStatusPaid
+	case statement.PaidAmount >= statement.MinimumPayment:
+		statement.Status = CreditStatementStatusMinimumPaid
+	default:
+		statement.Status = CreditStatementStatusOverdue
+	}
+
+	statement.GracePeriod = statement.Status == CreditStatementStatusPaid &&
+		(previous == nil || previous.Status == CreditStatementStatusPaid)
+	if statement.GracePeriod {
+		return 0
+	}
+	statement.PeriodInterest = l.periodInterest(statement, transactions)
+	return statement.PeriodInterest
+}
+
+// periodInterest начисляет проценты на задолженность на конец каждого дня периода выписки
+func (l *CreditLine) periodInterest(statement *CreditStatement, transactions []Transaction) float64 {
+	balance := statement.OpeningBalance
+	interest := 0.0
+	next := 0
+	for day := dateOnly(statement.PeriodStart); day.Before(statement.PeriodEnd); day = day.AddDate(0, 0, 1) {
+		dayEnd := day.AddDate(0, 0, 1)
+		for ; next < len(transactions) && transactions[next].CreatedAt.Before(dayEnd); next++ {
+			balance += BalanceChange(l.AccountID, transactions[next])
+		}
+		if balance < 0 {
+			interest += -balance * l.InterestRate / 100 / 365
+		}
+	}
+	return roundMoney(interest)
+}
+
+// IsDue проверяет, закончился ли день платежа по выписке
+func (s *CreditStatement) IsDue(now time.Time) bool {
+	return s.Status == CreditStatementStatusOpen && !now.Before(s.PaymentDeadline())
+}
+
+// PaymentDeadline возвращает момент окончания дня платежа
+func (s *CreditStatement) PaymentDeadline() time.Time {
+	return dateOnly(s.DueDate).AddDate(0, 0, 1)
+}
+
+// BalanceChange возвращает изменение баланса счета по транзакции: списание со знаком минус,
+// зачисление со знаком плюс. Отклоненные и отмененные операции и неустойка по кредитам
+// баланс не изменяют.
+func BalanceChange(accountID uint, transaction Transaction) float64 {
+	if transaction.Status == TransactionStatusFailed || transaction.Status == TransactionStatusCancelled ||
+		transaction.Type == TransactionTypePenalty {
+		return 0
+	}
+	change := 0.0
+	if transaction.ToAccountID == accountID {
+		change += transaction.Amount
+	}
+	if transaction.FromAccountID == accountID {
+		change -= transaction.Amount
+	}
+	return change
+}
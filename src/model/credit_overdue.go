@@ -0,0 +1,104 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// MaxPenaltyRate предельный размер неустойки за просрочку, процентов годовых.
+// Ограничение 353-ФЗ для кредитов, по которым в период просрочки начисляются проценты.
+const MaxPenaltyRate = 20.0
+
+// CapPenaltyRate ограничивает ставку неустойки допустимыми пределами
+func CapPenaltyRate(rate float64) float64 {
+	return math.Max(0, math.Min(rate, MaxPenaltyRate))
+}
+
+// PaymentAllocation распределение поступившей суммы по составу задолженности
+type PaymentAllocation struct {
+	Penalty   float64 `json:"penalty"`
+	Interest  float64 `json:"interest"`
+	Principal float64 `json:"principal"`
+}
+
+// Total возвращает распределенную сумму
+func (a PaymentAllocation) Total() float64 {
+	return roundMoney(a.Penalty + a.Interest + a.Principal)
+}
+
+// PenaltyDue возвращает неоплаченную неустойку по платежу
+func (p *PaymentSchedule) PenaltyDue() float64 {
+	return math.Max(0, roundMoney(p.PenaltyAccrued-p.PenaltyPaid))
+}
+
+// InterestDue возвращает неоплаченные проценты по платежу
+func (p *PaymentSchedule) InterestDue() float64 {
+	return math.Max(0, math.Min(roundMoney(p.Interest-p.InterestPaid), p.Remaining()))
+}
+
+// PrincipalDue возвращает неоплаченный основной долг по платежу
+func (p *PaymentSchedule) PrincipalDue() float64 {
+	return math.Max(0, roundMoney(p.Remaining()-p.InterestDue()))
+}
+
+// AmountDue возвращает сумму, необходимую для закрытия платежа, вместе с неустойкой
+func (p *PaymentSchedule) AmountDue() float64 {
+	return roundMoney(p.Remaining() + p.PenaltyDue())
+}
+
+// AccruePenalty отмечает платеж просроченным, обновляет число дней просрочки и начисляет
+// неустойку на просроченную часть платежа за полные дни с предыдущего начисления.
+// Возвращает начисленную сумму.
+func (p *PaymentSchedule) AccruePenalty(annualRate float64, now time.Time) float64 {
+	if !p.IsDue(now) {
+		return 0
+	}
+	p.MarkOverdue()
+	p.DaysPastDue = wholeDays(p.DueDate, now)
+
+	from := p.DueDate
+	if p.PenaltyAccruedAt != nil {
+		from = *p.PenaltyAccruedAt
+	}
+	days := wholeDays(from, now)
+	penalty := roundMoney(p.Remaining() * annualRate / 100 / 365 * float64(days))
+	// Сумма меньше копейки переносится на следующее начисление
+	if penalty <= 0 {
+		return 0
+	}
+
+	accruedAt := from.AddDate(0, 0, days)
+	p.PenaltyAccruedAt = &accruedAt
+	p.PenaltyAccrued = roundMoney(p.PenaltyAccrued + penalty)
+	return penalty
+}
+
+// ApplyPayment распределяет поступившую сумму в очередности, установленной законом:
+// неустойка, проценты, основной долг. Сумма сверх задолженности по платежу не учитывается.
+// Платеж закрывается, когда погашены все составляющие.
+func (p *PaymentSchedule) ApplyPayment(amount float64, paidAt time.Time) PaymentAllocation {
+	var allocation PaymentAllocation
+	allocation.Penalty = roundMoney(math.Min(amount, p.PenaltyDue()))
+	amount -= allocation.Penalty
+	allocation.Interest = roundMoney(math.Min(amount, p.InterestDue()))
+	amount -= allocation.Interest
+	allocation.Principal = roundMoney(math.Min(amount, p.PrincipalDue()))
+
+	p.PenaltyPaid = roundMoney(p.PenaltyPaid + allocation.Penalty)
+	p.InterestPaid = roundMoney(p.InterestPaid + allocation.Interest)
+	p.PrincipalPaid = roundMoney(p.PrincipalPaid + allocation.Principal)
+	p.PaidAmount = roundMoney(p.PaidAmount + allocation.Interest + allocation.Principal)
+	p.PaidAt = &paidAt
+	if p.Remaining() == 0 && p.PenaltyDue() == 0 {
+		p.Status = PaymentStatusPaid
+	}
+	return allocation
+}
+
+// wholeDays возвращает число полных дней между датами
+func wholeDays(from, to time.Time) int {
+	if !to.After(from) {
+		return 0
+	}
+	return int(to.Sub(from).Hours() / 24)
+}
@@ -0,0 +1,215 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCreditProductNotFound   = errors.New("credit product not found")
+	ErrCreditProductInactive   = errors.New("credit product is not active")
+	ErrInvalidCreditProduct    = errors.New("invalid credit product")
+	ErrInvalidCreditRateType   = errors.New("invalid credit rate type")
+	ErrInvalidCollateralType   = errors.New("invalid collateral type")
+	ErrAmountOutOfProduct      = errors.New("amount is outside the credit product limits")
+	ErrTermOutOfProduct        = errors.New("term is outside the credit product limits")
+	ErrRepaymentTypeNotAllowed = errors.New("repayment type is not allowed for the credit product")
+)
+
+// CreditRateType способ определения ставки кредитного продукта
+type CreditRateType string
+
+const (
+	// CreditRateFixed фиксированная ставка продукта
+	CreditRateFixed CreditRateType = "FIXED"
+	// CreditRateKeyRateSpread ключевая ставка на дату выдачи плюс надбавка продукта
+	CreditRateKeyRateSpread CreditRateType = "KEY_RATE_SPREAD"
+	// CreditRateFloating ключевая ставка плюс маржа продукта, пересматривается
+	// при каждом изменении ключевой ставки
+	CreditRateFloating CreditRateType = "FLOATING"
+)
+
+// CollateralType вид обеспечения, которое требуется по продукту
+type CollateralType string
+
+const (
+	CollateralNone       CollateralType = ""
+	CollateralVehicle    CollateralType = "VEHICLE"
+	CollateralRealEstate CollateralType = "REAL_ESTATE"
+)
+
+// DefaultCreditProductCode продукт, по которому подается заявка, если продукт не указан
+const DefaultCreditProductCode = "CONSUMER"
+
+// CreditProduct описывает кредитный продукт банка из каталога
+type CreditProduct struct {
+	gorm.Model
+	Code               string         `json:"code" gorm:"unique;not null"`
+	Name               string         `json:"name" gorm:"not null"`
+	MinAmount          float64        `json:"min_amount" gorm:"type:decimal(20,2);not null"`
+	MaxAmount          float64        `json:"max_amount" gorm:"type:decimal(20,2);not null"`
+	MinTerm            int            `json:"min_term" gorm:"not null"` // в месяцах
+	MaxTerm            int            `json:"max_term" gorm:"not null"` // в месяцах
+	RateType           CreditRateType `json:"rate_type" gorm:"type:varchar(20);not null"`
+	RateSpread         float64        `json:"rate_spread" gorm:"type:decimal(5,2);default:0"` // надбавка (маржа) к ключевой ставке
+	FixedRate          float64        `json:"fixed_rate" gorm:"type:decimal(5,2);default:0"`
+	RepaymentType      RepaymentType  `json:"repayment_type,omitempty" gorm:"type:varchar(20)"` // пусто - на выбор клиента
+	IssueFee           float64        `json:"issue_fee" gorm:"type:decimal(20,2);default:0"`    // фиксированная комиссия за выдачу
+	IssueFeePercent    float64        `json:"issue_fee_percent" gorm:"type:decimal(5,2);default:0"`
+	InsurancePercent   float64        `json:"insurance_percent" gorm:"type:decimal(5,2);default:0"` // разовая страховая премия от суммы кредита
+	RequiredCollateral CollateralType `json:"required_collateral,omitempty" gorm:"type:varchar(20)"`
+	MaxLoanToValue     float64        `json:"max_loan_to_value" gorm:"type:decimal(5,2);default:0"` // предельное отношение суммы кредита к стоимости залога, %; 0 - без ограничения
+	IsActive           bool           `json:"is_active" gorm:"default:true"`
+}
+
+// Validate проверяет все поля продукта
+func (p *CreditProduct) Validate() error {
+	if strings.TrimSpace(p.Code) == "" || strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: code and name are required", ErrInvalidCreditProduct)
+	}
+	if p.MinAmount <= 0 || p.MaxAmount < p.MinAmount {
+		return fmt.Errorf("%w: amount limits", ErrInvalidCreditProduct)
+	}
+	// Предельный срок общий для всех кредитов
+	if p.MinTerm <= 0 || p.MaxTerm < p.MinTerm || p.MaxTerm > 360 {
+		return fmt.Errorf("%w: term limits", ErrInvalidCreditProduct)
+	}
+	switch p.RateType {
+	case CreditRateFixed:
+		if p.FixedRate <= 0 || p.FixedRate > 100 {
+			return ErrInvalidInterestRate
+		}
+	case CreditRateKeyRateSpread, CreditRateFloating:
+		if p.RateSpread < 0 || p.RateSpread > 100 {
+			return ErrInvalidInterestRate
+		}
+	default:
+		return ErrInvalidCreditRateType
+	}
+	if p.RepaymentType != "" {
+		credit := Credit{RepaymentType: p.RepaymentType}
+		if err := credit.ValidateRepaymentType(); err != nil {
+			return err
+		}
+	}
+	if p.IssueFee < 0 || p.IssueFeePercent < 0 || p.IssueFeePercent > 100 {
+		return fmt.Errorf("%w: fees", ErrInvalidCreditProduct)
+	}
+	if p.InsurancePercent < 0 || p.InsurancePercent > 100 {
+		return fmt.Errorf("%w: insurance", ErrInvalidCreditProduct)
+	}
+	switch p.RequiredCollateral {
+	case CollateralNone, CollateralVehicle, CollateralRealEstate:
+	default:
+		return ErrInvalidCollateralType
+	}
+	if p.MaxLoanToValue < 0 || p.MaxLoanToValue > 100 {
+		return fmt.Errorf("%w: loan-to-value", ErrInvalidCreditProduct)
+	}
+	return nil
+}
+
+// CheckTerms проверяет условия заявки по продукту и возвращает способ погашения:
+// выбранный клиентом или установленный продуктом
+func (p *CreditProduct) CheckTerms(amount float64, term int, repaymentType RepaymentType) (RepaymentType, error) {
+	if !p.IsActive {
+		return "", ErrCreditProductInactive
+	}
+	if amount < p.MinAmount || amount > p.MaxAmount {
+		return "", fmt.Errorf("%w: %.2f-%.2f", ErrAmountOutOfProduct, p.MinAmount, p.MaxAmount)
+	}
+	if term < p.MinTerm || term > p.MaxTerm {
+		return "", fmt.Errorf("%w: %d-%d months", ErrTermOutOfProduct, p.MinTerm, p.MaxTerm)
+	}
+
+	switch {
+	case p.RepaymentType == "":
+		if repaymentType == "" {
+			return RepaymentTypeAnnuity, nil
+		}
+		return repaymentType, nil
+	case repaymentType == "" || repaymentType == p.RepaymentType:
+		return p.RepaymentType, nil
+	default:
+		return "", ErrRepaymentTypeNotAllowed
+	}
+}
+
+// Rate возвращает ставку продукта при заданной ключевой ставке
+func (p *CreditProduct) Rate(keyRate float64) float64 {
+	if p.RateType == CreditRateFixed {
+		return p.FixedRate
+	}
+	return keyRate + p.RateSpread
+}
+
+// Fee возвращает комиссию за выдачу кредита на заданную сумму
+func (p *CreditProduct) Fee(amount float64) float64 {
+	return math.Round((p.IssueFee+amount*p.IssueFeePercent/100)*100) / 100
+}
+
+// Insurance возвращает страховую премию по кредиту на заданную сумму
+func (p *CreditProduct) Insurance(amount float64) float64 {
+	return math.Round(amount*p.InsurancePercent) / 100
+}
+
+// BeforeCreate хук для валидации перед созданием
+func (p *CreditProduct) BeforeCreate(tx *gorm.DB) error {
+	return p.Validate()
+}
+
+// BeforeUpdate хук для валидации перед обновлением
+func (p *CreditProduct) BeforeUpdate(tx *gorm.DB) error {
+	return p.Validate()
+}
+
+// GetDefaultCreditProducts возвращает каталог кредитных продуктов по умолчанию
+func GetDefaultCreditProducts() []CreditProduct {
+	return []CreditProduct{
+		{
+			Code:       "CONSUMER",
+			Name:       "Потребительский кредит",
+			MinAmount:  10000,
+			MaxAmount:  5000000,
+			MinTerm:    3,
+			MaxTerm:    84,
+			RateType:   CreditRateKeyRateSpread,
+			RateSpread: DefaultRateSpread,
+			IsActive:   true,
+		},
+		{
+			Code:               "CAR",
+			Name:               "Автокредит",
+			MinAmount:          100000,
+			MaxAmount:          10000000,
+			MinTerm:            12,
+			MaxTerm:            96,
+			RateType:           CreditRateKeyRateSpread,
+			RateSpread:         3,
+			RepaymentType:      RepaymentTypeAnnuity,
+			IssueFeePercent:    1,
+			RequiredCollateral: CollateralVehicle,
+			MaxLoanToValue:     85,
+			IsActive:           true,
+		},
+		{
+			Code:               "MORTGAGE",
+			Name:               "Ипотека",
+			MinAmount:          500000,
+			MaxAmount:          50000000,
+			MinTerm:            36,
+			MaxTerm:            360,
+			RateType:           CreditRateFixed,
+			FixedRate:          18,
+			IssueFee:           10000,
+			InsurancePercent:   1,
+			RequiredCollateral: CollateralRealEstate,
+			MaxLoanToValue:     80,
+			IsActive:           true,
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// CreditRateHistory запись об установке ставки по кредиту: при выдаче и при каждом
+// пересмотре плавающей ставки
+type CreditRateHistory struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	CreditID       uint      `json:"credit_id" gorm:"index;not null"`
+	KeyRate        float64   `json:"key_rate" gorm:"type:decimal(5,2)"`
+	PreviousRate   float64   `json:"previous_rate" gorm:"type:decimal(5,2)"`
+	InterestRate   float64   `json:"interest_rate" gorm:"type:decimal(5,2);not null"`
+	MonthlyPayment float64   `json:"monthly_payment" gorm:"type:decimal(20,2)"`
+	EffectiveFrom  time.Time `json:"effective_from"` // дата, с которой проценты начисляются по новой ставке
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// IsFloating проверяет, пересматривается ли ставка кредита вслед за ключевой ставкой
+func (c *Credit) IsFloating() bool {
+	return c.RateType == CreditRateFloating
+}
+
+// Reprice устанавливает ставку плавающего кредита по новой ключевой ставке и пересчитывает
+// платежи со следующего периода: платеж текущего периода и внесенные платежи не меняются,
+// срок сохраняется, меняется размер платежа. Возвращает первый номер пересчитанного платежа,
+// пересчитанные платежи и дату, с которой действует новая ставка.
+func (c *Credit) Reprice(schedule []PaymentSchedule, keyRate float64, now time.Time) (int, []PaymentSchedule, time.Time) {
+	c.KeyRate = keyRate
+	c.InterestRate = roundMoney(keyRate + c.RateMargin)
+
+	// Текущий период — первый неоплаченный платеж, срок которого не наступил
+	current := -1
+	for i := range schedule {
+		if !schedule[i].IsPaid() && !schedule[i].IsDue(now) {
+			current = i
+			break
+		}
+	}
+	if current < 0 {
+		return 0, nil, time.Time{}
+	}
+
+	// Платежи, по которым уже вносились деньги, не пересчитываются
+	start := current + 1
+	for i := start; i < len(schedule); i++ {
+		if schedule[i].IsPaid() || schedule[i].PaidAmount > 0 {
+			start = i + 1
+		}
+	}
+	if start >= len(schedule) {
+		return 0, nil, time.Time{}
+	}
+
+	future := schedule[start:]
+	principal := 0.0
+	for _, payment := range future {
+		principal += payment.Principal
+	}
+
+	effectiveFrom := schedule[start-1].DueDate
+	recalculated := c.RecalculateSchedule(future, roundMoney(principal), EarlyRepaymentReducePayment, effectiveFrom)
+	return future[0].PaymentNumber, recalculated, effectiveFrom
+}
@@ -0,0 +1,269 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidRestructuringType   = errors.New("invalid restructuring type")
+	ErrInvalidRestructuring       = errors.New("invalid restructuring parameters")
+	ErrInvalidHolidayInterestMode = errors.New("invalid holiday interest mode")
+)
+
+// MaxPaymentHolidayMonths предельная длительность кредитных каникул
+const MaxPaymentHolidayMonths = 6
+
+// RestructuringType вид реструктуризации кредита
+type RestructuringType string
+
+const (
+	// RestructuringPaymentHoliday кредитные каникулы: платежи не вносятся несколько месяцев,
+	// оставшиеся платежи переносятся на конец срока
+	RestructuringPaymentHoliday RestructuringType = "PAYMENT_HOLIDAY"
+	// RestructuringTermExtension увеличение срока со снижением платежа
+	RestructuringTermExtension RestructuringType = "TERM_EXTENSION"
+	// RestructuringPaymentDayChange перенос дня ежемесячного платежа
+	RestructuringPaymentDayChange RestructuringType = "PAYMENT_DAY_CHANGE"
+)
+
+// HolidayInterestMode способ учета процентов, начисленных за кредитные каникулы
+type HolidayInterestMode string
+
+const (
+	// HolidayInterestCapitalize проценты за каникулы добавляются к основному долгу
+	HolidayInterestCapitalize HolidayInterestMode = "CAPITALIZE"
+	// HolidayInterestDefer проценты за каникулы равными частями добавляются к платежам после каникул
+	// без начисления на них процентов
+	HolidayInterestDefer HolidayInterestMode = "DEFER"
+)
+
+// RestructuringRequest параметры реструктуризации
+type RestructuringRequest struct {
+	Type         RestructuringType
+	Months       int
+	InterestMode HolidayInterestMode
+	PaymentDay   int
+	Reason       string
+}
+
+// Validate проверяет параметры реструктуризации для кредита
+func (r *RestructuringRequest) Validate(credit *Credit) error {
+	switch r.Type {
+	case RestructuringPaymentHoliday:
+		if r.Months < 1 || r.Months > MaxPaymentHolidayMonths {
+			return fmt.Errorf("%w: holiday must last 1-%d months", ErrInvalidRestructuring, MaxPaymentHolidayMonths)
+		}
+		if r.InterestMode != HolidayInterestCapitalize && r.InterestMode != HolidayInterestDefer {
+			return ErrInvalidHolidayInterestMode
+		}
+	case RestructuringTermExtension:
+		if r.Months < 1 {
+			return fmt.Errorf("%w: term extension must be positive", ErrInvalidRestructuring)
+		}
+	case RestructuringPaymentDayChange:
+		if r.PaymentDay < 1 || r.PaymentDay > 31 {
+			return ErrInvalidPaymentDay
+		}
+		if r.PaymentDay == credit.PaymentDay {
+			return fmt.Errorf("%w: payment day is unchanged", ErrInvalidRestructuring)
+		}
+	default:
+		return ErrInvalidRestructuringType
+	}
+	if credit.Term+r.Months > 360 {
+		return ErrInvalidTerm
+	}
+	if r.Reason == "" {
+		return fmt.Errorf("%w: reason is required", ErrInvalidRestructuring)
+	}
+	return nil
+}
+
+// CreditRestructuring запись о реструктуризации кредита. Каждая реструктуризация создает
+// новую версию графика, предыдущая версия сохраняется в архиве графиков.
+type CreditRestructuring struct {
+	ID                 uint                `json:"id" gorm:"primaryKey"`
+	CreditID           uint                `json:"credit_id" gorm:"index;not null"`
+	Version            int                 `json:"version" gorm:"not null"` // версия графика, созданная реструктуризацией
+	Type               RestructuringType   `json:"type" gorm:"type:varchar(30);not null"`
+	Months             int                 `json:"months,omitempty"`
+	InterestMode       HolidayInterestMode `json:"interest_mode,omitempty" gorm:"type:varchar(20)"`
+	HolidayInterest    float64             `json:"holiday_interest" gorm:"type:decimal(20,2);default:0"`
+	FromPaymentNumber  int                 `json:"from_payment_number" gorm:"not null"`
+	PreviousTerm       int                 `json:"previous_term"`
+	NewTerm            int                 `json:"new_term"`
+	PreviousPaymentDay int                 `json:"previous_payment_day"`
+	NewPaymentDay      int                 `json:"new_payment_day"`
+	PreviousPayment    float64             `json:"previous_payment" gorm:"type:decimal(20,2)"`
+	NewPayment         float64             `json:"new_payment" gorm:"type:decimal(20,2)"`
+	PreviousEndDate    time.Time           `json:"previous_end_date"`
+	NewEndDate         time.Time           `json:"new_end_date"`
+	OperatorID         uint                `json:"operator_id"`
+	Reason             string              `json:"reason" gorm:"type:text"`
+	CreatedAt          time.Time           `json:"created_at"`
+}
+
+// ArchivedPayment платеж из предыдущей версии графика, сохраняется для аудита
+type ArchivedPayment struct {
+	ID               uint          `json:"id" gorm:"primaryKey"`
+	CreditID         uint          `json:"credit_id" gorm:"index:idx_archived_payment_version;not null"`
+	Version          int           `json:"version" gorm:"index:idx_archived_payment_version;not null"`
+	PaymentNumber    int           `json:"payment_number" gorm:"not null"`
+	DueDate          time.Time     `json:"due_date"`
+	Interest         float64       `json:"interest" gorm:"type:decimal(20,2)"`
+	DeferredInterest float64       `json:"deferred_interest" gorm:"type:decimal(20,2)"`
+	Principal        float64       `json:"principal" gorm:"type:decimal(20,2)"`
+	TotalAmount      float64       `json:"total_amount" gorm:"type:decimal(20,2)"`
+	PaidAmount       float64       `json:"paid_amount" gorm:"type:decimal(20,2)"`
+	PenaltyAccrued   float64       `json:"penalty_accrued" gorm:"type:decimal(20,2)"`
+	PenaltyPaid      float64       `json:"penalty_paid" gorm:"type:decimal(20,2)"`
+	Status           PaymentStatus `json:"status" gorm:"type:varchar(20)"`
+	PaidAt           *time.Time    `json:"paid_at,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+// ArchiveSchedule копирует график платежей как версию version
+func ArchiveSchedule(schedule []PaymentSchedule, version int) []ArchivedPayment {
+	archived := make([]ArchivedPayment, 0, len(schedule))
+	for _, payment := range schedule {
+		archived = append(archived, ArchivedPayment{
+			CreditID:         payment.CreditID,
+			Version:          version,
+			PaymentNumber:    payment.PaymentNumber,
+			DueDate:          payment.DueDate,
+			Interest:         payment.Interest,
+			DeferredInterest: payment.DeferredInterest,
+			Principal:        payment.Principal,
+			TotalAmount:      payment.TotalAmount,
+			PaidAmount:       payment.PaidAmount,
+			PenaltyAccrued:   payment.PenaltyAccrued,
+			PenaltyPaid:      payment.PenaltyPaid,
+			Status:           payment.Status,
+			PaidAt:           payment.PaidAt,
+		})
+	}
+	return archived
+}
+
+// Restructure строит неоплаченную часть графика по условиям реструктуризации и меняет
+// срок и день платежа кредита. Оплаченные платежи не меняются; кредит с просроченными
+// платежами не реструктурируется. Возвращает запись о реструктуризации без версии и
+// сотрудника и пересчитанные платежи, заменяющие платежи начиная с FromPaymentNumber.
+func (c *Credit) Restructure(schedule []PaymentSchedule, request RestructuringRequest, now time.Time) (*CreditRestructuring, []PaymentSchedule, error) {
+	if err := request.Validate(c); err != nil {
+		return nil, nil, err
+	}
+
+	// Остаток основного долга и дата, с которой начисляются проценты, как при досрочном погашении
+	quote, err := c.CalculatePayoff(schedule, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	var remaining []PaymentSchedule
+	for _, payment := range schedule {
+		if !payment.IsPaid() {
+			remaining = append(remaining, payment)
+		}
+	}
+
+	restructuring := &CreditRestructuring{
+		CreditID:           c.ID,
+		Type:               request.Type,
+		Months:             request.Months,
+		InterestMode:       request.InterestMode,
+		FromPaymentNumber:  quote.NextPaymentNumber,
+		PreviousTerm:       c.Term,
+		PreviousPaymentDay: c.PaymentDay,
+		PreviousPayment:    remaining[0].TotalAmount,
+		PreviousEndDate:    remaining[len(remaining)-1].DueDate,
+		Reason:             request.Reason,
+	}
+
+	var recalculated []PaymentSchedule
+	switch request.Type {
+	case RestructuringPaymentHoliday:
+		recalculated, restructuring.HolidayInterest = c.paymentHoliday(remaining, quote, request.Months, request.InterestMode)
+		c.Term += request.Months
+	case RestructuringTermExtension:
+		last := remaining[len(remaining)-1]
+		for i := 1; i <= request.Months; i++ {
+			remaining = append(remaining, PaymentSchedule{
+				CreditID:      c.ID,
+				PaymentNumber: last.PaymentNumber + i,
+				DueDate:       c.dueDateInMonth(last.DueDate, i),
+			})
+		}
+		recalculated = c.RecalculateSchedule(remaining, quote.OutstandingPrincipal, EarlyRepaymentReducePayment, quote.InterestFrom)
+		c.Term += request.Months
+	case RestructuringPaymentDayChange:
+		c.PaymentDay = request.PaymentDay
+		// Если новая дата ближайшего платежа уже прошла, платежи сдвигаются на месяц вперед
+		shift := 0
+		if !c.dueDateInMonth(remaining[0].DueDate, 0).After(now) {
+			shift = 1
+			c.Term++
+		}
+		for i := range remaining {
+			remaining[i].DueDate = c.dueDateInMonth(remaining[i].DueDate, shift)
+		}
+		recalculated = c.RecalculateSchedule(remaining, quote.OutstandingPrincipal, EarlyRepaymentReducePayment, quote.InterestFrom)
+	}
+
+	if len(recalculated) == 0 {
+		return nil, nil, fmt.Errorf("%w: no principal to reschedule", ErrInvalidRestructuring)
+	}
+
+	restructuring.NewTerm = c.Term
+	restructuring.NewPaymentDay = c.PaymentDay
+	restructuring.NewPayment = recalculated[0].TotalAmount
+	restructuring.NewEndDate = recalculated[len(recalculated)-1].DueDate
+	return restructuring, recalculated, nil
+}
+
+// paymentHoliday освобождает от платежей на months месяцев: неоплаченные платежи переносятся
+// на months месяцев позже и пересчитываются от даты окончания каникул. Возвращает пересчитанные
+// платежи и проценты, начисленные за каникулы.
+func (c *Credit) paymentHoliday(remaining []PaymentSchedule, quote *PayoffQuote, months int, mode HolidayInterestMode) ([]PaymentSchedule, float64) {
+	// Каникулы заканчиваются в дату последнего пропущенного платежа
+	holidayEnd := c.dueDateInMonth(remaining[0].DueDate, months-1)
+	c.HolidayUntil = holidayEnd
+	holidayInterest := c.accrueInterest(quote.OutstandingPrincipal, quote.InterestFrom, holidayEnd)
+
+	shifted := make([]PaymentSchedule, len(remaining))
+	for i, payment := range remaining {
+		shifted[i] = payment
+		shifted[i].DueDate = c.dueDateInMonth(payment.DueDate, months)
+	}
+
+	principal := quote.OutstandingPrincipal
+	if mode == HolidayInterestCapitalize {
+		principal = roundMoney(principal + holidayInterest)
+	}
+	recalculated := c.RecalculateSchedule(shifted, principal, EarlyRepaymentReducePayment, holidayEnd)
+
+	if mode == HolidayInterestDefer {
+		spreadDeferredInterest(recalculated, holidayInterest)
+	}
+	return recalculated, holidayInterest
+}
+
+// spreadDeferredInterest добавляет отсроченные проценты к платежам равными частями,
+// остаток округления учитывается в последнем платеже
+func spreadDeferredInterest(schedule []PaymentSchedule, amount float64) {
+	if len(schedule) == 0 || amount <= 0 {
+		return
+	}
+	share := roundMoney(amount / float64(len(schedule)))
+	for i := range schedule {
+		part := share
+		if i == len(schedule)-1 {
+			part = roundMoney(amount - share*float64(len(schedule)-1))
+		}
+		schedule[i].DeferredInterest = roundMoney(schedule[i].DeferredInterest + part)
+		schedule[i].Interest = roundMoney(schedule[i].Interest + part)
+		schedule[i].Amount = roundMoney(schedule[i].Amount + part)
+		schedule[i].TotalAmount = roundMoney(schedule[i].TotalAmount + part)
+	}
+}
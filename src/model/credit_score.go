@@ -0,0 +1,90 @@
+package model
+
+// ScoreDecision решение по заявке на основании скорингового балла
+type ScoreDecision string
+
+const (
+	ScoreDecisionApprove      ScoreDecision = "APPROVE"
+	ScoreDecisionManualReview ScoreDecision = "MANUAL_REVIEW"
+	ScoreDecisionDecline      ScoreDecision = "DECLINE"
+)
+
+// Факторы скоринга
+const (
+	ScoreFactorIncome            = "INCOME"
+	ScoreFactorDebtToIncome      = "DEBT_TO_INCOME"
+	ScoreFactorOverdueHistory    = "OVERDUE_HISTORY"
+	ScoreFactorAccountAge        = "ACCOUNT_AGE"
+	ScoreFactorBalanceVolatility = "BALANCE_VOLATILITY"
+)
+
+// MaxCreditScore максимальный скоринговый балл
+const MaxCreditScore = 1000
+
+// DefaultRateSpread надбавка к ключевой ставке без учета скоринга
+const DefaultRateSpread = 5.0
+
+// ScoreFactor вклад одного фактора в скоринговый балл
+type ScoreFactor struct {
+	Factor    string  `json:"factor"`
+	Value     float64 `json:"value"`
+	Points    int     `json:"points"`
+	MaxPoints int     `json:"max_points"`
+}
+
+// ScoreThresholds пороги скорингового балла для решения по заявке
+type ScoreThresholds struct {
+	Approve int
+	Decline int
+}
+
+// CreditScore результат скоринга заявки
+type CreditScore struct {
+	Score          int           `json:"score"`
+	Decision       ScoreDecision `json:"decision"`
+	RateSpread     float64       `json:"rate_spread"`
+	Factors        []ScoreFactor `json:"factors"`
+	BureauReportID *uint         `json:"bureau_report_id,omitempty"`
+}
+
+// NewCreditScore суммирует баллы факторов и определяет решение и надбавку к ставке
+func NewCreditScore(factors []ScoreFactor, thresholds ScoreThresholds) *CreditScore {
+	score := &CreditScore{Factors: factors}
+	for _, factor := range factors {
+		score.Score += factor.Points
+	}
+
+	switch {
+	case score.Score >= thresholds.Approve:
+		score.Decision = ScoreDecisionApprove
+	case score.Score < thresholds.Decline:
+		score.Decision = ScoreDecisionDecline
+	default:
+		score.Decision = ScoreDecisionManualReview
+	}
+	score.RateSpread = RateSpreadForScore(score.Score, thresholds)
+	return score
+}
+
+// ApplyBureauReport связывает результат с кредитным отчетом. Без отчета или при текущей
+// просрочке в других банках заявка не одобряется автоматически и направляется на рассмотрение.
+func (s *CreditScore) ApplyBureauReport(report *CreditBureauReport) {
+	if report != nil {
+		s.BureauReportID = &report.ID
+	}
+	if (report == nil || report.HasDelinquency()) && s.Decision == ScoreDecisionApprove {
+		s.Decision = ScoreDecisionManualReview
+	}
+}
+
+// RateSpreadForScore возвращает надбавку к ключевой ставке: чем выше балл, тем ниже ставка
+func RateSpreadForScore(score int, thresholds ScoreThresholds) float64 {
+	switch {
+	case score >= (thresholds.Approve+MaxCreditScore)/2:
+		return DefaultRateSpread - 2
+	case score >= thresholds.Approve:
+		return DefaultRateSpread - 1
+	default:
+		return DefaultRateSpread
+	}
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrChallengeNotPending = errors.New("payment challenge is already closed")
+	ErrInvalidOTP          = errors.New("invalid one-time password")
+)
+
+// MaxOTPAttempts количество попыток ввода одноразового кода
+const MaxOTPAttempts = 3
+
+// PaymentChallengeStatus статус подтверждения платежа
+type PaymentChallengeStatus string
+
+const (
+	PaymentChallengePending   PaymentChallengeStatus = "PENDING"
+	PaymentChallengeConfirmed PaymentChallengeStatus = "CONFIRMED"
+	PaymentChallengeExpired   PaymentChallengeStatus = "EXPIRED"
+	PaymentChallengeFailed    PaymentChallengeStatus = "FAILED"
+)
+
+// PaymentChallenge подтверждение онлайн-платежа одноразовым кодом (в стиле 3-D Secure).
+// Хранится только хеш кода.
+type PaymentChallenge struct {
+	gorm.Model
+	TransactionID uint                   `json:"transaction_id" gorm:"uniqueIndex;not null"`
+	CardID        uint                   `json:"card_id" gorm:"index;not null"`
+	UserID        uint                   `json:"user_id" gorm:"not null"`
+	OTPHash       string                 `json:"-" gorm:"not null"`
+	ExpiresAt     time.Time              `json:"expires_at" gorm:"index;not null"`
+	Attempts      int                    `json:"attempts" gorm:"not null;default:0"`
+	Status        PaymentChallengeStatus `json:"status" gorm:"type:varchar(20);not null"`
+}
+
+// Validate проверяет все поля подтверждения
+func (c *PaymentChallenge) Validate() error {
+	if c.TransactionID == 0 || c.CardID == 0 || c.UserID == 0 {
+		return errors.New("transaction, card and user are required")
+	}
+	if c.OTPHash == "" {
+		return errors.New("OTP hash is required")
+	}
+	switch c.Status {
+	case PaymentChallengePending, PaymentChallengeConfirmed, PaymentChallengeExpired, PaymentChallengeFailed:
+	default:
+		return errors.New("invalid payment challenge status")
+	}
+	return nil
+}
+
+// IsExpired проверяет, истек ли срок действия кода
+func (c *PaymentChallenge) IsExpired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+// AttemptsLeft возвращает число оставшихся попыток ввода кода
+func (c *PaymentChallenge) AttemptsLeft() int {
+	if c.Attempts >= MaxOTPAttempts {
+		return 0
+	}
+	return MaxOTPAttempts - c.Attempts
+}
+
+// BeforeCreate хук для валидации перед созданием
+func (c *PaymentChallenge) BeforeCreate(tx *gorm.DB) error {
+	return c.Validate()
+}
+
+// BeforeUpdate хук для валидации перед обновлением
+func (c *PaymentChallenge) BeforeUpdate(tx *gorm.DB) error {
+	return c.Validate()
+}
@@ -22,6 +22,12 @@ const (
 	TransactionTypeWithdrawal TransactionType = "WITHDRAWAL"
 	TransactionTypePayment    TransactionType = "PAYMENT"
 	TransactionTypeCredit     TransactionType = "CREDIT"
+	// TransactionTypePenalty начисление неустойки по кредиту, баланс счета не изменяется
+	TransactionTypePenalty TransactionType = "PENALTY"
+	// TransactionTypeFee комиссия банка, списывается со счета
+	TransactionTypeFee TransactionType = "FEE"
+	// TransactionTypeInterest проценты по кредитному лимиту, списываются с кредитного счета
+	TransactionTypeInterest TransactionType = "INTEREST"
 )
 
 type TransactionStatus string
@@ -40,6 +46,7 @@ type Transaction struct {
 	Amount        float64           `json:"amount" gorm:"type:decimal(20,2);not null"`
 	FromAccountID uint              `json:"from_account_id"`
 	ToAccountID   uint              `json:"to_account_id"`
+	CardID        uint              `json:"card_id" gorm:"index"`
 	Description   string            `json:"description" gorm:"type:text"`
 	Metadata      string            `json:"metadata" gorm:"type:jsonb"`
 	ExpiresAt     time.Time         `json:"expires_at"`
@@ -69,7 +76,8 @@ func (t *Transaction) Validate() error {
 func (t *Transaction) ValidateType() error {
 	switch t.Type {
 	case TransactionTypeTransfer, TransactionTypeDeposit, TransactionTypeWithdrawal,
-		TransactionTypePayment, TransactionTypeCredit:
+		TransactionTypePayment, TransactionTypeCredit, TransactionTypePenalty, TransactionTypeFee,
+		TransactionTypeInterest:
 		return nil
 	default:
 		return ErrInvalidType
@@ -113,6 +121,18 @@ func (t *Transaction) ValidateAccounts() error {
 		if t.FromAccountID == 0 {
 			return errors.New("source account is required for withdrawal")
 		}
+	case TransactionTypePenalty:
+		if t.FromAccountID == 0 {
+			return errors.New("credit account is required for penalty")
+		}
+	case TransactionTypeFee:
+		if t.FromAccountID == 0 {
+			return errors.New("source account is required for fee")
+		}
+	case TransactionTypeInterest:
+		if t.FromAccountID == 0 {
+			return errors.New("credit account is required for interest")
+		}
 	}
 	return nil
 }
@@ -156,9 +176,11 @@ func (t *Transaction) BeforeUpdate(tx *gorm.DB) error {
 func (t *Transaction) ToDTO() map[string]interface{} {
 	amount := t.Amount
 
-	// Для платежей по кредиту, снятий и переводов с этого счета сумма должна быть отрицательной
+	// Для платежей по кредиту, комиссий, процентов, снятий и переводов с этого счета сумма должна быть отрицательной
 	if t.Type == TransactionTypePayment ||
 		t.Type == TransactionTypeWithdrawal ||
+		t.Type == TransactionTypeFee ||
+		t.Type == TransactionTypeInterest ||
 		(t.Type == TransactionTypeTransfer && t.FromAccountID > 0) {
 		amount = -amount
 	}
@@ -169,6 +191,7 @@ func (t *Transaction) ToDTO() map[string]interface{} {
 		"amount":          amount,
 		"from_account_id": t.FromAccountID,
 		"to_account_id":   t.ToAccountID,
+		"card_id":         t.CardID,
 		"description":     t.Description,
 		"status":          t.Status,
 		"created_at":      t.CreatedAt.Format(time.RFC3339),
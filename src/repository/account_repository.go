@@ -16,6 +16,9 @@ type AccountRepository interface {
 	GetByUserID(ctx context.Context, userID uint) ([]model.Account, error)
 	GetWithTransactions(ctx context.Context, id uint) (*model.Account, error)
 	UpdateBalance(ctx context.Context, id uint, amount float64) error
+	Debit(ctx context.Context, id uint, amount float64) (bool, error)
+	DebitBalance(ctx context.Context, id uint, amount float64) (bool, error)
+	UpdateCreditLimit(ctx context.Context, id uint, creditLimit float64) error
 	GetByType(ctx context.Context, accountType model.AccountType) ([]model.Account, error)
 	GetOverdueCredits(ctx context.Context) ([]model.Account, error)
 	GetDailyTransactions(ctx context.Context, id uint, date time.Time) ([]model.Transaction, error)
@@ -51,7 +54,7 @@ func (r *accountRepository) Create(ctx context.Context, account *model.Account)
 // GetByID получает счет по ID
 func (r *accountRepository) GetByID(ctx context.Context, id uint) (*model.Account, error) {
 	var account model.Account
-	if err := r.db.First(&account, id).Error; err != nil {
+	if err := r.conn(ctx).First(&account, id).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return &account, nil
@@ -60,7 +63,7 @@ func (r *accountRepository) GetByID(ctx context.Context, id uint) (*model.Accoun
 // GetByNumber получает счет по номеру
 func (r *accountRepository) GetByNumber(ctx context.Context, number string) (*model.Account, error) {
 	var account model.Account
-	if err := r.db.Where("number = ?", number).First(&account).Error; err != nil {
+	if err := r.conn(ctx).Where("number = ?", number).First(&account).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return &account, nil
@@ -69,7 +72,7 @@ func (r *accountRepository) GetByNumber(ctx context.Context, number string) (*mo
 // GetByUserID получает счета пользователя
 func (r *accountRepository) GetByUserID(ctx context.Context, userID uint) ([]model.Account, error) {
 	var accounts []model.Account
-	if err := r.db.Where("user_id = ?", userID).Find(&accounts).Error; err != nil {
+	if err := r.conn(ctx).Where("user_id = ?", userID).Find(&accounts).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return accounts, nil
@@ -78,7 +81,7 @@ func (r *accountRepository) GetByUserID(ctx context.Context, userID uint) ([]mod
 // GetWithTransactions получает счет с транзакциями
 func (r *accountRepository) GetWithTransactions(ctx context.Context, id uint) (*model.Account, error) {
 	var account model.Account
-	if err := r.db.Preload("Transactions").First(&account, id).Error; err != nil {
+	if err := r.conn(ctx).Preload("Transactions").First(&account, id).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return &account, nil
@@ -109,6 +112,50 @@ func (r *accountRepository) UpdateBalance(ctx context.Context, id uint, amount f
 	})
 }
 
+// UpdateCreditLimit изменяет только кредитный лимит счета, не затрагивая баланс
+func (r *accountRepository) UpdateCreditLimit(ctx context.Context, id uint, creditLimit float64) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Account{}).Where("id = ?", id).
+			Update("credit_limit", creditLimit).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// Debit списывает amount со счета, если хватает средств с учетом кредитного лимита.
+// Проверка и списание выполняются одним UPDATE, поэтому параллельные списания не уводят
+// счет за лимит. Возвращает false, если средств недостаточно.
+func (r *accountRepository) Debit(ctx context.Context, id uint, amount float64) (bool, error) {
+	debited := false
+	err := r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		result := tx.Model(&model.Account{}).Where("id = ? AND balance + credit_limit >= ?", id, amount).
+			Update("balance", gorm.Expr("balance - ?", amount))
+		if result.Error != nil {
+			return r.HandleError(result.Error)
+		}
+		debited = result.RowsAffected == 1
+		return nil
+	})
+	return debited, err
+}
+
+// DebitBalance списывает amount только из положительного остатка счета, не используя кредитный
+// лимит, условным UPDATE ... WHERE balance >= amount. Возвращает false, если остатка недостаточно.
+func (r *accountRepository) DebitBalance(ctx context.Context, id uint, amount float64) (bool, error) {
+	debited := false
+	err := r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		result := tx.Model(&model.Account{}).Where("id = ? AND balance >= ?", id, amount).
+			Update("balance", gorm.Expr("balance - ?", amount))
+		if result.Error != nil {
+			return r.HandleError(result.Error)
+		}
+		debited = result.RowsAffected == 1
+		return nil
+	})
+	return debited, err
+}
+
 // Delete удаляет счет
 func (r *accountRepository) Delete(ctx context.Context, id uint) error {
 	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
@@ -122,7 +169,7 @@ func (r *accountRepository) Delete(ctx context.Context, id uint) error {
 // List получает список счетов
 func (r *accountRepository) List(ctx context.Context, offset, limit int) ([]model.Account, error) {
 	var accounts []model.Account
-	if err := r.db.Offset(offset).Limit(limit).Find(&accounts).Error; err != nil {
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&accounts).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return accounts, nil
@@ -131,7 +178,7 @@ func (r *accountRepository) List(ctx context.Context, offset, limit int) ([]mode
 // Count возвращает количество счетов
 func (r *accountRepository) Count(ctx context.Context) (int64, error) {
 	var count int64
-	if err := r.db.Model(&model.Account{}).Count(&count).Error; err != nil {
+	if err := r.conn(ctx).Model(&model.Account{}).Count(&count).Error; err != nil {
 		return 0, r.HandleError(err)
 	}
 	return count, nil
@@ -140,7 +187,7 @@ func (r *accountRepository) Count(ctx context.Context) (int64, error) {
 // GetByType получает счета по типу
 func (r *accountRepository) GetByType(ctx context.Context, accountType model.AccountType) ([]model.Account, error) {
 	var accounts []model.Account
-	if err := r.db.Where("type = ?", accountType).Find(&accounts).Error; err != nil {
+	if err := r.conn(ctx).Where("type = ?", accountType).Find(&accounts).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return accounts, nil
@@ -149,7 +196,7 @@ func (r *accountRepository) GetByType(ctx context.Context, accountType model.Acc
 // GetOverdueCredits получает просроченные кредитные счета
 func (r *accountRepository) GetOverdueCredits(ctx context.Context) ([]model.Account, error) {
 	var accounts []model.Account
-	if err := r.db.Where("type = ? AND balance < 0", model.AccountTypeCredit).Find(&accounts).Error; err != nil {
+	if err := r.conn(ctx).Where("type = ? AND balance < 0", model.AccountTypeCredit).Find(&accounts).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return accounts, nil
@@ -161,7 +208,7 @@ func (r *accountRepository) GetDailyTransactions(ctx context.Context, id uint, d
 	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
-	if err := r.db.Where("(from_account_id = ? OR to_account_id = ?) AND created_at BETWEEN ? AND ?",
+	if err := r.conn(ctx).Where("(from_account_id = ? OR to_account_id = ?) AND created_at BETWEEN ? AND ?",
 		id, id, startOfDay, endOfDay).Find(&transactions).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
@@ -174,7 +221,7 @@ func (r *accountRepository) GetMonthlyTransactions(ctx context.Context, id uint,
 	startOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
 	endOfMonth := startOfMonth.AddDate(0, 1, 0)
 
-	if err := r.db.Where("(from_account_id = ? OR to_account_id = ?) AND created_at BETWEEN ? AND ?",
+	if err := r.conn(ctx).Where("(from_account_id = ? OR to_account_id = ?) AND created_at BETWEEN ? AND ?",
 		id, id, startOfMonth, endOfMonth).Find(&transactions).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
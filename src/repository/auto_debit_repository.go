@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+
+	"FinanceGolang/src/model"
+
+	"gorm.io/gorm"
+)
+
+// AutoDebitRepository интерфейс репозитория резервных счетов для списания платежей по кредитам
+// и попыток списания
+type AutoDebitRepository interface {
+	Repository[model.AutoDebitAccount]
+	GetByCreditID(ctx context.Context, creditID uint) ([]model.AutoDebitAccount, error)
+	AddAttempt(ctx context.Context, attempt *model.AutoDebitAttempt) error
+	GetAttempts(ctx context.Context, creditID uint) ([]model.AutoDebitAttempt, error)
+}
+
+// autoDebitRepository реализация репозитория резервных счетов
+type autoDebitRepository struct {
+	BaseRepository[model.AutoDebitAccount]
+}
+
+// AutoDebitRepositoryInstance создает новый репозиторий резервных счетов
+func AutoDebitRepositoryInstance(db *gorm.DB) AutoDebitRepository {
+	return &autoDebitRepository{
+		BaseRepository: *NewBaseRepository[model.AutoDebitAccount](db),
+	}
+}
+
+// Create сохраняет резервный счет
+func (r *autoDebitRepository) Create(ctx context.Context, account *model.AutoDebitAccount) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := account.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Create(account).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetByID получает резервный счет по ID
+func (r *autoDebitRepository) GetByID(ctx context.Context, id uint) (*model.AutoDebitAccount, error) {
+	var account model.AutoDebitAccount
+	if err := r.conn(ctx).First(&account, id).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &account, nil
+}
+
+// GetByCreditID получает резервные счета кредита в порядке приоритета
+func (r *autoDebitRepository) GetByCreditID(ctx context.Context, creditID uint) ([]model.AutoDebitAccount, error) {
+	var accounts []model.AutoDebitAccount
+	if err := r.conn(ctx).Where("credit_id = ?", creditID).Order("priority, id").Find(&accounts).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return accounts, nil
+}
+
+// Update обновляет резервный счет
+func (r *autoDebitRepository) Update(ctx context.Context, account *model.AutoDebitAccount) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := account.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Save(account).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// Delete отзывает разрешение на списание с резервного счета
+func (r *autoDebitRepository) Delete(ctx context.Context, id uint) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.AutoDebitAccount{}, id).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// List получает список резервных счетов
+func (r *autoDebitRepository) List(ctx context.Context, offset, limit int) ([]model.AutoDebitAccount, error) {
+	var accounts []model.AutoDebitAccount
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&accounts).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return accounts, nil
+}
+
+// Count возвращает количество резервных счетов
+func (r *autoDebitRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.conn(ctx).Model(&model.AutoDebitAccount{}).Count(&count).Error; err != nil {
+		return 0, r.HandleError(err)
+	}
+	return count, nil
+}
+
+// AddAttempt сохраняет попытку списания
+func (r *autoDebitRepository) AddAttempt(ctx context.Context, attempt *model.AutoDebitAttempt) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetAttempts получает попытки списания по кредиту
+func (r *autoDebitRepository) GetAttempts(ctx context.Context, creditID uint) ([]model.AutoDebitAttempt, error) {
+	var attempts []model.AutoDebitAttempt
+	if err := r.conn(ctx).Where("credit_id = ?", creditID).Order("id").Find(&attempts).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return attempts, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+
+	"FinanceGolang/src/model"
+
+	"gorm.io/gorm"
+)
+
+// CardAuthorizationRepository интерфейс репозитория авторизаций по картам
+type CardAuthorizationRepository interface {
+	Repository[model.CardAuthorization]
+	GetByRRN(ctx context.Context, terminalID, rrn string) (*model.CardAuthorization, error)
+	GetByCardID(ctx context.Context, cardID uint) ([]model.CardAuthorization, error)
+	UpdateFromStatus(ctx context.Context, authorization *model.CardAuthorization, from ...model.CardAuthorizationStatus) (bool, error)
+}
+
+// cardAuthorizationRepository реализация репозитория авторизаций по картам
+type cardAuthorizationRepository struct {
+	BaseRepository[model.CardAuthorization]
+}
+
+// CardAuthorizationRepositoryInstance создает новый репозиторий авторизаций по картам
+func CardAuthorizationRepositoryInstance(db *gorm.DB) CardAuthorizationRepository {
+	return &cardAuthorizationRepository{
+		BaseRepository: *NewBaseRepository[model.CardAuthorization](db),
+	}
+}
+
+// Create создает новую авторизацию
+func (r *cardAuthorizationRepository) Create(ctx context.Context, authorization *model.CardAuthorization) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := authorization.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Create(authorization).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetByID получает авторизацию по ID
+func (r *cardAuthorizationRepository) GetByID(ctx context.Context, id uint) (*model.CardAuthorization, error) {
+	var authorization model.CardAuthorization
+	if err := r.conn(ctx).First(&authorization, id).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &authorization, nil
+}
+
+// GetByRRN получает авторизацию терминала по ссылочному номеру операции
+func (r *cardAuthorizationRepository) GetByRRN(ctx context.Context, terminalID, rrn string) (*model.CardAuthorization, error) {
+	var authorization model.CardAuthorization
+	if err := r.conn(ctx).Where("terminal_id = ? AND rrn = ?", terminalID, rrn).First(&authorization).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &authorization, nil
+}
+
+// GetByCardID получает авторизации по ID карты
+func (r *cardAuthorizationRepository) GetByCardID(ctx context.Context, cardID uint) ([]model.CardAuthorization, error) {
+	var authorizations []model.CardAuthorization
+	if err := r.conn(ctx).Where("card_id = ?", cardID).Order("id").Find(&authorizations).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return authorizations, nil
+}
+
+// Update обновляет авторизацию
+func (r *cardAuthorizationRepository) Update(ctx context.Context, authorization *model.CardAuthorization) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := authorization.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Save(authorization).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// UpdateFromStatus сохраняет авторизацию, только если в базе она еще в одном из статусов from.
+// Возвращает false, если авторизацию уже перевел в другой статус параллельный запрос.
+func (r *cardAuthorizationRepository) UpdateFromStatus(ctx context.Context, authorization *model.CardAuthorization, from ...model.CardAuthorizationStatus) (bool, error) {
+	updated := false
+	err := r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := authorization.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		result := tx.Model(authorization).Where("status IN ?", from).Updates(map[string]interface{}{
+			"amount":      authorization.Amount,
+			"status":      authorization.Status,
+			"captured_at": authorization.CapturedAt,
+			"reversed_at": authorization.ReversedAt,
+		})
+		if result.Error != nil {
+			return r.HandleError(result.Error)
+		}
+		updated = result.RowsAffected == 1
+		return nil
+	})
+	return updated, err
+}
+
+// Delete удаляет авторизацию
+func (r *cardAuthorizationRepository) Delete(ctx context.Context, id uint) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.CardAuthorization{}, id).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// List получает список авторизаций
+func (r *cardAuthorizationRepository) List(ctx context.Context, offset, limit int) ([]model.CardAuthorization, error) {
+	var authorizations []model.CardAuthorization
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&authorizations).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return authorizations, nil
+}
+
+// Count возвращает количество авторизаций
+func (r *cardAuthorizationRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.conn(ctx).Model(&model.CardAuthorization{}).Count(&count).Error; err != nil {
+		return 0, r.HandleError(err)
+	}
+	return count, nil
+}
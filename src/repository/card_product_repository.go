@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+
+	"FinanceGolang/src/model"
+
+	"gorm.io/gorm"
+)
+
+// CardProductRepository интерфейс репозитория карточных продуктов
+type CardProductRepository interface {
+	Repository[model.CardProduct]
+	GetByCode(ctx context.Context, code string) (*model.CardProduct, error)
+	GetActive(ctx context.Context) ([]model.CardProduct, error)
+}
+
+// cardProductRepository реализация репозитория карточных продуктов
+type cardProductRepository struct {
+	BaseRepository[model.CardProduct]
+}
+
+// CardProductRepositoryInstance создает новый репозиторий карточных продуктов
+func CardProductRepositoryInstance(db *gorm.DB) CardProductRepository {
+	return &cardProductRepository{
+		BaseRepository: *NewBaseRepository[model.CardProduct](db),
+	}
+}
+
+// Create создает новый продукт
+func (r *cardProductRepository) Create(ctx context.Context, product *model.CardProduct) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := product.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Create(product).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetByID получает продукт по ID
+func (r *cardProductRepository) GetByID(ctx context.Context, id uint) (*model.CardProduct, error) {
+	var product model.CardProduct
+	if err := r.conn(ctx).First(&product, id).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &product, nil
+}
+
+// GetByCode получает продукт по коду
+func (r *cardProductRepository) GetByCode(ctx context.Context, code string) (*model.CardProduct, error) {
+	var product model.CardProduct
+	if err := r.conn(ctx).Where("code = ?", code).First(&product).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &product, nil
+}
+
+// GetActive получает активные продукты
+func (r *cardProductRepository) GetActive(ctx context.Context) ([]model.CardProduct, error) {
+	var products []model.CardProduct
+	if err := r.conn(ctx).Where("is_active = ?", true).Order("id").Find(&products).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return products, nil
+}
+
+// Update обновляет продукт
+func (r *cardProductRepository) Update(ctx context.Context, product *model.CardProduct) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := product.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Save(product).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// Delete удаляет продукт
+func (r *cardProductRepository) Delete(ctx context.Context, id uint) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.CardProduct{}, id).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// List получает список продуктов
+func (r *cardProductRepository) List(ctx context.Context, offset, limit int) ([]model.CardProduct, error) {
+	var products []model.CardProduct
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&products).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return products, nil
+}
+
+// Count возвращает количество продуктов
+func (r *cardProductRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.conn(ctx).Model(&model.CardProduct{}).Count(&count).Error; err != nil {
+		return 0, r.HandleError(err)
+	}
+	return count, nil
+}
@@ -13,16 +13,29 @@ import (
 // CardRepository интерфейс репозитория карт
 type CardRepository interface {
 	Repository[model.Card]
-	GetByNumber(ctx context.Context, number string) (*model.Card, error)
+	GetByNumber(ctx context.Context, numberHash string) (*model.Card, error)
+	GetWithoutNumberHash(ctx context.Context) ([]model.Card, error)
+	UpdateNumberHash(ctx context.Context, id uint, numberHash string) error
+	GetForReencryption(ctx context.Context, keyID string, afterID uint, limit int) ([]model.Card, error)
+	UpdateEncryptedFields(ctx context.Context, cards []model.Card) error
 	GetByUserID(ctx context.Context, userID uint) ([]model.Card, error)
 	GetByAccountID(ctx context.Context, accountID uint) ([]model.Card, error)
 	GetExpiredCards(ctx context.Context) ([]model.Card, error)
 	GetActiveCards(ctx context.Context) ([]model.Card, error)
 	UpdateStatus(ctx context.Context, id uint, isActive bool) error
+	UpdateUsage(ctx context.Context, card *model.Card) error
+	UpdateControls(ctx context.Context, card *model.Card) error
 	GetDailyUsage(ctx context.Context, id uint, date time.Time) (float64, error)
 	GetMonthlyUsage(ctx context.Context, id uint, year int, month time.Month) (float64, error)
 }
 
+// usageStatuses статусы транзакций, которые расходуют лимиты карты:
+// проведенные операции и заблокированные по авторизации средства
+var usageStatuses = []model.TransactionStatus{
+	model.TransactionStatusPending,
+	model.TransactionStatusCompleted,
+}
+
 // cardRepository реализация репозитория карт
 type cardRepository struct {
 	BaseRepository[model.Card]
@@ -62,25 +75,50 @@ func (r *cardRepository) Create(ctx context.Context, card *model.Card) error {
 // GetByID получает карту по ID
 func (r *cardRepository) GetByID(ctx context.Context, id uint) (*model.Card, error) {
 	var card model.Card
-	if err := r.db.First(&card, id).Error; err != nil {
+	if err := r.conn(ctx).First(&card, id).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return &card, nil
 }
 
-// GetByNumber получает карту по номеру
-func (r *cardRepository) GetByNumber(ctx context.Context, number string) (*model.Card, error) {
+// GetByNumber получает карту по слепому индексу номера.
+// Номер хранится в зашифрованном виде, поэтому поиск идет по HMAC номера,
+// который вычисляется в сервисном слое.
+func (r *cardRepository) GetByNumber(ctx context.Context, numberHash string) (*model.Card, error) {
 	var card model.Card
-	if err := r.db.Where("number = ?", number).First(&card).Error; err != nil {
+	if numberHash == "" {
+		return nil, ErrNotFound
+	}
+	if err := r.conn(ctx).Where("number_hash = ?", numberHash).First(&card).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return &card, nil
 }
 
+// GetWithoutNumberHash получает карты без слепого индекса номера
+func (r *cardRepository) GetWithoutNumberHash(ctx context.Context) ([]model.Card, error) {
+	var cards []model.Card
+	if err := r.conn(ctx).Where("number_hash IS NULL OR number_hash = ''").Find(&cards).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return cards, nil
+}
+
+// UpdateNumberHash обновляет слепой индекс номера карты
+func (r *cardRepository) UpdateNumberHash(ctx context.Context, id uint, numberHash string) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		// UpdateColumn не вызывает хуки: зашифрованный номер не проходит валидацию
+		if err := tx.Model(&model.Card{}).Where("id = ?", id).UpdateColumn("number_hash", numberHash).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
 // GetByUserID получает карты пользователя
 func (r *cardRepository) GetByUserID(ctx context.Context, userID uint) ([]model.Card, error) {
 	var cards []model.Card
-	if err := r.db.Where("user_id = ?", userID).Find(&cards).Error; err != nil {
+	if err := r.conn(ctx).Where("user_id = ?", userID).Find(&cards).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return cards, nil
@@ -89,7 +127,7 @@ func (r *cardRepository) GetByUserID(ctx context.Context, userID uint) ([]model.
 // GetByAccountID получает карты по ID счета
 func (r *cardRepository) GetByAccountID(ctx context.Context, accountID uint) ([]model.Card, error) {
 	var cards []model.Card
-	if err := r.db.Where("account_id = ?", accountID).Find(&cards).Error; err != nil {
+	if err := r.conn(ctx).Where("account_id = ?", accountID).Find(&cards).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return cards, nil
@@ -99,7 +137,7 @@ func (r *cardRepository) GetByAccountID(ctx context.Context, accountID uint) ([]
 func (r *cardRepository) GetExpiredCards(ctx context.Context) ([]model.Card, error) {
 	var cards []model.Card
 	now := time.Now().Format("01/06")
-	if err := r.db.Where("expiry_date < ?", now).Find(&cards).Error; err != nil {
+	if err := r.conn(ctx).Where("expiry_date < ?", now).Find(&cards).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return cards, nil
@@ -108,12 +146,44 @@ func (r *cardRepository) GetExpiredCards(ctx context.Context) ([]model.Card, err
 // GetActiveCards получает активные карты
 func (r *cardRepository) GetActiveCards(ctx context.Context) ([]model.Card, error) {
 	var cards []model.Card
-	if err := r.db.Where("is_active = ?", true).Find(&cards).Error; err != nil {
+	if err := r.conn(ctx).Where("is_active = ?", true).Find(&cards).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return cards, nil
+}
+
+// GetForReencryption получает пачку карт, зашифрованных не ключом keyID.
+// Карты отдаются по возрастанию ID начиная после afterID.
+func (r *cardRepository) GetForReencryption(ctx context.Context, keyID string, afterID uint, limit int) ([]model.Card, error) {
+	var cards []model.Card
+	if err := r.conn(ctx).Where("id > ?", afterID).
+		Where("number_key_id IS NULL OR number_key_id <> ? OR expiry_key_id IS NULL OR expiry_key_id <> ?", keyID, keyID).
+		Order("id").
+		Limit(limit).
+		Find(&cards).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return cards, nil
 }
 
+// UpdateEncryptedFields сохраняет перешифрованные поля пачки карт в одной транзакции
+func (r *cardRepository) UpdateEncryptedFields(ctx context.Context, cards []model.Card) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		for _, card := range cards {
+			if err := tx.Model(&model.Card{}).Where("id = ?", card.ID).UpdateColumns(map[string]interface{}{
+				"number":        card.Number,
+				"number_hash":   card.NumberHash,
+				"number_key_id": card.NumberKeyID,
+				"expiry_date":   card.ExpiryDate,
+				"expiry_key_id": card.ExpiryKeyID,
+			}).Error; err != nil {
+				return r.HandleError(err)
+			}
+		}
+		return nil
+	})
+}
+
 // Update обновляет карту
 func (r *cardRepository) Update(ctx context.Context, card *model.Card) error {
 	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
@@ -131,7 +201,37 @@ func (r *cardRepository) Update(ctx context.Context, card *model.Card) error {
 // UpdateStatus обновляет статус карты
 func (r *cardRepository) UpdateStatus(ctx context.Context, id uint, isActive bool) error {
 	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
-		if err := tx.Model(&model.Card{}).Where("id = ?", id).Update("is_active", isActive).Error; err != nil {
+		// UpdateColumn не вызывает хуки: зашифрованные поля не проходят валидацию
+		if err := tx.Model(&model.Card{}).Where("id = ?", id).UpdateColumn("is_active", isActive).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// UpdateUsage сохраняет данные об использовании карты после оплаты
+func (r *cardRepository) UpdateUsage(ctx context.Context, card *model.Card) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Card{}).Where("id = ?", card.ID).UpdateColumns(map[string]interface{}{
+			"spent_amount": card.SpentAmount,
+			"last_used":    card.LastUsed,
+			"is_active":    card.IsActive,
+			"closed_at":    card.ClosedAt,
+		}).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// UpdateControls сохраняет ограничения операций по карте
+func (r *cardRepository) UpdateControls(ctx context.Context, card *model.Card) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		// Select нужен, чтобы сохранить выключенные (false) ограничения,
+		// структура передается целиком для сериализации списка MCC
+		if err := tx.Model(card).
+			Select("allow_online", "allow_contactless", "allow_cash", "allow_foreign", "blocked_mccs").
+			UpdateColumns(card).Error; err != nil {
 			return r.HandleError(err)
 		}
 		return nil
@@ -151,7 +251,7 @@ func (r *cardRepository) Delete(ctx context.Context, id uint) error {
 // List получает список карт
 func (r *cardRepository) List(ctx context.Context, offset, limit int) ([]model.Card, error) {
 	var cards []model.Card
-	if err := r.db.Offset(offset).Limit(limit).Find(&cards).Error; err != nil {
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&cards).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return cards, nil
@@ -160,7 +260,7 @@ func (r *cardRepository) List(ctx context.Context, offset, limit int) ([]model.C
 // Count возвращает количество карт
 func (r *cardRepository) Count(ctx context.Context) (int64, error) {
 	var count int64
-	if err := r.db.Model(&model.Card{}).Count(&count).Error; err != nil {
+	if err := r.conn(ctx).Model(&model.Card{}).Count(&count).Error; err != nil {
 		return 0, r.HandleError(err)
 	}
 	return count, nil
@@ -172,8 +272,8 @@ func (r *cardRepository) GetDailyUsage(ctx context.Context, id uint, date time.T
 	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
-	if err := r.db.Model(&model.Transaction{}).
-		Where("card_id = ? AND created_at BETWEEN ? AND ?", id, startOfDay, endOfDay).
+	if err := r.conn(ctx).Model(&model.Transaction{}).
+		Where("card_id = ? AND status IN ? AND created_at BETWEEN ? AND ?", id, usageStatuses, startOfDay, endOfDay).
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&total).Error; err != nil {
 		return 0, r.HandleError(err)
@@ -187,8 +287,8 @@ func (r *cardRepository) GetMonthlyUsage(ctx context.Context, id uint, year int,
 	startOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
 	endOfMonth := startOfMonth.AddDate(0, 1, 0)
 
-	if err := r.db.Model(&model.Transaction{}).
-		Where("card_id = ? AND created_at BETWEEN ? AND ?", id, startOfMonth, endOfMonth).
+	if err := r.conn(ctx).Model(&model.Transaction{}).
+		Where("card_id = ? AND status IN ? AND created_at BETWEEN ? AND ?", id, usageStatuses, startOfMonth, endOfMonth).
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&total).Error; err != nil {
 		return 0, r.HandleError(err)
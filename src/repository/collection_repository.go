@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+
+	"FinanceGolang/src/model"
+
+	"gorm.io/gorm"
+)
+
+// CollectionRepository интерфейс репозитория дел о взыскании и их истории
+type CollectionRepository interface {
+	Repository[model.CollectionCase]
+	GetOpenByCreditID(ctx context.Context, creditID uint) (*model.CollectionCase, error)
+	GetOpenCases(ctx context.Context) ([]model.CollectionCase, error)
+	AddActivity(ctx context.Context, activity *model.CollectionActivity) error
+	UpdateActivity(ctx context.Context, activity *model.CollectionActivity) error
+	GetActivities(ctx context.Context, caseID uint) ([]model.CollectionActivity, error)
+	GetPendingPromises(ctx context.Context) ([]model.CollectionActivity, error)
+}
+
+// collectionRepository реализация репозитория дел о взыскании
+type collectionRepository struct {
+	BaseRepository[model.CollectionCase]
+}
+
+// CollectionRepositoryInstance создает новый репозиторий дел о взыскании
+func CollectionRepositoryInstance(db *gorm.DB) CollectionRepository {
+	return &collectionRepository{
+		BaseRepository: *NewBaseRepository[model.CollectionCase](db),
+	}
+}
+
+// Create открывает дело о взыскании
+func (r *collectionRepository) Create(ctx context.Context, collectionCase *model.CollectionCase) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(collectionCase).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetByID получает дело о взыскании по ID
+func (r *collectionRepository) GetByID(ctx context.Context, id uint) (*model.CollectionCase, error) {
+	var collectionCase model.CollectionCase
+	if err := r.conn(ctx).First(&collectionCase, id).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &collectionCase, nil
+}
+
+// GetOpenByCreditID получает открытое дело о взыскании по кредиту
+func (r *collectionRepository) GetOpenByCreditID(ctx context.Context, creditID uint) (*model.CollectionCase, error) {
+	var collectionCase model.CollectionCase
+	if err := r.conn(ctx).Where("credit_id = ? AND status = ?", creditID, model.CollectionCaseOpen).
+		First(&collectionCase).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &collectionCase, nil
+}
+
+// GetOpenCases получает открытые дела о взыскании
+func (r *collectionRepository) GetOpenCases(ctx context.Context) ([]model.CollectionCase, error) {
+	var cases []model.CollectionCase
+	if err := r.conn(ctx).Where("status = ?", model.CollectionCaseOpen).Order("id").Find(&cases).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return cases, nil
+}
+
+// Update обновляет дело о взыскании
+func (r *collectionRepository) Update(ctx context.Context, collectionCase *model.CollectionCase) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Save(collectionCase).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// Delete удаляет дело о взыскании
+func (r *collectionRepository) Delete(ctx context.Context, id uint) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.CollectionCase{}, id).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// List получает список дел о взыскании
+func (r *collectionRepository) List(ctx context.Context, offset, limit int) ([]model.CollectionCase, error) {
+	var cases []model.CollectionCase
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&cases).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return cases, nil
+}
+
+// Count возвращает количество дел о взыскании
+func (r *collectionRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.conn(ctx).Model(&model.CollectionCase{}).Count(&count).Error; err != nil {
+		return 0, r.HandleError(err)
+	}
+	return count, nil
+}
+
+// AddActivity сохраняет запись в истории взыскания
+func (r *collectionRepository) AddActivity(ctx context.Context, activity *model.CollectionActivity) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(activity).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// UpdateActivity обновляет запись в истории взыскания
+func (r *collectionRepository) UpdateActivity(ctx context.Context, activity *model.CollectionActivity) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Save(activity).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetActivities получает историю взыскания по делу
+func (r *collectionRepository) GetActivities(ctx context.Context, caseID uint) ([]model.CollectionActivity, error) {
+	var activities []model.CollectionActivity
+	if err := r.conn(ctx).Where("case_id = ?", caseID).Order("id").Find(&activities).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return activities, nil
+}
+
+// GetPendingPromises получает обещания оплаты, по которым еще не подведен итог
+func (r *collectionRepository) GetPendingPromises(ctx context.Context) ([]model.CollectionActivity, error) {
+	var activities []model.CollectionActivity
+	if err := r.conn(ctx).Where("type = ? AND promise_status = ?", model.CollectionActivityPromise, model.PromiseStatusPending).
+		Order("id").Find(&activities).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return activities, nil
+}
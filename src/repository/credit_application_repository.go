@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+
+	"FinanceGolang/src/model"
+
+	"gorm.io/gorm"
+)
+
+// CreditApplicationRepository интерфейс репозитория заявок на кредит
+type CreditApplicationRepository interface {
+	Repository[model.CreditApplication]
+	GetByUserID(ctx context.Context, userID uint) ([]model.CreditApplication, error)
+	GetByStatus(ctx context.Context, status model.CreditApplicationStatus) ([]model.CreditApplication, error)
+	UpdateFromStatus(ctx context.Context, application *model.CreditApplication, from model.CreditApplicationStatus) (bool, error)
+	AddEvent(ctx context.Context, event *model.CreditApplicationEvent) error
+	GetEvents(ctx context.Context, applicationID uint) ([]model.CreditApplicationEvent, error)
+}
+
+// creditApplicationRepository реализация репозитория заявок на кредит
+type creditApplicationRepository struct {
+	BaseRepository[model.CreditApplication]
+}
+
+// CreditApplicationRepositoryInstance создает новый репозиторий заявок на кредит
+func CreditApplicationRepositoryInstance(db *gorm.DB) CreditApplicationRepository {
+	return &creditApplicationRepository{
+		BaseRepository: *NewBaseRepository[model.CreditApplication](db),
+	}
+}
+
+// Create создает новую заявку
+func (r *creditApplicationRepository) Create(ctx context.Context, application *model.CreditApplication) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := application.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Create(application).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetByID получает заявку по ID
+func (r *creditApplicationRepository) GetByID(ctx context.Context, id uint) (*model.CreditApplication, error) {
+	var application model.CreditApplication
+	if err := r.conn(ctx).First(&application, id).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &application, nil
+}
+
+// GetByUserID получает заявки пользователя
+func (r *creditApplicationRepository) GetByUserID(ctx context.Context, userID uint) ([]model.CreditApplication, error) {
+	var applications []model.CreditApplication
+	if err := r.conn(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&applications).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return applications, nil
+}
+
+// GetByStatus получает заявки в указанном статусе
+func (r *creditApplicationRepository) GetByStatus(ctx context.Context, status model.CreditApplicationStatus) ([]model.CreditApplication, error) {
+	var applications []model.CreditApplication
+	if err := r.conn(ctx).Where("status = ?", status).Order("id").Find(&applications).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return applications, nil
+}
+
+// Update обновляет заявку
+func (r *creditApplicationRepository) Update(ctx context.Context, application *model.CreditApplication) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := application.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Save(application).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// UpdateFromStatus сохраняет заявку, только если в базе она еще в статусе from.
+// Возвращает false, если статус заявки уже изменил другой запрос.
+func (r *creditApplicationRepository) UpdateFromStatus(ctx context.Context, application *model.CreditApplication, from model.CreditApplicationStatus) (bool, error) {
+	updated := false
+	err := r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := application.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		result := tx.Model(application).Where("status = ?", from).
+			Select("*").Omit("created_at").Updates(application)
+		if result.Error != nil {
+			return r.HandleError(result.Error)
+		}
+		updated = result.RowsAffected == 1
+		return nil
+	})
+	return updated, err
+}
+
+// Delete удаляет заявку
+func (r *creditApplicationRepository) Delete(ctx context.Context, id uint) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.CreditApplication{}, id).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// List получает список заявок
+func (r *creditApplicationRepository) List(ctx context.Context, offset, limit int) ([]model.CreditApplication, error) {
+	var applications []model.CreditApplication
+	if err := r.conn(ctx).Order("id DESC").Offset(offset).Limit(limit).Find(&applications).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return applications, nil
+}
+
+// Count возвращает количество заявок
+func (r *creditApplicationRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.conn(ctx).Model(&model.CreditApplication{}).Count(&count).Error; err != nil {
+		return 0, r.HandleError(err)
+	}
+	return count, nil
+}
+
+// AddEvent записывает изменение статуса заявки
+func (r *creditApplicationRepository) AddEvent(ctx context.Context, event *model.CreditApplicationEvent) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetEvents получает историю статусов заявки
+func (r *creditApplicationRepository) GetEvents(ctx context.Context, applicationID uint) ([]model.CreditApplicationEvent, error) {
+	var events []model.CreditApplicationEvent
+	if err := r.conn(ctx).Where("application_id = ?", applicationID).Order("id").Find(&events).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return events, nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"FinanceGolang/src/model"
+
+	"gorm.io/gorm"
+)
+
+// CreditBureauRepository интерфейс репозитория выгрузок кредитной истории,
+// событий кредитов за период выгрузки и полученных из бюро кредитных отчетов
+type CreditBureauRepository interface {
+	Repository[model.CreditBureauExport]
+	GetLast(ctx context.Context) (*model.CreditBureauExport, error)
+	AddReport(ctx context.Context, report *model.CreditBureauReport) error
+	GetLastReport(ctx context.Context, userID uint, source string) (*model.CreditBureauReport, error)
+	GetOpenedCredits(ctx context.Context, from, to time.Time) ([]model.Credit, error)
+	GetPaidPayments(ctx context.Context, from, to time.Time) ([]model.PaymentSchedule, error)
+	GetDelinquentCredits(ctx context.Context) ([]model.Credit, error)
+	GetClosedCredits(ctx context.Context, from, to time.Time) ([]model.Credit, error)
+}
+
+// creditBureauRepository реализация репозитория выгрузок кредитной истории
+type creditBureauRepository struct {
+	BaseRepository[model.CreditBureauExport]
+}
+
+// CreditBureauRepositoryInstance создает новый репозиторий выгрузок кредитной истории
+func CreditBureauRepositoryInstance(db *gorm.DB) CreditBureauRepository {
+	return &creditBureauRepository{
+		BaseRepository: *NewBaseRepository[model.CreditBureauExport](db),
+	}
+}
+
+// Create сохраняет выгрузку
+func (r *creditBureauRepository) Create(ctx context.Context, export *model.CreditBureauExport) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(export).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetByID получает выгрузку вместе с файлом и подписью
+func (r *creditBureauRepository) GetByID(ctx context.Context, id uint) (*model.CreditBureauExport, error) {
+	var export model.CreditBureauExport
+	if err := r.conn(ctx).First(&export, id).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &export, nil
+}
+
+// GetLast получает последнюю выгрузку без файла
+func (r *creditBureauRepository) GetLast(ctx context.Context) (*model.CreditBureauExport, error) {
+	var export model.CreditBureauExport
+	if err := r.conn(ctx).Omit("content", "signature").Order("period_to DESC, id DESC").
+		First(&export).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &export, nil
+}
+
+// Update обновляет выгрузку
+func (r *creditBureauRepository) Update(ctx context.Context, export *model.CreditBureauExport) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Save(export).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// Delete удаляет выгрузку
+func (r *creditBureauRepository) Delete(ctx context.Context, id uint) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.CreditBureauExport{}, id).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// List получает список выгрузок без файлов, начиная с последней
+func (r *creditBureauRepository) List(ctx context.Context, offset, limit int) ([]model.CreditBureauExport, error) {
+	var exports []model.CreditBureauExport
+	if err := r.conn(ctx).Omit("content", "signature").Order("period_to DESC, id DESC").
+		Offset(offset).Limit(limit).Find(&exports).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return exports, nil
+}
+
+// Count возвращает количество выгрузок
+func (r *creditBureauRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.conn(ctx).Model(&model.CreditBureauExport{}).Count(&count).Error; err != nil {
+		return 0, r.HandleError(err)
+	}
+	return count, nil
+}
+
+// GetOpenedCredits получает кредиты, выданные в периоде (from, to]
+func (r *creditBureauRepository) GetOpenedCredits(ctx context.Context, from, to time.Time) ([]model.Credit, error) {
+	var credits []model.Credit
+	if err := r.conn(ctx).Where("start_date > ? AND start_date <= ? AND status NOT IN ?", from, to,
+		[]model.CreditStatus{model.CreditStatusPending, model.CreditStatusCancelled}).
+		Order("start_date, id").Find(&credits).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return credits, nil
+}
+
+// GetPaidPayments получает платежи по графику, оплата по которым поступала в периоде (from, to]
+func (r *creditBureauRepository) GetPaidPayments(ctx context.Context, from, to time.Time) ([]model.PaymentSchedule, error) {
+	var payments []model.PaymentSchedule
+	if err := r.conn(ctx).Where("paid_at > ? AND paid_at <= ? AND paid_amount > 0", from, to).
+		Order("paid_at, credit_id, payment_number").Find(&payments).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return payments, nil
+}
+
+// GetDelinquentCredits получает кредиты с просроченной задолженностью
+func (r *creditBureauRepository) GetDelinquentCredits(ctx context.Context) ([]model.Credit, error) {
+	var credits []model.Credit
+	if err := r.conn(ctx).Where("status = ?", model.CreditStatusOverdue).
+		Order("id").Find(&credits).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return credits, nil
+}
+
+// GetClosedCredits получает кредиты, погашенные в периоде (from, to]
+func (r *creditBureauRepository) GetClosedCredits(ctx context.Context, from, to time.Time) ([]model.Credit, error) {
+	var credits []model.Credit
+	if err := r.conn(ctx).Where("status = ? AND last_payment > ? AND last_payment <= ?", model.CreditStatusPaid, from, to).
+		Order("last_payment, id").Find(&credits).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return credits, nil
+}
+
+// AddReport сохраняет кредитный отчет
+func (r *creditBureauRepository) AddReport(ctx context.Context, report *model.CreditBureauReport) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(report).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetLastReport получает последний кредитный отчет пользователя из источника source
+func (r *creditBureauRepository) GetLastReport(ctx context.Context, userID uint, source string) (*model.CreditBureauReport, error) {
+	var report model.CreditBureauReport
+	if err := r.conn(ctx).Where("user_id = ? AND source = ?", userID, source).Order("received_at DESC, id DESC").
+		First(&report).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &report, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+
+	"FinanceGolang/src/model"
+
+	"gorm.io/gorm"
+)
+
+// CreditDocumentRepository интерфейс репозитория документов по кредитам
+type CreditDocumentRepository interface {
+	Repository[model.CreditDocument]
+	CreateDocuments(ctx context.Context, documents []model.CreditDocument) error
+	GetByCreditID(ctx context.Context, creditID uint, scheduleVersion int) ([]model.CreditDocument, error)
+	GetCreditsWithoutDocuments(ctx context.Context) ([]model.Credit, error)
+}
+
+// creditDocumentRepository реализация репозитория документов по кредитам
+type creditDocumentRepository struct {
+	BaseRepository[model.CreditDocument]
+}
+
+// CreditDocumentRepositoryInstance создает новый репозиторий документов по кредитам
+func CreditDocumentRepositoryInstance(db *gorm.DB) CreditDocumentRepository {
+	return &creditDocumentRepository{
+		BaseRepository: *NewBaseRepository[model.CreditDocument](db),
+	}
+}
+
+// Create сохраняет документ
+func (r *creditDocumentRepository) Create(ctx context.Context, document *model.CreditDocument) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(document).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// CreateDocuments сохраняет комплект документов одной версии графика
+func (r *creditDocumentRepository) CreateDocuments(ctx context.Context, documents []model.CreditDocument) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(&documents).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetByID получает документ вместе с файлом и подписью
+func (r *creditDocumentRepository) GetByID(ctx context.Context, id uint) (*model.CreditDocument, error) {
+	var document model.CreditDocument
+	if err := r.conn(ctx).First(&document, id).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &document, nil
+}
+
+// GetByCreditID получает документы кредита для версии графика без файлов
+func (r *creditDocumentRepository) GetByCreditID(ctx context.Context, creditID uint, scheduleVersion int) ([]model.CreditDocument, error) {
+	var documents []model.CreditDocument
+	if err := r.conn(ctx).Omit("content", "signature").
+		Where("credit_id = ? AND schedule_version = ?", creditID, scheduleVersion).
+		Order("id").Find(&documents).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return documents, nil
+}
+
+// GetCreditsWithoutDocuments получает кредиты с графиком платежей, для действующей версии
+// графика которых документы не сформированы
+func (r *creditDocumentRepository) GetCreditsWithoutDocuments(ctx context.Context) ([]model.Credit, error) {
+	var credits []model.Credit
+	if err := r.conn(ctx).
+		Where("EXISTS (SELECT 1 FROM payment_schedules WHERE payment_schedules.credit_id = credits.id)").
+		Where("NOT EXISTS (SELECT 1 FROM credit_documents WHERE credit_documents.credit_id = credits.id" +
+			" AND credit_documents.schedule_version = credits.schedule_version AND credit_documents.deleted_at IS NULL)").
+		Find(&credits).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return credits, nil
+}
+
+// Update обновляет документ
+func (r *creditDocumentRepository) Update(ctx context.Context, document *model.CreditDocument) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Save(document).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// Delete удаляет документ
+func (r *creditDocumentRepository) Delete(ctx context.Context, id uint) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.CreditDocument{}, id).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// List получает список документов без файлов
+func (r *creditDocumentRepository) List(ctx context.Context, offset, limit int) ([]model.CreditDocument, error) {
+	var documents []model.CreditDocument
+	if err := r.conn(ctx).Omit("content", "signature").Order("id DESC").
+		Offset(offset).Limit(limit).Find(&documents).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return documents, nil
+}
+
+// Count возвращает количество документов
+func (r *creditDocumentRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.conn(ctx).Model(&model.CreditDocument{}).Count(&count).Error; err != nil {
+		return 0, r.HandleError(err)
+	}
+	return count, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+
+	"FinanceGolang/src/model"
+
+	"gorm.io/gorm"
+)
+
+// CreditLineRepository интерфейс репозитория кредитных линий и выписок по ним
+type CreditLineRepository interface {
+	Repository[model.CreditLine]
+	GetByUserID(ctx context.Context, userID uint) ([]model.CreditLine, error)
+	GetActive(ctx context.Context) ([]model.CreditLine, error)
+	CreateStatement(ctx context.Context, statement *model.CreditStatement) error
+	UpdateStatement(ctx context.Context, statement *model.CreditStatement) error
+	GetStatements(ctx context.Context, creditLineID uint) ([]model.CreditStatement, error)
+}
+
+// creditLineRepository реализация репозитория кредитных линий
+type creditLineRepository struct {
+	BaseRepository[model.CreditLine]
+}
+
+// CreditLineRepositoryInstance создает новый репозиторий кредитных линий
+func CreditLineRepositoryInstance(db *gorm.DB) CreditLineRepository {
+	return &creditLineRepository{
+		BaseRepository: *NewBaseRepository[model.CreditLine](db),
+	}
+}
+
+// Create создает новую кредитную линию
+func (r *creditLineRepository) Create(ctx context.Context, line *model.CreditLine) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := line.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Create(line).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetByID получает кредитную линию по ID
+func (r *creditLineRepository) GetByID(ctx context.Context, id uint) (*model.CreditLine, error) {
+	var line model.CreditLine
+	if err := r.conn(ctx).First(&line, id).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &line, nil
+}
+
+// GetByUserID получает кредитные линии пользователя
+func (r *creditLineRepository) GetByUserID(ctx context.Context, userID uint) ([]model.CreditLine, error) {
+	var lines []model.CreditLine
+	if err := r.conn(ctx).Where("user_id = ?", userID).Order("id").Find(&lines).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return lines, nil
+}
+
+// GetActive получает действующие кредитные линии
+func (r *creditLineRepository) GetActive(ctx context.Context) ([]model.CreditLine, error) {
+	var lines []model.CreditLine
+	if err := r.conn(ctx).Where("status = ?", model.CreditLineStatusActive).Order("id").Find(&lines).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return lines, nil
+}
+
+// Update обновляет кредитную линию
+func (r *creditLineRepository) Update(ctx context.Context, line *model.CreditLine) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := line.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Save(line).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// Delete удаляет кредитную линию
+func (r *creditLineRepository) Delete(ctx context.Context, id uint) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.CreditLine{}, id).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// List получает список кредитных линий
+func (r *creditLineRepository) List(ctx context.Context, offset, limit int) ([]model.CreditLine, error) {
+	var lines []model.CreditLine
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&lines).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return lines, nil
+}
+
+// Count возвращает количество кредитных линий
+func (r *creditLineRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.conn(ctx).Model(&model.CreditLine{}).Count(&count).Error; err != nil {
+		return 0, r.HandleError(err)
+	}
+	return count, nil
+}
+
+// CreateStatement сохраняет выписку
+func (r *creditLineRepository) CreateStatement(ctx context.Context, statement *model.CreditStatement) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(statement).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// UpdateStatement обновляет выписку
+func (r *creditLineRepository) UpdateStatement(ctx context.Context, statement *model.CreditStatement) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Save(statement).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetStatements получает выписки по кредитной линии в порядке расчетных периодов
+func (r *creditLineRepository) GetStatements(ctx context.Context, creditLineID uint) ([]model.CreditStatement, error) {
+	var statements []model.CreditStatement
+	if err := r.conn(ctx).Where("credit_line_id = ?", creditLineID).Order("period_end").Find(&statements).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return statements, nil
+}
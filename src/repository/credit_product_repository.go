@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+
+	"FinanceGolang/src/model"
+
+	"gorm.io/gorm"
+)
+
+// CreditProductRepository интерфейс репозитория кредитных продуктов
+type CreditProductRepository interface {
+	Repository[model.CreditProduct]
+	GetByCode(ctx context.Context, code string) (*model.CreditProduct, error)
+	GetActive(ctx context.Context) ([]model.CreditProduct, error)
+}
+
+// creditProductRepository реализация репозитория кредитных продуктов
+type creditProductRepository struct {
+	BaseRepository[model.CreditProduct]
+}
+
+// CreditProductRepositoryInstance создает новый репозиторий кредитных продуктов
+func CreditProductRepositoryInstance(db *gorm.DB) CreditProductRepository {
+	return &creditProductRepository{
+		BaseRepository: *NewBaseRepository[model.CreditProduct](db),
+	}
+}
+
+// Create создает новый продукт
+func (r *creditProductRepository) Create(ctx context.Context, product *model.CreditProduct) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := product.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Create(product).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetByID получает продукт по ID
+func (r *creditProductRepository) GetByID(ctx context.Context, id uint) (*model.CreditProduct, error) {
+	var product model.CreditProduct
+	if err := r.conn(ctx).First(&product, id).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &product, nil
+}
+
+// GetByCode получает продукт по коду
+func (r *creditProductRepository) GetByCode(ctx context.Context, code string) (*model.CreditProduct, error) {
+	var product model.CreditProduct
+	if err := r.conn(ctx).Where("code = ?", code).First(&product).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &product, nil
+}
+
+// GetActive получает активные продукты
+func (r *creditProductRepository) GetActive(ctx context.Context) ([]model.CreditProduct, error) {
+	var products []model.CreditProduct
+	if err := r.conn(ctx).Where("is_active = ?", true).Order("id").Find(&products).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return products, nil
+}
+
+// Update обновляет продукт
+func (r *creditProductRepository) Update(ctx context.Context, product *model.CreditProduct) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := product.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Save(product).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// Delete удаляет продукт
+func (r *creditProductRepository) Delete(ctx context.Context, id uint) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.CreditProduct{}, id).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// List получает список продуктов
+func (r *creditProductRepository) List(ctx context.Context, offset, limit int) ([]model.CreditProduct, error) {
+	var products []model.CreditProduct
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&products).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return products, nil
+}
+
+// Count возвращает количество продуктов
+func (r *creditProductRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.conn(ctx).Model(&model.CreditProduct{}).Count(&count).Error; err != nil {
+		return 0, r.HandleError(err)
+	}
+	return count, nil
+}
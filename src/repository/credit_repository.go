@@ -15,6 +15,8 @@ type CreditRepository interface {
 	GetByAccountID(ctx context.Context, accountID uint) (*model.Credit, error)
 	GetActiveCredits(ctx context.Context) ([]model.Credit, error)
 	GetOverdueCredits(ctx context.Context) ([]model.Credit, error)
+	GetFloatingCredits(ctx context.Context) ([]model.Credit, error)
+	GetCreditsWithoutSchedule(ctx context.Context) ([]model.Credit, error)
 	GetCreditsByUserID(ctx context.Context, userID uint) ([]model.Credit, error)
 	UpdateStatus(ctx context.Context, id uint, status model.CreditStatus) error
 	UpdateNextPayment(ctx context.Context, id uint, nextPayment time.Time) error
@@ -22,7 +24,22 @@ type CreditRepository interface {
 	GetCreditsByStatus(ctx context.Context, status model.CreditStatus) ([]model.Credit, error)
 	GetCreditsByDateRange(ctx context.Context, startDate, endDate time.Time) ([]model.Credit, error)
 	GetPaymentSchedule(ctx context.Context, creditID uint) ([]model.PaymentSchedule, error)
+	GetScheduledPayment(ctx context.Context, creditID uint, paymentNumber int) (*model.PaymentSchedule, error)
+	CreatePaymentSchedule(ctx context.Context, schedule []model.PaymentSchedule) error
+	ReplacePaymentSchedule(ctx context.Context, creditID uint, fromNumber int, schedule []model.PaymentSchedule) error
 	UpdatePaymentSchedule(ctx context.Context, payment *model.PaymentSchedule) error
+	AddRateHistory(ctx context.Context, entry *model.CreditRateHistory) error
+	GetRateHistory(ctx context.Context, creditID uint) ([]model.CreditRateHistory, error)
+	ArchivePaymentSchedule(ctx context.Context, payments []model.ArchivedPayment) error
+	GetArchivedSchedule(ctx context.Context, creditID uint, version int) ([]model.ArchivedPayment, error)
+	AddRestructuring(ctx context.Context, restructuring *model.CreditRestructuring) error
+	GetRestructurings(ctx context.Context, creditID uint) ([]model.CreditRestructuring, error)
+	AddCollateral(ctx context.Context, collateral *model.Collateral) error
+	AddGuarantor(ctx context.Context, guarantor *model.Guarantor) error
+	GetApplicationSecurity(ctx context.Context, applicationID uint) (*model.CreditSecurity, error)
+	GetCreditSecurity(ctx context.Context, creditID uint) (*model.CreditSecurity, error)
+	GetGuaranteedCredits(ctx context.Context, userID uint) ([]model.Credit, error)
+	AttachSecurity(ctx context.Context, applicationID, creditID uint) error
 }
 
 // creditRepository реализация репозитория кредитов
@@ -54,7 +71,7 @@ func (r *creditRepository) Create(ctx context.Context, credit *model.Credit) err
 // GetByID получает кредит по ID
 func (r *creditRepository) GetByID(ctx context.Context, id uint) (*model.Credit, error) {
 	var credit model.Credit
-	if err := r.db.First(&credit, id).Error; err != nil {
+	if err := r.conn(ctx).First(&credit, id).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return &credit, nil
@@ -63,7 +80,7 @@ func (r *creditRepository) GetByID(ctx context.Context, id uint) (*model.Credit,
 // GetByAccountID получает кредит по ID счета
 func (r *creditRepository) GetByAccountID(ctx context.Context, accountID uint) (*model.Credit, error) {
 	var credit model.Credit
-	if err := r.db.Where("account_id = ?", accountID).First(&credit).Error; err != nil {
+	if err := r.conn(ctx).Where("account_id = ?", accountID).First(&credit).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return &credit, nil
@@ -72,7 +89,7 @@ func (r *creditRepository) GetByAccountID(ctx context.Context, accountID uint) (
 // GetActiveCredits получает активные кредиты
 func (r *creditRepository) GetActiveCredits(ctx context.Context) ([]model.Credit, error) {
 	var credits []model.Credit
-	if err := r.db.Where("status = ?", model.CreditStatusActive).Find(&credits).Error; err != nil {
+	if err := r.conn(ctx).Where("status = ?", model.CreditStatusActive).Find(&credits).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return credits, nil
@@ -82,7 +99,31 @@ func (r *creditRepository) GetActiveCredits(ctx context.Context) ([]model.Credit
 func (r *creditRepository) GetOverdueCredits(ctx context.Context) ([]model.Credit, error) {
 	var credits []model.Credit
 	now := time.Now()
-	if err := r.db.Where("status = ? AND next_payment < ?", model.CreditStatusActive, now).Find(&credits).Error; err != nil {
+	if err := r.conn(ctx).Where("status IN ? AND next_payment < ?",
+		[]model.CreditStatus{model.CreditStatusActive, model.CreditStatusOverdue}, now).
+		Find(&credits).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return credits, nil
+}
+
+// GetFloatingCredits получает непогашенные кредиты с плавающей ставкой
+func (r *creditRepository) GetFloatingCredits(ctx context.Context) ([]model.Credit, error) {
+	var credits []model.Credit
+	if err := r.conn(ctx).Where("status IN ? AND rate_type = ?",
+		[]model.CreditStatus{model.CreditStatusActive, model.CreditStatusOverdue}, model.CreditRateFloating).
+		Find(&credits).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return credits, nil
+}
+
+// GetCreditsWithoutSchedule получает кредиты, для которых не сохранен график платежей
+func (r *creditRepository) GetCreditsWithoutSchedule(ctx context.Context) ([]model.Credit, error) {
+	var credits []model.Credit
+	if err := r.conn(ctx).
+		Where("NOT EXISTS (SELECT 1 FROM payment_schedules WHERE payment_schedules.credit_id = credits.id)").
+		Find(&credits).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return credits, nil
@@ -91,7 +132,7 @@ func (r *creditRepository) GetOverdueCredits(ctx context.Context) ([]model.Credi
 // GetCreditsByUserID получает кредиты пользователя
 func (r *creditRepository) GetCreditsByUserID(ctx context.Context, userID uint) ([]model.Credit, error) {
 	var credits []model.Credit
-	if err := r.db.Joins("JOIN accounts ON accounts.id = credits.account_id").
+	if err := r.conn(ctx).Joins("JOIN accounts ON accounts.id = credits.account_id").
 		Where("accounts.user_id = ?", userID).
 		Find(&credits).Error; err != nil {
 		return nil, r.HandleError(err)
@@ -109,6 +150,9 @@ func (r *creditRepository) Update(ctx context.Context, credit *model.Credit) err
 		if err := tx.Save(credit).Error; err != nil {
 			return r.HandleError(err)
 		}
+		if credit.Status == model.CreditStatusPaid {
+			return r.releaseSecurity(tx, credit.ID)
+		}
 		return nil
 	})
 }
@@ -119,10 +163,29 @@ func (r *creditRepository) UpdateStatus(ctx context.Context, id uint, status mod
 		if err := tx.Model(&model.Credit{}).Where("id = ?", id).Update("status", status).Error; err != nil {
 			return r.HandleError(err)
 		}
+		if status == model.CreditStatusPaid {
+			return r.releaseSecurity(tx, id)
+		}
 		return nil
 	})
 }
 
+// releaseSecurity снимает обременение с залога и прекращает поручительства по погашенному кредиту
+func (r *creditRepository) releaseSecurity(tx *gorm.DB, creditID uint) error {
+	now := time.Now()
+	if err := tx.Model(&model.Collateral{}).
+		Where("credit_id = ? AND status = ?", creditID, model.CollateralStatusPledged).
+		Updates(map[string]interface{}{"status": model.CollateralStatusReleased, "released_at": now}).Error; err != nil {
+		return r.HandleError(err)
+	}
+	if err := tx.Model(&model.Guarantor{}).
+		Where("credit_id = ? AND status = ?", creditID, model.GuarantorStatusActive).
+		Updates(map[string]interface{}{"status": model.GuarantorStatusReleased, "released_at": now}).Error; err != nil {
+		return r.HandleError(err)
+	}
+	return nil
+}
+
 // UpdateNextPayment обновляет дату следующего платежа
 func (r *creditRepository) UpdateNextPayment(ctx context.Context, id uint, nextPayment time.Time) error {
 	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
@@ -157,7 +220,7 @@ func (r *creditRepository) Delete(ctx context.Context, id uint) error {
 // List получает список кредитов
 func (r *creditRepository) List(ctx context.Context, offset, limit int) ([]model.Credit, error) {
 	var credits []model.Credit
-	if err := r.db.Offset(offset).Limit(limit).Find(&credits).Error; err != nil {
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&credits).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return credits, nil
@@ -166,7 +229,7 @@ func (r *creditRepository) List(ctx context.Context, offset, limit int) ([]model
 // Count возвращает количество кредитов
 func (r *creditRepository) Count(ctx context.Context) (int64, error) {
 	var count int64
-	if err := r.db.Model(&model.Credit{}).Count(&count).Error; err != nil {
+	if err := r.conn(ctx).Model(&model.Credit{}).Count(&count).Error; err != nil {
 		return 0, r.HandleError(err)
 	}
 	return count, nil
@@ -175,7 +238,7 @@ func (r *creditRepository) Count(ctx context.Context) (int64, error) {
 // GetCreditsByStatus получает кредиты по статусу
 func (r *creditRepository) GetCreditsByStatus(ctx context.Context, status model.CreditStatus) ([]model.Credit, error) {
 	var credits []model.Credit
-	if err := r.db.Where("status = ?", status).Find(&credits).Error; err != nil {
+	if err := r.conn(ctx).Where("status = ?", status).Find(&credits).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return credits, nil
@@ -184,7 +247,7 @@ func (r *creditRepository) GetCreditsByStatus(ctx context.Context, status model.
 // GetCreditsByDateRange получает кредиты в указанном диапазоне дат
 func (r *creditRepository) GetCreditsByDateRange(ctx context.Context, startDate, endDate time.Time) ([]model.Credit, error) {
 	var credits []model.Credit
-	if err := r.db.Where("created_at BETWEEN ? AND ?", startDate, endDate).Find(&credits).Error; err != nil {
+	if err := r.conn(ctx).Where("created_at BETWEEN ? AND ?", startDate, endDate).Find(&credits).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return credits, nil
@@ -193,12 +256,53 @@ func (r *creditRepository) GetCreditsByDateRange(ctx context.Context, startDate,
 // GetPaymentSchedule получает график платежей по кредиту
 func (r *creditRepository) GetPaymentSchedule(ctx context.Context, creditID uint) ([]model.PaymentSchedule, error) {
 	var schedule []model.PaymentSchedule
-	if err := r.db.Where("credit_id = ?", creditID).Find(&schedule).Error; err != nil {
+	if err := r.conn(ctx).Where("credit_id = ?", creditID).Order("payment_number").Find(&schedule).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return schedule, nil
 }
 
+// GetScheduledPayment получает платеж графика по номеру
+func (r *creditRepository) GetScheduledPayment(ctx context.Context, creditID uint, paymentNumber int) (*model.PaymentSchedule, error) {
+	var payment model.PaymentSchedule
+	if err := r.conn(ctx).Where("credit_id = ? AND payment_number = ?", creditID, paymentNumber).
+		First(&payment).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &payment, nil
+}
+
+// CreatePaymentSchedule сохраняет график платежей
+func (r *creditRepository) CreatePaymentSchedule(ctx context.Context, schedule []model.PaymentSchedule) error {
+	if len(schedule) == 0 {
+		return nil
+	}
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(&schedule).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// ReplacePaymentSchedule заменяет неоплаченные платежи графика начиная с номера fromNumber
+func (r *creditRepository) ReplacePaymentSchedule(ctx context.Context, creditID uint, fromNumber int, schedule []model.PaymentSchedule) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Where("credit_id = ? AND payment_number >= ? AND status <> ?",
+			creditID, fromNumber, model.PaymentStatusPaid).
+			Delete(&model.PaymentSchedule{}).Error; err != nil {
+			return r.HandleError(err)
+		}
+		if len(schedule) == 0 {
+			return nil
+		}
+		if err := tx.Create(&schedule).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
 // UpdatePaymentSchedule обновляет график платежей
 func (r *creditRepository) UpdatePaymentSchedule(ctx context.Context, payment *model.PaymentSchedule) error {
 	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
@@ -208,3 +312,134 @@ func (r *creditRepository) UpdatePaymentSchedule(ctx context.Context, payment *m
 		return nil
 	})
 }
+
+// AddRateHistory сохраняет запись об установке ставки по кредиту
+func (r *creditRepository) AddRateHistory(ctx context.Context, entry *model.CreditRateHistory) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetRateHistory получает историю ставок по кредиту
+func (r *creditRepository) GetRateHistory(ctx context.Context, creditID uint) ([]model.CreditRateHistory, error) {
+	var history []model.CreditRateHistory
+	if err := r.conn(ctx).Where("credit_id = ?", creditID).Order("id").Find(&history).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return history, nil
+}
+
+// ArchivePaymentSchedule сохраняет предыдущую версию графика платежей
+func (r *creditRepository) ArchivePaymentSchedule(ctx context.Context, payments []model.ArchivedPayment) error {
+	if len(payments) == 0 {
+		return nil
+	}
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(&payments).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetArchivedSchedule получает сохраненную версию графика платежей
+func (r *creditRepository) GetArchivedSchedule(ctx context.Context, creditID uint, version int) ([]model.ArchivedPayment, error) {
+	var payments []model.ArchivedPayment
+	if err := r.conn(ctx).Where("credit_id = ? AND version = ?", creditID, version).
+		Order("payment_number").Find(&payments).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return payments, nil
+}
+
+// AddRestructuring сохраняет запись о реструктуризации кредита
+func (r *creditRepository) AddRestructuring(ctx context.Context, restructuring *model.CreditRestructuring) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(restructuring).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetRestructurings получает реструктуризации кредита
+func (r *creditRepository) GetRestructurings(ctx context.Context, creditID uint) ([]model.CreditRestructuring, error) {
+	var restructurings []model.CreditRestructuring
+	if err := r.conn(ctx).Where("credit_id = ?", creditID).Order("id").Find(&restructurings).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return restructurings, nil
+}
+
+// AddCollateral сохраняет предмет залога по заявке
+func (r *creditRepository) AddCollateral(ctx context.Context, collateral *model.Collateral) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(collateral).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// AddGuarantor сохраняет поручителя по заявке
+func (r *creditRepository) AddGuarantor(ctx context.Context, guarantor *model.Guarantor) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(guarantor).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetApplicationSecurity получает залог и поручителей по заявке
+func (r *creditRepository) GetApplicationSecurity(ctx context.Context, applicationID uint) (*model.CreditSecurity, error) {
+	return r.getSecurity(ctx, "application_id = ?", applicationID)
+}
+
+// GetCreditSecurity получает залог и поручителей по кредиту
+func (r *creditRepository) GetCreditSecurity(ctx context.Context, creditID uint) (*model.CreditSecurity, error) {
+	return r.getSecurity(ctx, "credit_id = ?", creditID)
+}
+
+// getSecurity получает залог и поручителей по условию
+func (r *creditRepository) getSecurity(ctx context.Context, query string, id uint) (*model.CreditSecurity, error) {
+	security := &model.CreditSecurity{Collateral: []model.Collateral{}, Guarantors: []model.Guarantor{}}
+	if err := r.conn(ctx).Where(query, id).Order("id").Find(&security.Collateral).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	if err := r.conn(ctx).Where(query, id).Order("id").Find(&security.Guarantors).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return security, nil
+}
+
+// GetGuaranteedCredits получает кредиты, по которым пользователь выступает поручителем
+func (r *creditRepository) GetGuaranteedCredits(ctx context.Context, userID uint) ([]model.Credit, error) {
+	var credits []model.Credit
+	if err := r.conn(ctx).Where("id IN (?)", r.conn(ctx).Model(&model.Guarantor{}).
+		Select("credit_id").Where("user_id = ? AND credit_id IS NOT NULL", userID)).
+		Order("id").Find(&credits).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return credits, nil
+}
+
+// AttachSecurity закрепляет залог и поручителей по заявке за выданным кредитом
+func (r *creditRepository) AttachSecurity(ctx context.Context, applicationID, creditID uint) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Collateral{}).
+			Where("application_id = ? AND status = ?", applicationID, model.CollateralStatusRegistered).
+			Updates(map[string]interface{}{"credit_id": creditID, "status": model.CollateralStatusPledged}).Error; err != nil {
+			return r.HandleError(err)
+		}
+		if err := tx.Model(&model.Guarantor{}).
+			Where("application_id = ? AND status = ?", applicationID, model.GuarantorStatusRegistered).
+			Updates(map[string]interface{}{"credit_id": creditID, "status": model.GuarantorStatusActive}).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
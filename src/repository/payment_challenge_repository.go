@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"FinanceGolang/src/model"
+
+	"gorm.io/gorm"
+)
+
+// PaymentChallengeRepository интерфейс репозитория подтверждений платежей
+type PaymentChallengeRepository interface {
+	Repository[model.PaymentChallenge]
+	GetByTransactionID(ctx context.Context, transactionID uint) (*model.PaymentChallenge, error)
+	GetExpiredPending(ctx context.Context, now time.Time) ([]model.PaymentChallenge, error)
+	UpdatePending(ctx context.Context, challenge *model.PaymentChallenge) (bool, error)
+	RegisterFailedAttempt(ctx context.Context, challenge *model.PaymentChallenge) (bool, error)
+	ConfirmPayment(ctx context.Context, challenge *model.PaymentChallenge, accountID uint, transaction *model.Transaction) (bool, error)
+}
+
+// paymentChallengeRepository реализация репозитория подтверждений платежей
+type paymentChallengeRepository struct {
+	BaseRepository[model.PaymentChallenge]
+}
+
+// PaymentChallengeRepositoryInstance создает новый репозиторий подтверждений платежей
+func PaymentChallengeRepositoryInstance(db *gorm.DB) PaymentChallengeRepository {
+	return &paymentChallengeRepository{
+		BaseRepository: *NewBaseRepository[model.PaymentChallenge](db),
+	}
+}
+
+// Create создает новое подтверждение
+func (r *paymentChallengeRepository) Create(ctx context.Context, challenge *model.PaymentChallenge) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := challenge.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Create(challenge).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// GetByID получает подтверждение по ID
+func (r *paymentChallengeRepository) GetByID(ctx context.Context, id uint) (*model.PaymentChallenge, error) {
+	var challenge model.PaymentChallenge
+	if err := r.conn(ctx).First(&challenge, id).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &challenge, nil
+}
+
+// GetByTransactionID получает подтверждение по ID транзакции
+func (r *paymentChallengeRepository) GetByTransactionID(ctx context.Context, transactionID uint) (*model.PaymentChallenge, error) {
+	var challenge model.PaymentChallenge
+	if err := r.conn(ctx).Where("transaction_id = ?", transactionID).First(&challenge).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return &challenge, nil
+}
+
+// GetExpiredPending получает неподтвержденные подтверждения с истекшим сроком
+func (r *paymentChallengeRepository) GetExpiredPending(ctx context.Context, now time.Time) ([]model.PaymentChallenge, error) {
+	var challenges []model.PaymentChallenge
+	if err := r.conn(ctx).Where("status = ? AND expires_at < ?", model.PaymentChallengePending, now).
+		Find(&challenges).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return challenges, nil
+}
+
+// UpdatePending сохраняет статус подтверждения, только если в базе оно
+// еще ожидает кода. Возвращает false, если подтверждение уже закрыто другим запросом.
+func (r *paymentChallengeRepository) UpdatePending(ctx context.Context, challenge *model.PaymentChallenge) (bool, error) {
+	updated := false
+	err := r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		var err error
+		updated, err = r.updatePending(tx, challenge)
+		return err
+	})
+	return updated, err
+}
+
+// RegisterFailedAttempt учитывает неверный код условным UPDATE ... SET attempts = attempts + 1
+// WHERE status = PENDING AND attempts < MaxOTPAttempts и перечитывает подтверждение. После последней
+// попытки подтверждение закрывается статусом FAILED. Возвращает false, если подтверждение уже
+// закрыто другим запросом.
+func (r *paymentChallengeRepository) RegisterFailedAttempt(ctx context.Context, challenge *model.PaymentChallenge) (bool, error) {
+	registered := false
+	err := r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		result := tx.Model(challenge).
+			Where("status = ? AND attempts < ?", model.PaymentChallengePending, model.MaxOTPAttempts).
+			Update("attempts", gorm.Expr("attempts + 1"))
+		if result.Error != nil {
+			return r.HandleError(result.Error)
+		}
+		if result.RowsAffected != 1 {
+			return nil
+		}
+
+		if err := tx.First(challenge, challenge.ID).Error; err != nil {
+			return r.HandleError(err)
+		}
+		if challenge.Attempts >= model.MaxOTPAttempts {
+			challenge.Status = model.PaymentChallengeFailed
+			if err := tx.Model(challenge).Update("status", challenge.Status).Error; err != nil {
+				return r.HandleError(err)
+			}
+		}
+		registered = true
+		return nil
+	})
+	return registered, err
+}
+
+// ConfirmPayment в одной транзакции базы данных закрывает подтверждение статусом CONFIRMED,
+// списывает сумму транзакции со счета accountID и сохраняет транзакцию. Если подтверждение
+// уже закрыто другим запросом, возвращает false и ничего не меняет. Если на счете не хватает
+// средств с учетом кредитного лимита, возвращает model.ErrInsufficientFunds и ничего не меняет.
+func (r *paymentChallengeRepository) ConfirmPayment(ctx context.Context, challenge *model.PaymentChallenge, accountID uint, transaction *model.Transaction) (bool, error) {
+	confirmed := false
+	err := r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		challenge.Status = model.PaymentChallengeConfirmed
+		updated, err := r.updatePending(tx, challenge)
+		if err != nil || !updated {
+			return err
+		}
+
+		result := tx.Model(&model.Account{}).
+			Where("id = ? AND balance + credit_limit >= ?", accountID, transaction.Amount).
+			Update("balance", gorm.Expr("balance - ?", transaction.Amount))
+		if result.Error != nil {
+			return r.HandleError(result.Error)
+		}
+		if result.RowsAffected != 1 {
+			return model.ErrInsufficientFunds
+		}
+		if err := tx.Save(transaction).Error; err != nil {
+			return r.HandleError(err)
+		}
+		confirmed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return confirmed, nil
+}
+
+// updatePending закрывает подтверждение условным UPDATE ... WHERE status = PENDING.
+// Число попыток меняет только RegisterFailedAttempt.
+func (r *paymentChallengeRepository) updatePending(tx *gorm.DB, challenge *model.PaymentChallenge) (bool, error) {
+	if err := challenge.Validate(); err != nil {
+		return false, ErrInvalidData
+	}
+
+	result := tx.Model(challenge).
+		Where("status = ?", model.PaymentChallengePending).
+		Update("status", challenge.Status)
+	if result.Error != nil {
+		return false, r.HandleError(result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Update обновляет подтверждение
+func (r *paymentChallengeRepository) Update(ctx context.Context, challenge *model.PaymentChallenge) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := challenge.Validate(); err != nil {
+			return ErrInvalidData
+		}
+
+		if err := tx.Save(challenge).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// Delete удаляет подтверждение
+func (r *paymentChallengeRepository) Delete(ctx context.Context, id uint) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.PaymentChallenge{}, id).Error; err != nil {
+			return r.HandleError(err)
+		}
+		return nil
+	})
+}
+
+// List получает список подтверждений
+func (r *paymentChallengeRepository) List(ctx context.Context, offset, limit int) ([]model.PaymentChallenge, error) {
+	var challenges []model.PaymentChallenge
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&challenges).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return challenges, nil
+}
+
+// Count возвращает количество подтверждений
+func (r *paymentChallengeRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.conn(ctx).Model(&model.PaymentChallenge{}).Count(&count).Error; err != nil {
+		return 0, r.HandleError(err)
+	}
+	return count, nil
+}
@@ -28,8 +28,13 @@ type Repository[T any] interface {
 	List(ctx context.Context, offset, limit int) ([]T, error)
 	// Count возвращает общее количество записей
 	Count(ctx context.Context) (int64, error)
+	// InTransaction выполняет fn в одной транзакции базы данных
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
+// txKey ключ контекста, под которым хранится открытая транзакция
+type txKey struct{}
+
 // BaseRepository базовая реализация репозитория
 type BaseRepository[T any] struct {
 	db *gorm.DB
@@ -40,8 +45,13 @@ func NewBaseRepository[T any](db *gorm.DB) *BaseRepository[T] {
 	return &BaseRepository[T]{db: db}
 }
 
-// WithTransaction выполняет операции в транзакции
+// WithTransaction выполняет операции в транзакции. Если в ctx уже открыта транзакция
+// (см. InTransaction), операции выполняются в ней, а фиксирует ее вызывающий.
 func (r *BaseRepository[T]) WithTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(tx)
+	}
+
 	tx := r.db.Begin()
 	if tx.Error != nil {
 		return ErrDatabaseError
@@ -59,6 +69,22 @@ func (r *BaseRepository[T]) WithTransaction(ctx context.Context, fn func(tx *gor
 	return nil
 }
 
+// InTransaction выполняет fn в одной транзакции базы данных. Все репозитории, вызванные
+// с переданным в fn контекстом, читают и пишут в этой транзакции; ошибка fn откатывает ее.
+func (r *BaseRepository[T]) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.WithTransaction(ctx, func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn возвращает транзакцию, открытую в ctx, или соединение репозитория
+func (r *BaseRepository[T]) conn(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return r.db
+}
+
 // HandleError обрабатывает ошибки базы данных
 func (r *BaseRepository[T]) HandleError(err error) error {
 	if err == nil {
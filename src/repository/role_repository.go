@@ -50,7 +50,7 @@ func (r *roleRepository) Create(ctx context.Context, role *model.Role) error {
 // GetByID получает роль по ID
 func (r *roleRepository) GetByID(ctx context.Context, id uint) (*model.Role, error) {
 	var role model.Role
-	if err := r.db.First(&role, id).Error; err != nil {
+	if err := r.conn(ctx).First(&role, id).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return &role, nil
@@ -59,7 +59,7 @@ func (r *roleRepository) GetByID(ctx context.Context, id uint) (*model.Role, err
 // GetByName получает роль по имени
 func (r *roleRepository) GetByName(ctx context.Context, name string) (*model.Role, error) {
 	var role model.Role
-	if err := r.db.Where("name = ?", name).First(&role).Error; err != nil {
+	if err := r.conn(ctx).Where("name = ?", name).First(&role).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return &role, nil
@@ -68,7 +68,7 @@ func (r *roleRepository) GetByName(ctx context.Context, name string) (*model.Rol
 // GetByUserID получает роли пользователя
 func (r *roleRepository) GetByUserID(ctx context.Context, userID uint) ([]model.Role, error) {
 	var roles []model.Role
-	if err := r.db.Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+	if err := r.conn(ctx).Joins("JOIN user_roles ON user_roles.role_id = roles.id").
 		Where("user_roles.user_id = ?", userID).
 		Find(&roles).Error; err != nil {
 		return nil, r.HandleError(err)
@@ -79,7 +79,7 @@ func (r *roleRepository) GetByUserID(ctx context.Context, userID uint) ([]model.
 // GetByPermission получает роли по разрешению
 func (r *roleRepository) GetByPermission(ctx context.Context, permission string) ([]model.Role, error) {
 	var roles []model.Role
-	if err := r.db.Where("permissions @> ?", permission).Find(&roles).Error; err != nil {
+	if err := r.conn(ctx).Where("permissions @> ?", permission).Find(&roles).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return roles, nil
@@ -88,7 +88,7 @@ func (r *roleRepository) GetByPermission(ctx context.Context, permission string)
 // GetActiveRoles получает активные роли
 func (r *roleRepository) GetActiveRoles(ctx context.Context) ([]model.Role, error) {
 	var roles []model.Role
-	if err := r.db.Where("is_active = ?", true).Find(&roles).Error; err != nil {
+	if err := r.conn(ctx).Where("is_active = ?", true).Find(&roles).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return roles, nil
@@ -154,7 +154,7 @@ func (r *roleRepository) Delete(ctx context.Context, id uint) error {
 // List получает список ролей
 func (r *roleRepository) List(ctx context.Context, offset, limit int) ([]model.Role, error) {
 	var roles []model.Role
-	if err := r.db.Offset(offset).Limit(limit).Find(&roles).Error; err != nil {
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&roles).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return roles, nil
@@ -163,7 +163,7 @@ func (r *roleRepository) List(ctx context.Context, offset, limit int) ([]model.R
 // Count возвращает количество ролей
 func (r *roleRepository) Count(ctx context.Context) (int64, error) {
 	var count int64
-	if err := r.db.Model(&model.Role{}).Count(&count).Error; err != nil {
+	if err := r.conn(ctx).Model(&model.Role{}).Count(&count).Error; err != nil {
 		return 0, r.HandleError(err)
 	}
 	return count, nil
@@ -17,6 +17,7 @@ type TransactionRepository interface {
 	GetByType(ctx context.Context, transactionType model.TransactionType) ([]model.Transaction, error)
 	GetByStatus(ctx context.Context, status model.TransactionStatus) ([]model.Transaction, error)
 	GetByDateRange(ctx context.Context, startDate, endDate time.Time) ([]model.Transaction, error)
+	GetByAccountAndDateRange(ctx context.Context, accountID uint, startDate, endDate time.Time) ([]model.Transaction, error)
 	GetDailyTransactions(ctx context.Context, date time.Time) ([]model.Transaction, error)
 	GetMonthlyTransactions(ctx context.Context, year int, month time.Month) ([]model.Transaction, error)
 	UpdateStatus(ctx context.Context, id uint, status model.TransactionStatus) error
@@ -52,7 +53,7 @@ func (r *transactionRepository) Create(ctx context.Context, transaction *model.T
 // GetByID получает транзакцию по ID
 func (r *transactionRepository) GetByID(ctx context.Context, id uint) (*model.Transaction, error) {
 	var transaction model.Transaction
-	if err := r.db.First(&transaction, id).Error; err != nil {
+	if err := r.conn(ctx).First(&transaction, id).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return &transaction, nil
@@ -61,7 +62,7 @@ func (r *transactionRepository) GetByID(ctx context.Context, id uint) (*model.Tr
 // GetByAccountID получает транзакции по ID счета
 func (r *transactionRepository) GetByAccountID(ctx context.Context, accountID uint) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	if err := r.db.Where("from_account_id = ? OR to_account_id = ?", accountID, accountID).
+	if err := r.conn(ctx).Where("from_account_id = ? OR to_account_id = ?", accountID, accountID).
 		Find(&transactions).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
@@ -71,7 +72,7 @@ func (r *transactionRepository) GetByAccountID(ctx context.Context, accountID ui
 // GetByCardID получает транзакции по ID карты
 func (r *transactionRepository) GetByCardID(ctx context.Context, cardID uint) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	if err := r.db.Where("card_id = ?", cardID).Find(&transactions).Error; err != nil {
+	if err := r.conn(ctx).Where("card_id = ?", cardID).Find(&transactions).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return transactions, nil
@@ -80,7 +81,7 @@ func (r *transactionRepository) GetByCardID(ctx context.Context, cardID uint) ([
 // GetByType получает транзакции по типу
 func (r *transactionRepository) GetByType(ctx context.Context, transactionType model.TransactionType) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	if err := r.db.Where("type = ?", transactionType).Find(&transactions).Error; err != nil {
+	if err := r.conn(ctx).Where("type = ?", transactionType).Find(&transactions).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return transactions, nil
@@ -89,7 +90,7 @@ func (r *transactionRepository) GetByType(ctx context.Context, transactionType m
 // GetByStatus получает транзакции по статусу
 func (r *transactionRepository) GetByStatus(ctx context.Context, status model.TransactionStatus) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	if err := r.db.Where("status = ?", status).Find(&transactions).Error; err != nil {
+	if err := r.conn(ctx).Where("status = ?", status).Find(&transactions).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return transactions, nil
@@ -98,20 +99,32 @@ func (r *transactionRepository) GetByStatus(ctx context.Context, status model.Tr
 // GetByDateRange получает транзакции в указанном диапазоне дат
 func (r *transactionRepository) GetByDateRange(ctx context.Context, startDate, endDate time.Time) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	if err := r.db.Where("created_at BETWEEN ? AND ?", startDate, endDate).
+	if err := r.conn(ctx).Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Find(&transactions).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return transactions, nil
 }
 
+// GetByAccountAndDateRange получает транзакции по счету с startDate до endDate (не включая)
+// в порядке проведения
+func (r *transactionRepository) GetByAccountAndDateRange(ctx context.Context, accountID uint, startDate, endDate time.Time) ([]model.Transaction, error) {
+	var transactions []model.Transaction
+	if err := r.conn(ctx).Where("(from_account_id = ? OR to_account_id = ?) AND created_at >= ? AND created_at < ?",
+		accountID, accountID, startDate, endDate).
+		Order("created_at, id").Find(&transactions).Error; err != nil {
+		return nil, r.HandleError(err)
+	}
+	return transactions, nil
+}
+
 // GetDailyTransactions получает транзакции за день
 func (r *transactionRepository) GetDailyTransactions(ctx context.Context, date time.Time) ([]model.Transaction, error) {
 	var transactions []model.Transaction
 	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
-	if err := r.db.Where("created_at BETWEEN ? AND ?", startOfDay, endOfDay).
+	if err := r.conn(ctx).Where("created_at BETWEEN ? AND ?", startOfDay, endOfDay).
 		Find(&transactions).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
@@ -124,7 +137,7 @@ func (r *transactionRepository) GetMonthlyTransactions(ctx context.Context, year
 	startOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
 	endOfMonth := startOfMonth.AddDate(0, 1, 0)
 
-	if err := r.db.Where("created_at BETWEEN ? AND ?", startOfMonth, endOfMonth).
+	if err := r.conn(ctx).Where("created_at BETWEEN ? AND ?", startOfMonth, endOfMonth).
 		Find(&transactions).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
@@ -168,7 +181,7 @@ func (r *transactionRepository) Delete(ctx context.Context, id uint) error {
 // List получает список транзакций
 func (r *transactionRepository) List(ctx context.Context, offset, limit int) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	if err := r.db.Offset(offset).Limit(limit).Find(&transactions).Error; err != nil {
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&transactions).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return transactions, nil
@@ -177,7 +190,7 @@ func (r *transactionRepository) List(ctx context.Context, offset, limit int) ([]
 // Count возвращает количество транзакций
 func (r *transactionRepository) Count(ctx context.Context) (int64, error) {
 	var count int64
-	if err := r.db.Model(&model.Transaction{}).Count(&count).Error; err != nil {
+	if err := r.conn(ctx).Model(&model.Transaction{}).Count(&count).Error; err != nil {
 		return 0, r.HandleError(err)
 	}
 	return count, nil
@@ -186,7 +199,7 @@ func (r *transactionRepository) Count(ctx context.Context) (int64, error) {
 // GetTransactionsByAmountRange получает транзакции в указанном диапазоне сумм
 func (r *transactionRepository) GetTransactionsByAmountRange(ctx context.Context, minAmount, maxAmount float64) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	if err := r.db.Where("amount BETWEEN ? AND ?", minAmount, maxAmount).
+	if err := r.conn(ctx).Where("amount BETWEEN ? AND ?", minAmount, maxAmount).
 		Find(&transactions).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
@@ -50,7 +50,7 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 // GetByID получает пользователя по ID
 func (r *userRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
 	var user model.User
-	if err := r.db.First(&user, id).Error; err != nil {
+	if err := r.conn(ctx).First(&user, id).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return &user, nil
@@ -59,7 +59,7 @@ func (r *userRepository) GetByID(ctx context.Context, id uint) (*model.User, err
 // GetByEmail получает пользователя по email
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.conn(ctx).Where("email = ?", email).First(&user).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return &user, nil
@@ -68,7 +68,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.U
 // GetByUsername получает пользователя по username
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
-	if err := r.db.Where("username = ?", username).First(&user).Error; err != nil {
+	if err := r.conn(ctx).Preload("Roles").Where("username = ?", username).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
@@ -80,7 +80,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 // GetWithRoles получает пользователя с ролями
 func (r *userRepository) GetWithRoles(ctx context.Context, id uint) (*model.User, error) {
 	var user model.User
-	if err := r.db.Preload("Roles").First(&user, id).Error; err != nil {
+	if err := r.conn(ctx).Preload("Roles").First(&user, id).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return &user, nil
@@ -123,7 +123,7 @@ func (r *userRepository) Delete(ctx context.Context, id uint) error {
 // List получает список пользователей
 func (r *userRepository) List(ctx context.Context, offset, limit int) ([]model.User, error) {
 	var users []model.User
-	if err := r.db.Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+	if err := r.conn(ctx).Offset(offset).Limit(limit).Find(&users).Error; err != nil {
 		return nil, r.HandleError(err)
 	}
 	return users, nil
@@ -132,7 +132,7 @@ func (r *userRepository) List(ctx context.Context, offset, limit int) ([]model.U
 // Count возвращает количество пользователей
 func (r *userRepository) Count(ctx context.Context) (int64, error) {
 	var count int64
-	if err := r.db.Model(&model.User{}).Count(&count).Error; err != nil {
+	if err := r.conn(ctx).Model(&model.User{}).Count(&count).Error; err != nil {
 		return 0, r.HandleError(err)
 	}
 	return count, nil
@@ -165,7 +165,7 @@ func (r *userRepository) RemoveRole(ctx context.Context, userID, roleID uint) er
 // GetByRole получает пользователей по роли
 func (r *userRepository) GetByRole(ctx context.Context, roleName string, offset, limit int) ([]model.User, error) {
 	var users []model.User
-	if err := r.db.Joins("JOIN user_roles ON user_roles.user_id = users.id").
+	if err := r.conn(ctx).Joins("JOIN user_roles ON user_roles.user_id = users.id").
 		Joins("JOIN roles ON roles.id = user_roles.role_id").
 		Where("roles.name = ?", roleName).
 		Offset(offset).
@@ -179,7 +179,7 @@ func (r *userRepository) GetByRole(ctx context.Context, roleName string, offset,
 // FindUserByUsername получает пользователя по username (устаревший метод)
 func (r *userRepository) FindUserByUsername(username string) (*model.User, error) {
 	var user model.User
-	if err := r.db.Where("username = ?", username).First(&user).Error; err != nil {
+	if err := r.db.Preload("Roles").Where("username = ?", username).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
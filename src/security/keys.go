@@ -0,0 +1,294 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrKeysNotFound = errors.New("pgp keys not found")
+	ErrUnknownKeyID = errors.New("unknown pgp key id")
+)
+
+const (
+	// LegacyKeyID версия ключа для карт, зашифрованных до появления версий ключей
+	LegacyKeyID = "v1"
+
+	legacyPublicKeyFile  = "public_key.asc"
+	legacyPrivateKeyFile = "private_key.asc"
+	publicKeySuffix      = ".public.asc"
+	privateKeySuffix     = ".private.asc"
+	keyEmail             = "cards@financegolang.local"
+	signingKeyEmail      = "bank@financegolang.local"
+)
+
+// KeyPair версия PGP-ключа для шифрования данных карт
+type KeyPair struct {
+	ID         string
+	Version    int
+	PublicKey  string
+	PrivateKey string
+}
+
+// KeyManager хранит версии PGP-ключей: ключей шифрования карт или ключей подписи банка.
+// Ключи лежат в каталоге в виде <id>.public.asc и <id>.private.asc,
+// активной считается версия с наибольшим номером.
+type KeyManager struct {
+	mu       sync.RWMutex
+	dir      string
+	email    string
+	keys     map[string]*KeyPair
+	activeID string
+}
+
+// LoadKeyManager загружает ключи из каталога dir.
+// Если ключей нет, импортирует public_key.asc/private_key.asc из рабочего каталога,
+// а при allowGenerate генерирует новую пару. Иначе возвращает ErrKeysNotFound.
+func LoadKeyManager(dir string, allowGenerate bool) (*KeyManager, error) {
+	return loadKeyManager(dir, keyEmail, true, allowGenerate)
+}
+
+// LoadSigningKeyManager загружает ключи подписи банка из каталога dir.
+// Ключи подписи хранятся отдельно от ключей карт; если их нет, при allowGenerate
+// генерируется новая пара, иначе возвращается ErrKeysNotFound.
+func LoadSigningKeyManager(dir string, allowGenerate bool) (*KeyManager, error) {
+	return loadKeyManager(dir, signingKeyEmail, false, allowGenerate)
+}
+
+// loadKeyManager загружает ключи из каталога, при необходимости импортируя
+// ключи из рабочего каталога или генерируя новую пару
+func loadKeyManager(dir string, email string, importLegacy bool, allowGenerate bool) (*KeyManager, error) {
+	m := &KeyManager{
+		dir:   dir,
+		email: email,
+		keys:  make(map[string]*KeyPair),
+	}
+
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	if len(m.keys) > 0 {
+		return m, nil
+	}
+
+	if importLegacy {
+		imported, err := m.importLegacy()
+		if err != nil {
+			return nil, err
+		}
+		if imported {
+			return m, nil
+		}
+	}
+
+	if !allowGenerate {
+		return nil, fmt.Errorf("%w in %s", ErrKeysNotFound, dir)
+	}
+	if _, err := m.Rotate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ActiveKeyID возвращает идентификатор ключа, которым шифруются новые данные
+func (m *KeyManager) ActiveKeyID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.activeID
+}
+
+// Encrypt шифрует данные активным ключом и возвращает его идентификатор
+func (m *KeyManager) Encrypt(data string) (string, string, error) {
+	m.mu.RLock()
+	key := m.keys[m.activeID]
+	m.mu.RUnlock()
+
+	if key == nil {
+		return "", "", ErrKeysNotFound
+	}
+
+	ciphertext, err := encryptMessage(key.PublicKey, data)
+	if err != nil {
+		return "", "", err
+	}
+	return key.ID, ciphertext, nil
+}
+
+// Decrypt расшифровывает данные ключом keyID.
+// Пустой keyID означает данные, зашифрованные до появления версий ключей.
+func (m *KeyManager) Decrypt(keyID string, data string) (string, error) {
+	if keyID == "" {
+		keyID = LegacyKeyID
+	}
+
+	m.mu.RLock()
+	key := m.keys[keyID]
+	m.mu.RUnlock()
+
+	if key == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKeyID, keyID)
+	}
+	return decryptMessage(key.PrivateKey, data)
+}
+
+// Sign создает отсоединенную подпись данных активным ключом и возвращает его идентификатор
+func (m *KeyManager) Sign(data []byte) (string, string, error) {
+	m.mu.RLock()
+	key := m.keys[m.activeID]
+	m.mu.RUnlock()
+
+	if key == nil {
+		return "", "", ErrKeysNotFound
+	}
+
+	signature, err := signMessage(key.PrivateKey, data)
+	if err != nil {
+		return "", "", err
+	}
+	return key.ID, signature, nil
+}
+
+// Verify проверяет отсоединенную подпись данных ключом keyID
+func (m *KeyManager) Verify(keyID string, data []byte, signature string) error {
+	publicKey, err := m.PublicKey(keyID)
+	if err != nil {
+		return err
+	}
+	return verifySignature(publicKey, data, signature)
+}
+
+// PublicKey возвращает открытый ключ версии keyID, пустой keyID означает активную версию
+func (m *KeyManager) PublicKey(keyID string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if keyID == "" {
+		keyID = m.activeID
+	}
+	key := m.keys[keyID]
+	if key == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKeyID, keyID)
+	}
+	return key.PublicKey, nil
+}
+
+// Rotate генерирует новую пару ключей, сохраняет ее и делает активной
+func (m *KeyManager) Rotate() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	version := 0
+	for _, key := range m.keys {
+		if key.Version > version {
+			version = key.Version
+		}
+	}
+
+	publicKey, privateKey, err := GenerateKeyPair(m.email)
+	if err != nil {
+		return "", fmt.Errorf("error generating key pair: %v", err)
+	}
+
+	key := &KeyPair{
+		ID:         fmt.Sprintf("v%d", version+1),
+		Version:    version + 1,
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+	}
+	if err := m.save(key); err != nil {
+		return "", err
+	}
+
+	m.keys[key.ID] = key
+	m.activeID = key.ID
+	return key.ID, nil
+}
+
+// load читает все версии ключей из каталога
+func (m *KeyManager) load() error {
+	files, err := filepath.Glob(filepath.Join(m.dir, "*"+publicKeySuffix))
+	if err != nil {
+		return fmt.Errorf("error listing keys in %s: %v", m.dir, err)
+	}
+
+	for _, publicKeyFile := range files {
+		id := strings.TrimSuffix(filepath.Base(publicKeyFile), publicKeySuffix)
+
+		var version int
+		if _, err := fmt.Sscanf(id, "v%d", &version); err != nil {
+			return fmt.Errorf("invalid key id %q: %v", id, err)
+		}
+
+		publicKey, err := ioutil.ReadFile(publicKeyFile)
+		if err != nil {
+			return fmt.Errorf("error reading public key %s: %v", id, err)
+		}
+		privateKey, err := ioutil.ReadFile(filepath.Join(m.dir, id+privateKeySuffix))
+		if err != nil {
+			return fmt.Errorf("error reading private key %s: %v", id, err)
+		}
+
+		m.keys[id] = &KeyPair{
+			ID:         id,
+			Version:    version,
+			PublicKey:  string(publicKey),
+			PrivateKey: string(privateKey),
+		}
+		if active := m.keys[m.activeID]; active == nil || active.Version < version {
+			m.activeID = id
+		}
+	}
+
+	return nil
+}
+
+// importLegacy переносит ключи из рабочего каталога как версию LegacyKeyID
+func (m *KeyManager) importLegacy() (bool, error) {
+	publicKey, err := ioutil.ReadFile(legacyPublicKeyFile)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("error reading legacy public key: %v", err)
+	}
+
+	privateKey, err := ioutil.ReadFile(legacyPrivateKeyFile)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("error reading legacy private key: %v", err)
+	}
+
+	key := &KeyPair{
+		ID:         LegacyKeyID,
+		Version:    1,
+		PublicKey:  string(publicKey),
+		PrivateKey: string(privateKey),
+	}
+	if err := m.save(key); err != nil {
+		return false, err
+	}
+
+	m.keys[key.ID] = key
+	m.activeID = key.ID
+	return true, nil
+}
+
+// save записывает пару ключей в каталог
+func (m *KeyManager) save(key *KeyPair) error {
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		return fmt.Errorf("error creating keys directory: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(m.dir, key.ID+privateKeySuffix), []byte(key.PrivateKey), 0600); err != nil {
+		return fmt.Errorf("error saving private key %s: %v", key.ID, err)
+	}
+	// Открытый ключ пишем последним: по нему load находит версии
+	if err := ioutil.WriteFile(filepath.Join(m.dir, key.ID+publicKeySuffix), []byte(key.PublicKey), 0644); err != nil {
+		return fmt.Errorf("error saving public key %s: %v", key.ID, err)
+	}
+	return nil
+}
@@ -2,9 +2,9 @@ package security
 
 import (
 	"bytes"
-	"fmt"
+	"encoding/base64"
 	"io/ioutil"
-	"os"
+	"strings"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/ProtonMail/go-crypto/openpgp/armor"
@@ -23,11 +23,13 @@ func GenerateKeyPair(email string) (string, string, error) {
 	if err != nil {
 		return "", "", err
 	}
-	defer publicKeyWriter.Close()
-
 	if err := entity.Serialize(publicKeyWriter); err != nil {
 		return "", "", err
 	}
+	// Закрываем writer до чтения буфера, иначе armor-блок останется незавершенным
+	if err := publicKeyWriter.Close(); err != nil {
+		return "", "", err
+	}
 
 	// Экспорт закрытого ключа
 	var privateKeyBuf bytes.Buffer
@@ -35,56 +37,103 @@ func GenerateKeyPair(email string) (string, string, error) {
 	if err != nil {
 		return "", "", err
 	}
-	defer privateKeyWriter.Close()
-
 	if err := entity.SerializePrivate(privateKeyWriter, nil); err != nil {
 		return "", "", err
 	}
+	if err := privateKeyWriter.Close(); err != nil {
+		return "", "", err
+	}
 
 	return publicKeyBuf.String(), privateKeyBuf.String(), nil
 }
 
-func MainGenerateKeyPair() {
-	publicKeyFile := "public_key.asc"
-	privateKeyFile := "private_key.asc"
+// encryptMessage шифрует данные открытым ключом и возвращает base64 от armor-сообщения
+func encryptMessage(publicKey string, data string) (string, error) {
+	entityList, err := openpgp.ReadArmoredKeyRing(strings.NewReader(publicKey))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	writer, err := armor.Encode(&buf, "PGP MESSAGE", nil)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := openpgp.Encrypt(writer, entityList, nil, nil, nil)
+	if err != nil {
+		return "", err
+	}
 
-	// Проверка существования ключей
-	if _, err := os.Stat(publicKeyFile); err == nil {
-		fmt.Println("Public key already exists. Skipping key generation.")
-		return
-	} else if !os.IsNotExist(err) {
-		fmt.Println("Error checking public key file:", err)
-		return
+	if _, err := plaintext.Write([]byte(data)); err != nil {
+		return "", err
+	}
+	// Сообщение нужно закрыть до armor-writer, иначе шифртекст будет обрезан
+	if err := plaintext.Close(); err != nil {
+		return "", err
 	}
 
-	if _, err := os.Stat(privateKeyFile); err == nil {
-		fmt.Println("Private key already exists. Skipping key generation.")
-		return
-	} else if !os.IsNotExist(err) {
-		fmt.Println("Error checking private key file:", err)
-		return
+	if err := writer.Close(); err != nil {
+		return "", err
 	}
 
-	// Генерация ключа
-	email := "user@example.com" // Замените на ваш email
-	publicKey, privateKey, err := GenerateKeyPair(email)
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decryptMessage расшифровывает результат encryptMessage закрытым ключом
+func decryptMessage(privateKey string, data string) (string, error) {
+	entityList, err := openpgp.ReadArmoredKeyRing(strings.NewReader(privateKey))
 	if err != nil {
-		fmt.Println("Error generating key pair:", err)
-		return
+		return "", err
 	}
 
-	// Сохранение ключей в файлы
-	if err := ioutil.WriteFile(publicKeyFile, []byte(publicKey), 0644); err != nil {
-		fmt.Println("Error saving public key:", err)
-		return
+	armored, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
 	}
-	if err := ioutil.WriteFile(privateKeyFile, []byte(privateKey), 0644); err != nil {
-		fmt.Println("Error saving private key:", err)
-		return
+
+	block, err := armor.Decode(bytes.NewReader(armored))
+	if err != nil {
+		return "", err
+	}
+
+	md, err := openpgp.ReadMessage(block.Body, entityList, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := ioutil.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// signMessage создает отсоединенную armor-подпись данных закрытым ключом
+func signMessage(privateKey string, data []byte) (string, error) {
+	entityList, err := openpgp.ReadArmoredKeyRing(strings.NewReader(privateKey))
+	if err != nil {
+		return "", err
+	}
+	if len(entityList) == 0 {
+		return "", ErrKeysNotFound
+	}
+
+	var buf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&buf, entityList[0], bytes.NewReader(data), nil); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// verifySignature проверяет отсоединенную armor-подпись данных открытым ключом
+func verifySignature(publicKey string, data []byte, signature string) error {
+	entityList, err := openpgp.ReadArmoredKeyRing(strings.NewReader(publicKey))
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("Public key:")
-	fmt.Println(publicKey)
-	fmt.Println("Private key:")
-	fmt.Println(privateKey)
+	_, err = openpgp.CheckArmoredDetachedSignature(entityList, bytes.NewReader(data), strings.NewReader(signature), nil)
+	return err
 }
@@ -1,68 +1,20 @@
 package security
 
 import (
-	"bytes"
 	"crypto/hmac"
+	cryptorand "crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
-	"io/ioutil"
+	"math/big"
 	"math/rand"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/ProtonMail/go-crypto/openpgp"
-	"github.com/ProtonMail/go-crypto/openpgp/armor"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// EncryptData шифрует данные с использованием PGP.
-func EncryptData(data string) (string, error) {
-	// Загрузка публичного ключа из файла
-	publicKeyFile := "public_key.asc"
-
-	// Проверка существования файла с публичным ключом
-	if _, err := os.Stat(publicKeyFile); os.IsNotExist(err) {
-		fmt.Println("Отсутствует ключ, генерируем его")
-		MainGenerateKeyPair() // Генерация ключа
-	}
-
-	// Чтение публичного ключа из файла
-	publicKey, err := ioutil.ReadFile(publicKeyFile)
-	if err != nil {
-		return "", fmt.Errorf("error reading public key file: %v", err)
-	}
-
-	entityList, err := openpgp.ReadArmoredKeyRing(strings.NewReader(string(publicKey)))
-	if err != nil {
-		return "", err
-	}
-
-	var buf bytes.Buffer
-	writer, err := armor.Encode(&buf, "PGP MESSAGE", nil)
-	if err != nil {
-		return "", err
-	}
-
-	plaintext, err := openpgp.Encrypt(writer, entityList, nil, nil, nil)
-	if err != nil {
-		return "", err
-	}
-	defer plaintext.Close()
-
-	if _, err := plaintext.Write([]byte(data)); err != nil {
-		return "", err
-	}
-
-	if err := writer.Close(); err != nil {
-		return "", err
-	}
-
-	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
-}
-
 // GenerateHMAC генерирует HMAC для данных.
 func GenerateHMAC(data string, secret []byte) string {
 	h := hmac.New(sha256.New, secret)
@@ -76,11 +28,12 @@ func GenerateCVV() string {
 	return fmt.Sprintf("%d", cvv)
 }
 
-func GenerateExpiryDate() string {
+// GenerateExpiryDate генерирует срок действия карты через validityYears лет
+func GenerateExpiryDate(validityYears int) string {
 	currentTime := time.Now()
 	// Получаем текущий месяц и год
 	month := int(currentTime.Month())
-	year := currentTime.Year() + 5 // Добавляем 5 лет к текущему году
+	year := currentTime.Year() + validityYears // Добавляем срок действия к текущему году
 
 	// Форматируем как MM/YY, где MM - 01-12, YY - последние две цифры года
 	return fmt.Sprintf("%02d/%02d", month, year%100) // Форматируем как MM/YY
@@ -95,6 +48,35 @@ func HashCVV(cvv string) (string, error) {
 	return string(hashedCVV), nil
 }
 
+// CheckCVV сравнивает CVV с bcrypt-хешем
+func CheckCVV(cvv, hashedCVV string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hashedCVV), []byte(cvv)) == nil
+}
+
+// GenerateOTP генерирует одноразовый код из digits цифр криптографически стойким генератором
+func GenerateOTP(digits int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := cryptorand.Int(cryptorand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+// HashOTP хеширует одноразовый код с использованием bcrypt
+func HashOTP(otp string) (string, error) {
+	hashedOTP, err := bcrypt.GenerateFromPassword([]byte(otp), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedOTP), nil
+}
+
+// CheckOTP сравнивает одноразовый код с bcrypt-хешем
+func CheckOTP(otp, hashedOTP string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hashedOTP), []byte(otp)) == nil
+}
+
 // Генерация валидного номера карты
 func GenerateCardNumber(prefix string, length int) string {
 	var cardNumber strings.Builder
@@ -39,6 +39,10 @@ func AccountServiceInstance(accountRepo repository.AccountRepository, transactio
 // Базовые операции со счетом
 func (s *accountService) CreateAccount(account *model.Account, userID uint) error {
 	fmt.Println("Creating account for user ID:", userID)
+	// Кредитный счет открывается банком вместе с кредитной линией
+	if account.IsCredit() || account.CreditLimit != 0 {
+		return fmt.Errorf("could not create account: %w", model.ErrInvalidAccountType)
+	}
 	account.UserID = userID
 	if err := s.accountRepo.Create(context.Background(), account); err != nil {
 		return fmt.Errorf("could not create account: %v", err)
@@ -122,7 +126,7 @@ func (s *accountService) Withdraw(accountID uint, amount float64, description st
 		return fmt.Errorf("failed to get account: %v", err)
 	}
 
-	if account.Balance < amount {
+	if account.AvailableBalance() < amount {
 		return errors.New("insufficient funds")
 	}
 
@@ -167,7 +171,7 @@ func (s *accountService) Transfer(fromAccountID, toAccountID uint, amount float6
 		return fmt.Errorf("failed to get destination account: %v", err)
 	}
 
-	if fromAccount.Balance < amount {
+	if fromAccount.AvailableBalance() < amount {
 		return errors.New("insufficient funds")
 	}
 
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AutoDebitService списание платежей по кредитам с наступившим сроком: со счета кредита,
+// а при нехватке средств — с резервных счетов заемщика в порядке приоритета, в том числе частично
+type AutoDebitService interface {
+	AuthorizeAccount(userID uint, creditID uint, accountID uint, priority int) (*model.AutoDebitAccount, error)
+	RevokeAccount(userID uint, creditID uint, authorizationID uint) error
+	GetAccounts(userID uint, creditID uint) ([]model.AutoDebitAccount, error)
+	GetAttempts(userID uint, creditID uint) ([]model.AutoDebitAttempt, error)
+	CollectPayment(credit *model.Credit, schedule []model.PaymentSchedule, payment *model.PaymentSchedule, now time.Time) ([]model.AutoDebitAttempt, error)
+}
+
+type autoDebitService struct {
+	autoDebitRepo   repository.AutoDebitRepository
+	creditRepo      repository.CreditRepository
+	accountRepo     repository.AccountRepository
+	transactionRepo repository.TransactionRepository
+}
+
+func AutoDebitServiceInstance(
+	autoDebitRepo repository.AutoDebitRepository,
+	creditRepo repository.CreditRepository,
+	accountRepo repository.AccountRepository,
+	transactionRepo repository.TransactionRepository,
+) AutoDebitService {
+	return &autoDebitService{
+		autoDebitRepo:   autoDebitRepo,
+		creditRepo:      creditRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+// AuthorizeAccount разрешает списывать платежи по кредиту с другого счета заемщика.
+// Резервным счетом может быть только собственный некредитный счет, отличный от счета кредита.
+func (s *autoDebitService) AuthorizeAccount(userID uint, creditID uint, accountID uint, priority int) (*model.AutoDebitAccount, error) {
+	credit, err := s.getUserCredit(userID, creditID)
+	if err != nil {
+		return nil, err
+	}
+	if credit.Status == model.CreditStatusPaid || credit.Status == model.CreditStatusCancelled {
+		return nil, model.ErrCreditNotActive
+	}
+
+	account, err := s.accountRepo.GetByID(context.Background(), accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.UserID != userID {
+		return nil, ErrAccountNotOwned
+	}
+	if account.ID == credit.AccountID || account.IsCredit() {
+		return nil, model.ErrInvalidAutoDebitAccount
+	}
+
+	authorized, err := s.autoDebitRepo.GetByCreditID(context.Background(), credit.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auto debit accounts: %v", err)
+	}
+	for _, existing := range authorized {
+		if existing.AccountID == account.ID {
+			return nil, model.ErrAutoDebitAccountExists
+		}
+	}
+
+	authorization := &model.AutoDebitAccount{
+		CreditID:  credit.ID,
+		UserID:    userID,
+		AccountID: account.ID,
+		Priority:  priority,
+	}
+	if err := authorization.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.autoDebitRepo.Create(context.Background(), authorization); err != nil {
+		return nil, fmt.Errorf("failed to authorize auto debit account: %v", err)
+	}
+
+	return authorization, nil
+}
+
+// RevokeAccount отзывает разрешение на списание с резервного счета
+func (s *autoDebitService) RevokeAccount(userID uint, creditID uint, authorizationID uint) error {
+	credit, err := s.getUserCredit(userID, creditID)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := s.autoDebitRepo.GetByID(context.Background(), authorizationID)
+	if err != nil {
+		return err
+	}
+	if authorization.CreditID != credit.ID {
+		return repository.ErrNotFound
+	}
+
+	return s.autoDebitRepo.Delete(context.Background(), authorization.ID)
+}
+
+// GetAccounts возвращает резервные счета кредита в порядке приоритета
+func (s *autoDebitService) GetAccounts(userID uint, creditID uint) ([]model.AutoDebitAccount, error) {
+	credit, err := s.getUserCredit(userID, creditID)
+	if err != nil {
+		return nil, err
+	}
+	return s.autoDebitRepo.GetByCreditID(context.Background(), credit.ID)
+}
+
+// GetAttempts возвращает попытки списания платежей по кредиту
+func (s *autoDebitService) GetAttempts(userID uint, creditID uint) ([]model.AutoDebitAttempt, error) {
+	credit, err := s.getUserCredit(userID, creditID)
+	if err != nil {
+		return nil, err
+	}
+	return s.autoDebitRepo.GetAttempts(context.Background(), credit.ID)
+}
+
+// CollectPayment списывает платеж с наступившим сроком: сначала со счета кредита, затем с резервных
+// счетов по приоритету, с каждого — сколько есть на счете, пока платеж не погашен. Каждая попытка
+// сохраняется. Кредит обновляется по графику после списания.
+func (s *autoDebitService) CollectPayment(credit *model.Credit, schedule []model.PaymentSchedule, payment *model.PaymentSchedule, now time.Time) ([]model.AutoDebitAttempt, error) {
+	fallbacks, err := s.autoDebitRepo.GetByCreditID(context.Background(), credit.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auto debit accounts: %v", err)
+	}
+	accountIDs := []uint{credit.AccountID}
+	for _, fallback := range fallbacks {
+		accountIDs = append(accountIDs, fallback.AccountID)
+	}
+
+	var attempts []model.AutoDebitAttempt
+	debited := false
+	for i, accountID := range accountIDs {
+		if payment.IsPaid() {
+			break
+		}
+		attempt, err := s.debit(credit, payment, accountID, i > 0, now)
+		if err != nil {
+			return attempts, err
+		}
+		attempts = append(attempts, *attempt)
+		debited = debited || attempt.Debited > 0
+	}
+
+	if !debited {
+		return attempts, nil
+	}
+
+	credit.LastPayment = now
+	credit.ApplySchedule(schedule, now)
+	if err := s.creditRepo.Update(context.Background(), credit); err != nil {
+		return attempts, fmt.Errorf("failed to update credit: %v", err)
+	}
+
+	return attempts, nil
+}
+
+// debit списывает в погашение платежа доступный остаток счета и сохраняет попытку.
+// Остаток списывается условным UPDATE ... WHERE balance >= суммы в одной транзакции базы данных
+// с платежом графика, транзакцией и попыткой. Если остаток успели потратить параллельно,
+// попытка сохраняется неуспешной.
+func (s *autoDebitService) debit(credit *model.Credit, payment *model.PaymentSchedule, accountID uint, fallback bool, now time.Time) (*model.AutoDebitAttempt, error) {
+	account, err := s.accountRepo.GetByID(context.Background(), accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %v", err)
+	}
+
+	due := payment.AmountDue()
+	attempt := &model.AutoDebitAttempt{
+		CreditID:      credit.ID,
+		UserID:        credit.UserID,
+		PaymentNumber: payment.PaymentNumber,
+		AccountID:     account.ID,
+		Fallback:      fallback,
+		Requested:     due,
+		Debited:       account.DebitAmount(due),
+		Status:        model.AutoDebitStatusFailed,
+	}
+
+	if attempt.Debited > 0 {
+		// Платеж меняется в копии, чтобы при откате транзакции график в памяти остался прежним
+		paid := *payment
+		err := s.accountRepo.InTransaction(context.Background(), func(ctx context.Context) error {
+			debited, err := s.accountRepo.DebitBalance(ctx, account.ID, attempt.Debited)
+			if err != nil {
+				return fmt.Errorf("failed to update account balance: %v", err)
+			}
+			if !debited {
+				return model.ErrInsufficientFunds
+			}
+
+			// Распределяем списанную сумму: неустойка, проценты, основной долг
+			allocation := paid.ApplyPayment(attempt.Debited, now)
+			if err := s.creditRepo.UpdatePaymentSchedule(ctx, &paid); err != nil {
+				return fmt.Errorf("failed to update payment schedule: %v", err)
+			}
+
+			description := fmt.Sprintf("Платеж по кредиту #%d, платеж #%d: неустойка %.2f, проценты %.2f, основной долг %.2f",
+				credit.ID, payment.PaymentNumber, allocation.Penalty, allocation.Interest, allocation.Principal)
+			if fallback {
+				description = fmt.Sprintf("Списание с резервного счета. %s", description)
+			}
+			transaction := &model.Transaction{
+				Type:          model.TransactionTypePayment,
+				FromAccountID: account.ID,
+				Amount:        attempt.Debited,
+				Description:   description,
+				Status:        model.TransactionStatusCompleted,
+			}
+			if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+				return fmt.Errorf("failed to create transaction: %v", err)
+			}
+
+			attempt.TransactionID = &transaction.ID
+			attempt.Status = model.AutoDebitStatusPartial
+			if paid.IsPaid() {
+				attempt.Status = model.AutoDebitStatusSuccess
+			}
+			if err := s.autoDebitRepo.AddAttempt(ctx, attempt); err != nil {
+				return fmt.Errorf("failed to record auto debit attempt: %v", err)
+			}
+			return nil
+		})
+		if err == nil {
+			*payment = paid
+			credit.TotalPaid += attempt.Debited
+			return attempt, nil
+		}
+		if !errors.Is(err, model.ErrInsufficientFunds) {
+			return nil, err
+		}
+		attempt.Debited = 0
+		attempt.TransactionID = nil
+		attempt.Status = model.AutoDebitStatusFailed
+	}
+
+	if err := s.autoDebitRepo.AddAttempt(context.Background(), attempt); err != nil {
+		return nil, fmt.Errorf("failed to record auto debit attempt: %v", err)
+	}
+
+	return attempt, nil
+}
+
+// getUserCredit получает кредит пользователя
+func (s *autoDebitService) getUserCredit(userID uint, creditID uint) (*model.Credit, error) {
+	credit, err := s.creditRepo.GetByID(context.Background(), creditID)
+	if err != nil {
+		return nil, err
+	}
+	if credit.UserID != userID {
+		return nil, ErrCreditNotOwned
+	}
+	return credit, nil
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"FinanceGolang/src/security"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// otpDigits длина одноразового кода подтверждения
+const otpDigits = 6
+
+// ErrChallengeNotFound подтверждение платежа не найдено или принадлежит другому пользователю
+var ErrChallengeNotFound = errors.New("payment challenge not found")
+
+// CardNotPresentPayment онлайн-платеж по реквизитам карты
+type CardNotPresentPayment struct {
+	PAN        string
+	ExpiryDate string // MM/YY
+	CVV        string
+	Payment    model.CardPayment
+}
+
+// CardNotPresentResult результат онлайн-платежа.
+// Если ChallengeRequired, транзакция ждет подтверждения кодом до ChallengeExpiresAt.
+type CardNotPresentResult struct {
+	Transaction        *model.Transaction
+	DeclineReason      model.DeclineReason
+	ChallengeRequired  bool
+	ChallengeExpiresAt *time.Time
+}
+
+// CardNotPresentService проводит онлайн-платежи по реквизитам карты
+// с подтверждением одноразовым кодом для сумм выше порога
+type CardNotPresentService interface {
+	Pay(userID uint, req *CardNotPresentPayment) (*CardNotPresentResult, error)
+	Confirm(userID, transactionID uint, otp string) (*CardNotPresentResult, error)
+	ExpireChallenges() error
+}
+
+type cardNotPresentService struct {
+	cardService        CardService
+	accountRepo        repository.AccountRepository
+	transactionRepo    repository.TransactionRepository
+	challengeRepo      repository.PaymentChallengeRepository
+	userRepo           repository.UserRepository
+	notifier           Notifier
+	challengeThreshold float64
+	otpTTL             time.Duration
+}
+
+func CardNotPresentServiceInstance(
+	cardService CardService,
+	accountRepo repository.AccountRepository,
+	transactionRepo repository.TransactionRepository,
+	challengeRepo repository.PaymentChallengeRepository,
+	userRepo repository.UserRepository,
+	notifier Notifier,
+	challengeThreshold float64,
+	otpTTL time.Duration,
+) CardNotPresentService {
+	return &cardNotPresentService{
+		cardService:        cardService,
+		accountRepo:        accountRepo,
+		transactionRepo:    transactionRepo,
+		challengeRepo:      challengeRepo,
+		userRepo:           userRepo,
+		notifier:           notifier,
+		challengeThreshold: challengeThreshold,
+		otpTTL:             otpTTL,
+	}
+}
+
+// Pay проверяет реквизиты карты и проводит платеж. Суммы не выше порога списываются сразу,
+// для остальных создается ожидающая транзакция и клиенту отправляется одноразовый код.
+func (s *cardNotPresentService) Pay(userID uint, req *CardNotPresentPayment) (*CardNotPresentResult, error) {
+	req.Payment.Channel = model.CardChannelOnline
+	if err := req.Payment.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Просроченные подтверждения резервируют лимиты карты, закрываем их перед проверкой
+	if err := s.ExpireChallenges(); err != nil {
+		return nil, err
+	}
+
+	card, err := s.cardService.GetCardByNumber(req.PAN)
+	if errors.Is(err, repository.ErrNotFound) {
+		return &CardNotPresentResult{DeclineReason: model.DeclineCardNotFound}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get card: %v", err)
+	}
+	if card.UserID != userID {
+		return nil, ErrCardNotOwned
+	}
+
+	reason, err := s.cardService.VerifyCardData(card, req.ExpiryDate, req.CVV)
+	if err != nil {
+		return nil, err
+	}
+	if reason != model.DeclineNone {
+		transaction, err := newCardTransaction(card, &req.Payment)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.saveDeclined(transaction, reason); err != nil {
+			return nil, err
+		}
+		return &CardNotPresentResult{Transaction: transaction, DeclineReason: reason}, nil
+	}
+
+	if req.Payment.Amount <= s.challengeThreshold {
+		transaction, reason, err := s.cardService.ProcessCardPayment(userID, card.ID, &req.Payment)
+		if err != nil {
+			return nil, err
+		}
+		return &CardNotPresentResult{Transaction: transaction, DeclineReason: reason}, nil
+	}
+
+	// В сообщении указываем только последние цифры номера: в карте номер хранится зашифрованным
+	return s.startChallenge(card, &req.Payment, "*"+req.PAN[len(req.PAN)-4:])
+}
+
+// startChallenge проверяет платеж и создает ожидающую транзакцию с одноразовым кодом.
+// Ожидающая транзакция резервирует лимиты карты до подтверждения или истечения кода.
+func (s *cardNotPresentService) startChallenge(card *model.Card, payment *model.CardPayment, maskedPAN string) (*CardNotPresentResult, error) {
+	transaction, err := newCardTransaction(card, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	reason, err := s.cardService.AuthorizePayment(card, payment)
+	if err != nil {
+		return nil, err
+	}
+	if reason != model.DeclineNone {
+		if err := s.saveDeclined(transaction, reason); err != nil {
+			return nil, err
+		}
+		return &CardNotPresentResult{Transaction: transaction, DeclineReason: reason}, nil
+	}
+
+	user, err := s.userRepo.GetByID(context.Background(), card.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+
+	otp, err := security.GenerateOTP(otpDigits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate OTP: %v", err)
+	}
+	otpHash, err := security.HashOTP(otp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash OTP: %v", err)
+	}
+
+	if err := s.transactionRepo.Create(context.Background(), transaction); err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %v", err)
+	}
+
+	challenge := &model.PaymentChallenge{
+		TransactionID: transaction.ID,
+		CardID:        card.ID,
+		UserID:        card.UserID,
+		OTPHash:       otpHash,
+		ExpiresAt:     time.Now().Add(s.otpTTL),
+		Status:        model.PaymentChallengePending,
+	}
+	if err := s.challengeRepo.Create(context.Background(), challenge); err != nil {
+		return nil, fmt.Errorf("failed to save payment challenge: %v", err)
+	}
+
+	body := fmt.Sprintf("Код подтверждения оплаты %.2f ₽ картой %s: %s. Никому не сообщайте код.",
+		payment.Amount, maskedPAN, otp)
+	if err := s.notifier.Notify(user.Email, "Подтверждение платежа", body); err != nil {
+		// Без доставленного кода платеж подтвердить нельзя, закрываем его сразу
+		challenge.Status = model.PaymentChallengeFailed
+		if updateErr := s.challengeRepo.Update(context.Background(), challenge); updateErr != nil {
+			return nil, fmt.Errorf("failed to update payment challenge: %v", updateErr)
+		}
+		if failErr := s.failTransaction(transaction, model.DeclineChallengeFailed); failErr != nil {
+			return nil, failErr
+		}
+		return nil, fmt.Errorf("failed to send OTP: %v", err)
+	}
+
+	return &CardNotPresentResult{
+		Transaction:        transaction,
+		ChallengeRequired:  true,
+		ChallengeExpiresAt: &challenge.ExpiresAt,
+	}, nil
+}
+
+// Confirm проверяет одноразовый код и списывает средства по ожидающей транзакции.
+// Истекший код или исчерпанные попытки завершают транзакцию отказом.
+func (s *cardNotPresentService) Confirm(userID, transactionID uint, otp string) (*CardNotPresentResult, error) {
+	challenge, err := s.challengeRepo.GetByTransactionID(context.Background(), transactionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrChallengeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get payment challenge: %v", err)
+	}
+	if challenge.UserID != userID {
+		return nil, ErrChallengeNotFound
+	}
+	if challenge.Status != model.PaymentChallengePending {
+		return nil, model.ErrChallengeNotPending
+	}
+
+	transaction, err := s.transactionRepo.GetByID(context.Background(), transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get transaction: %v", err)
+	}
+
+	if challenge.IsExpired() {
+		if err := s.expireChallenge(challenge, transaction); err != nil {
+			return nil, err
+		}
+		return &CardNotPresentResult{Transaction: transaction, DeclineReason: model.DeclineChallengeExpired}, nil
+	}
+
+	if !security.CheckOTP(otp, challenge.OTPHash) {
+		registered, err := s.challengeRepo.RegisterFailedAttempt(context.Background(), challenge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update payment challenge: %v", err)
+		}
+		if !registered {
+			return nil, model.ErrChallengeNotPending
+		}
+		if challenge.Status == model.PaymentChallengeFailed {
+			if err := s.failTransaction(transaction, model.DeclineChallengeFailed); err != nil {
+				return nil, err
+			}
+			return &CardNotPresentResult{Transaction: transaction, DeclineReason: model.DeclineChallengeFailed}, nil
+		}
+		return nil, fmt.Errorf("%w: %d attempts left", model.ErrInvalidOTP, challenge.AttemptsLeft())
+	}
+
+	reason, err := s.completePayment(challenge, transaction)
+	if err != nil {
+		return nil, err
+	}
+	return &CardNotPresentResult{Transaction: transaction, DeclineReason: reason}, nil
+}
+
+// completePayment закрывает подтверждение и списывает средства по транзакции.
+// Лимиты уже зарезервированы ожидающей транзакцией, повторно проверяются карта и баланс.
+// Подтверждение, списание и проведение транзакции сохраняются одной транзакцией базы данных:
+// при ошибке код остается неиспользованным, а параллельный запрос получает ErrChallengeNotPending.
+func (s *cardNotPresentService) completePayment(challenge *model.PaymentChallenge, transaction *model.Transaction) (model.DeclineReason, error) {
+	card, err := s.cardService.GetCardByID(challenge.CardID)
+	if err != nil {
+		return model.DeclineNone, fmt.Errorf("could not get card: %v", err)
+	}
+
+	reason := model.DeclineNone
+	switch err := card.CanPay(transaction.Amount); {
+	case errors.Is(err, model.ErrCardNotActive):
+		reason = model.DeclineCardInactive
+	case errors.Is(err, model.ErrCardExpired):
+		reason = model.DeclineCardExpired
+	case errors.Is(err, model.ErrCardCapExceeded):
+		reason = model.DeclineAmountCapExceeded
+	case err != nil:
+		return model.DeclineNone, err
+	}
+
+	if reason == model.DeclineNone {
+		account, err := s.accountRepo.GetByID(context.Background(), card.AccountID)
+		if err != nil {
+			return model.DeclineNone, fmt.Errorf("failed to get account: %v", err)
+		}
+		if account.AvailableBalance() < transaction.Amount {
+			reason = model.DeclineInsufficientFunds
+		}
+	}
+
+	if reason != model.DeclineNone {
+		return reason, s.declinePayment(challenge, transaction, reason)
+	}
+
+	transaction.Complete()
+	confirmed, err := s.challengeRepo.ConfirmPayment(context.Background(), challenge, card.AccountID, transaction)
+	if errors.Is(err, model.ErrInsufficientFunds) {
+		// Баланс уменьшился после проверки, списание не прошло и ничего не сохранено
+		challenge.Status = model.PaymentChallengePending
+		transaction.CompletedAt = nil
+		return model.DeclineInsufficientFunds, s.declinePayment(challenge, transaction, model.DeclineInsufficientFunds)
+	}
+	if err != nil {
+		return model.DeclineNone, fmt.Errorf("failed to confirm payment: %v", err)
+	}
+	if !confirmed {
+		return model.DeclineNone, model.ErrChallengeNotPending
+	}
+	if err := s.cardService.RecordPayment(context.Background(), card, transaction.Amount); err != nil {
+		return model.DeclineNone, err
+	}
+
+	return model.DeclineNone, nil
+}
+
+// declinePayment закрывает подтверждение статусом FAILED, когда верный код введен,
+// но платеж отклонен, и завершает транзакцию отказом
+func (s *cardNotPresentService) declinePayment(challenge *model.PaymentChallenge, transaction *model.Transaction, reason model.DeclineReason) error {
+	challenge.Status = model.PaymentChallengeFailed
+	if err := s.updatePending(challenge); err != nil {
+		return err
+	}
+	return s.failTransaction(transaction, reason)
+}
+
+// ExpireChallenges завершает отказом транзакции, код по которым не введен вовремя
+func (s *cardNotPresentService) ExpireChallenges() error {
+	challenges, err := s.challengeRepo.GetExpiredPending(context.Background(), time.Now())
+	if err != nil {
+		return fmt.Errorf("could not get expired payment challenges: %v", err)
+	}
+
+	for i := range challenges {
+		transaction, err := s.transactionRepo.GetByID(context.Background(), challenges[i].TransactionID)
+		if err != nil {
+			return fmt.Errorf("could not get transaction: %v", err)
+		}
+		// Подтверждение, закрытое параллельным запросом, пропускаем
+		err = s.expireChallenge(&challenges[i], transaction)
+		if err != nil && !errors.Is(err, model.ErrChallengeNotPending) {
+			return err
+		}
+	}
+	return nil
+}
+
+// expireChallenge закрывает подтверждение по истечении срока кода
+func (s *cardNotPresentService) expireChallenge(challenge *model.PaymentChallenge, transaction *model.Transaction) error {
+	challenge.Status = model.PaymentChallengeExpired
+	if err := s.updatePending(challenge); err != nil {
+		return err
+	}
+	return s.failTransaction(transaction, model.DeclineChallengeExpired)
+}
+
+// updatePending сохраняет подтверждение, если его не закрыл параллельный запрос
+func (s *cardNotPresentService) updatePending(challenge *model.PaymentChallenge) error {
+	updated, err := s.challengeRepo.UpdatePending(context.Background(), challenge)
+	if err != nil {
+		return fmt.Errorf("failed to update payment challenge: %v", err)
+	}
+	if !updated {
+		return model.ErrChallengeNotPending
+	}
+	return nil
+}
+
+// saveDeclined сохраняет новую транзакцию, отклоненную с кодом причины отказа
+func (s *cardNotPresentService) saveDeclined(transaction *model.Transaction, reason model.DeclineReason) error {
+	transaction.Fail(errors.New(string(reason)))
+	if err := s.transactionRepo.Create(context.Background(), transaction); err != nil {
+		return fmt.Errorf("failed to create transaction: %v", err)
+	}
+	return nil
+}
+
+// failTransaction завершает ожидающую транзакцию отказом с кодом причины
+func (s *cardNotPresentService) failTransaction(transaction *model.Transaction, reason model.DeclineReason) error {
+	transaction.Fail(errors.New(string(reason)))
+	if err := s.transactionRepo.Update(context.Background(), transaction); err != nil {
+		return fmt.Errorf("failed to update transaction: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// CardOperation операция по карте, поступившая от терминала или эквайера
+type CardOperation struct {
+	PAN        string
+	ExpiryDate string // MM/YY, пустое значение не проверяется
+	CVV        string // пустое значение не проверяется
+	TerminalID string
+	RRN        string
+	STAN       string
+	Payment    model.CardPayment
+}
+
+// CardProcessingService проводит операции по картам от внешних терминалов:
+// блокировку средств, списание и отмену
+type CardProcessingService interface {
+	Authorize(op *CardOperation) (*model.CardAuthorization, model.DeclineReason, error)
+	Capture(op *CardOperation) (*model.CardAuthorization, model.DeclineReason, error)
+	Reverse(terminalID, rrn string) (*model.CardAuthorization, error)
+}
+
+type cardProcessingService struct {
+	cardService     CardService
+	cardRepo        repository.CardRepository
+	accountRepo     repository.AccountRepository
+	transactionRepo repository.TransactionRepository
+	authRepo        repository.CardAuthorizationRepository
+}
+
+func CardProcessingServiceInstance(
+	cardService CardService,
+	cardRepo repository.CardRepository,
+	accountRepo repository.AccountRepository,
+	transactionRepo repository.TransactionRepository,
+	authRepo repository.CardAuthorizationRepository,
+) CardProcessingService {
+	return &cardProcessingService{
+		cardService:     cardService,
+		cardRepo:        cardRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		authRepo:        authRepo,
+	}
+}
+
+// Authorize проверяет карту и блокирует сумму операции на связанном счете.
+// Заблокированные средства списываются со счета сразу и возвращаются при отмене.
+func (s *cardProcessingService) Authorize(op *CardOperation) (*model.CardAuthorization, model.DeclineReason, error) {
+	if err := op.Payment.Validate(); err != nil {
+		return nil, model.DeclineNone, err
+	}
+	if op.TerminalID == "" || op.RRN == "" {
+		return nil, model.DeclineNone, errors.New("terminal id and RRN are required")
+	}
+
+	// Повторный запрос с тем же RRN не должен блокировать средства второй раз
+	_, err := s.authRepo.GetByRRN(context.Background(), op.TerminalID, op.RRN)
+	if err == nil {
+		return nil, model.DeclineNone, model.ErrDuplicateAuthorization
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, model.DeclineNone, fmt.Errorf("could not check authorization: %v", err)
+	}
+
+	card, err := s.cardService.GetCardByNumber(op.PAN)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, model.DeclineCardNotFound, nil
+	}
+	if err != nil {
+		return nil, model.DeclineNone, fmt.Errorf("could not get card: %v", err)
+	}
+
+	transaction, err := newCardTransaction(card, &op.Payment)
+	if err != nil {
+		return nil, model.DeclineNone, err
+	}
+
+	reason, err := s.cardService.VerifyCardData(card, op.ExpiryDate, op.CVV)
+	if err != nil {
+		return nil, model.DeclineNone, err
+	}
+	if reason == model.DeclineNone {
+		reason, err = s.cardService.AuthorizePayment(card, &op.Payment)
+		if err != nil {
+			return nil, model.DeclineNone, err
+		}
+	}
+	if reason != model.DeclineNone {
+		transaction.Fail(errors.New(string(reason)))
+		if err := s.transactionRepo.Create(context.Background(), transaction); err != nil {
+			return nil, reason, fmt.Errorf("failed to create transaction: %v", err)
+		}
+		return nil, reason, nil
+	}
+
+	authorization := &model.CardAuthorization{
+		CardID:     card.ID,
+		AccountID:  card.AccountID,
+		TerminalID: op.TerminalID,
+		RRN:        op.RRN,
+		STAN:       op.STAN,
+		AuthCode:   fmt.Sprintf("%06d", rand.Intn(1000000)),
+		Amount:     op.Payment.Amount,
+		Status:     model.CardAuthorizationHeld,
+	}
+	err = s.authRepo.InTransaction(context.Background(), func(ctx context.Context) error {
+		// Блокируем средства: транзакция остается в статусе PENDING до списания
+		if err := s.accountRepo.UpdateBalance(ctx, card.AccountID, -op.Payment.Amount); err != nil {
+			return fmt.Errorf("failed to hold funds: %v", err)
+		}
+		if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to create transaction: %v", err)
+		}
+
+		authorization.TransactionID = transaction.ID
+		if err := s.authRepo.Create(ctx, authorization); err != nil {
+			// Параллельный запрос с тем же RRN успел сохранить авторизацию первым
+			if errors.Is(err, repository.ErrAlreadyExists) {
+				return model.ErrDuplicateAuthorization
+			}
+			return fmt.Errorf("failed to save authorization: %v", err)
+		}
+
+		// Сумма учитывается в тратах карты сразу, чтобы одноразовую карту нельзя было
+		// авторизовать повторно до списания
+		return s.cardService.RecordPayment(ctx, card, op.Payment.Amount)
+	})
+	if err != nil {
+		return nil, model.DeclineNone, err
+	}
+
+	return authorization, model.DeclineNone, nil
+}
+
+// Capture списывает средства по операции. Если по RRN есть заблокированная авторизация,
+// списывается сумма не больше заблокированной, остаток возвращается на счет.
+// Иначе операция авторизуется и списывается сразу (одностадийная оплата).
+func (s *cardProcessingService) Capture(op *CardOperation) (*model.CardAuthorization, model.DeclineReason, error) {
+	authorization, err := s.authRepo.GetByRRN(context.Background(), op.TerminalID, op.RRN)
+	if errors.Is(err, repository.ErrNotFound) {
+		authorization, reason, err := s.Authorize(op)
+		if err != nil || reason != model.DeclineNone {
+			return nil, reason, err
+		}
+		return authorization, model.DeclineNone, s.capture(authorization, authorization.Amount)
+	}
+	if err != nil {
+		return nil, model.DeclineNone, fmt.Errorf("could not get authorization: %v", err)
+	}
+
+	if err := s.capture(authorization, op.Payment.Amount); err != nil {
+		return nil, model.DeclineNone, err
+	}
+	return authorization, model.DeclineNone, nil
+}
+
+// capture подтверждает заблокированную авторизацию. Авторизация переводится в CAPTURED
+// условным обновлением, поэтому параллельные списание и отмена не возвращают средства дважды.
+func (s *cardProcessingService) capture(authorization *model.CardAuthorization, amount float64) error {
+	released, err := authorization.Capture(amount)
+	if err != nil {
+		return err
+	}
+
+	return s.authRepo.InTransaction(context.Background(), func(ctx context.Context) error {
+		captured, err := s.authRepo.UpdateFromStatus(ctx, authorization, model.CardAuthorizationHeld)
+		if err != nil {
+			return fmt.Errorf("failed to update authorization: %v", err)
+		}
+		if !captured {
+			return model.ErrAuthorizationNotHeld
+		}
+
+		transaction, err := s.transactionRepo.GetByID(ctx, authorization.TransactionID)
+		if err != nil {
+			return fmt.Errorf("could not get transaction: %v", err)
+		}
+
+		if released > 0 {
+			if err := s.accountRepo.UpdateBalance(ctx, authorization.AccountID, released); err != nil {
+				return fmt.Errorf("failed to release funds: %v", err)
+			}
+			if err := s.adjustCardSpending(ctx, authorization.CardID, -released); err != nil {
+				return err
+			}
+		}
+
+		transaction.Amount = amount
+		transaction.Complete()
+		if err := s.transactionRepo.Update(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to update transaction: %v", err)
+		}
+		return nil
+	})
+}
+
+// Reverse отменяет авторизацию или списание и возвращает средства на счет.
+// Авторизация переводится в REVERSED условным обновлением, и средства возвращаются
+// только тем запросом, который ее перевел: повторная отмена не меняет баланс.
+func (s *cardProcessingService) Reverse(terminalID, rrn string) (*model.CardAuthorization, error) {
+	authorization, err := s.authRepo.GetByRRN(context.Background(), terminalID, rrn)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, model.ErrAuthorizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get authorization: %v", err)
+	}
+	if authorization.Status == model.CardAuthorizationReversed {
+		return authorization, nil
+	}
+
+	authorization.Reverse()
+	err = s.authRepo.InTransaction(context.Background(), func(ctx context.Context) error {
+		reversed, err := s.authRepo.UpdateFromStatus(ctx, authorization, model.CardAuthorizationHeld, model.CardAuthorizationCaptured)
+		if err != nil {
+			return fmt.Errorf("failed to update authorization: %v", err)
+		}
+		if !reversed {
+			return nil
+		}
+
+		transaction, err := s.transactionRepo.GetByID(ctx, authorization.TransactionID)
+		if err != nil {
+			return fmt.Errorf("could not get transaction: %v", err)
+		}
+
+		if err := s.accountRepo.UpdateBalance(ctx, authorization.AccountID, authorization.Amount); err != nil {
+			return fmt.Errorf("failed to return funds: %v", err)
+		}
+		if err := s.adjustCardSpending(ctx, authorization.CardID, -authorization.Amount); err != nil {
+			return err
+		}
+
+		transaction.Cancel()
+		if err := s.transactionRepo.Update(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to update transaction: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return authorization, nil
+}
+
+// adjustCardSpending корректирует сумму трат карты при частичном списании и отмене.
+// Закрытая одноразовая карта при этом не открывается повторно.
+func (s *cardProcessingService) adjustCardSpending(ctx context.Context, cardID uint, amount float64) error {
+	card, err := s.cardRepo.GetByID(ctx, cardID)
+	if err != nil {
+		return fmt.Errorf("could not get card: %v", err)
+	}
+
+	card.SpentAmount += amount
+	if card.SpentAmount < 0 {
+		card.SpentAmount = 0
+	}
+	if err := s.cardRepo.UpdateUsage(ctx, card); err != nil {
+		return fmt.Errorf("failed to update card usage: %v", err)
+	}
+	return nil
+}
+
+// newCardTransaction создает транзакцию оплаты картой с параметрами операции в метаданных
+func newCardTransaction(card *model.Card, payment *model.CardPayment) (*model.Transaction, error) {
+	metadata, err := json.Marshal(map[string]interface{}{
+		"mcc":      payment.MCC,
+		"channel":  payment.Channel,
+		"country":  payment.Country,
+		"merchant": payment.Merchant,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payment metadata: %v", err)
+	}
+
+	return &model.Transaction{
+		Type:          model.TransactionTypePayment,
+		FromAccountID: card.AccountID,
+		CardID:        card.ID,
+		Amount:        payment.Amount,
+		Description:   payment.Description,
+		Metadata:      string(metadata),
+		Status:        model.TransactionStatusPending,
+	}, nil
+}
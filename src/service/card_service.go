@@ -6,34 +6,80 @@ import (
 	"FinanceGolang/src/repository"
 	"FinanceGolang/src/security"
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrCardNotOwned карта не принадлежит пользователю
+var ErrCardNotOwned = errors.New("card does not belong to the user")
+
+const (
+	// maxCardNumberAttempts ограничивает число попыток сгенерировать уникальный номер
+	maxCardNumberAttempts = 10
+	// maxVirtualCardDays максимальный срок действия виртуальной карты в днях
+	maxVirtualCardDays = 365
 )
 
 type CardService interface {
-	CreateCard(card *model.Card, userID uint) (*dto.UnsecureCard, error)
+	CreateCard(card *model.Card, productCode string, userID uint) (*dto.UnsecureCard, error)
+	CreateVirtualCard(userID, accountID uint, productCode string, singleUse bool, amountCap float64, expiryDays int) (*dto.UnsecureCard, error)
+	RecordPayment(ctx context.Context, card *model.Card, amount float64) error
+	ProcessCardPayment(userID, cardID uint, payment *model.CardPayment) (*model.Transaction, model.DeclineReason, error)
+	UpdateControls(userID, cardID uint, controls *model.CardControls) (*model.Card, error)
+	AuthorizePayment(card *model.Card, payment *model.CardPayment) (model.DeclineReason, error)
+	VerifyCardData(card *model.Card, expiryDate, cvv string) (model.DeclineReason, error)
 	GetCardByID(id uint) (*model.Card, error)
+	GetCardByNumber(number string) (*model.Card, error)
 	GetUserCards(userID uint) ([]model.Card, error)
+	BackfillNumberHashes() (int, error)
+	ReencryptCards(batchSize int) (int, int, error)
+	GetCardProducts() ([]model.CardProduct, error)
 }
 
 type cardService struct {
-	cardRepo    repository.CardRepository
-	accountRepo repository.AccountRepository
-	publicKey   string
-	hmacSecret  []byte
+	cardRepo        repository.CardRepository
+	accountRepo     repository.AccountRepository
+	productRepo     repository.CardProductRepository
+	transactionRepo repository.TransactionRepository
+	keys            *security.KeyManager
+	hmacSecret      []byte
 }
 
-func CardServiceInstance(cardRepo repository.CardRepository, accountRepo repository.AccountRepository, publicKey string, hmacSecret []byte) CardService {
+func CardServiceInstance(
+	cardRepo repository.CardRepository,
+	accountRepo repository.AccountRepository,
+	productRepo repository.CardProductRepository,
+	transactionRepo repository.TransactionRepository,
+	keys *security.KeyManager,
+	hmacSecret []byte,
+) CardService {
 	return &cardService{
-		cardRepo:    cardRepo,
-		accountRepo: accountRepo,
-		publicKey:   publicKey,
-		hmacSecret:  hmacSecret,
+		cardRepo:        cardRepo,
+		accountRepo:     accountRepo,
+		productRepo:     productRepo,
+		transactionRepo: transactionRepo,
+		keys:            keys,
+		hmacSecret:      hmacSecret,
 	}
 }
 
-func (s *cardService) CreateCard(card *model.Card, userID uint) (*dto.UnsecureCard, error) {
+func (s *cardService) CreateCard(card *model.Card, productCode string, userID uint) (*dto.UnsecureCard, error) {
+	// Находим карточный продукт
+	if productCode == "" {
+		productCode = model.DefaultCardProductCode
+	}
+	product, err := s.productRepo.GetByCode(context.Background(), productCode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", model.ErrCardProductNotFound, productCode)
+	}
+	if !product.IsActive {
+		return nil, model.ErrCardProductInactive
+	}
+
 	// Проверяем, что счет принадлежит пользователю
 	accounts, err := s.accountRepo.GetByUserID(context.Background(), userID)
 	if err != nil {
@@ -42,10 +88,12 @@ func (s *cardService) CreateCard(card *model.Card, userID uint) (*dto.UnsecureCa
 
 	accountExists := false
 	var accountName string
+	var accountType model.AccountType
 	for _, account := range accounts {
 		if account.ID == card.AccountID {
 			accountExists = true
 			accountName = account.Number
+			accountType = account.Type
 			break
 		}
 	}
@@ -54,14 +102,31 @@ func (s *cardService) CreateCard(card *model.Card, userID uint) (*dto.UnsecureCa
 		return nil, fmt.Errorf("account does not belong to the user")
 	}
 
+	// Кредитная карта расходует кредитный лимит и выпускается только к счету типа CREDIT
+	if product.FundingType == model.CardFundingCredit && accountType != model.AccountTypeCredit {
+		return nil, fmt.Errorf("%w: credit card requires a CREDIT account", model.ErrInvalidAccountType)
+	}
+
 	var unsecureCard dto.UnsecureCard
 
+	// Генерируем уникальный номер карты
+	number, numberHash, err := s.generateUniqueNumber(product)
+	if err != nil {
+		return nil, err
+	}
+
 	// Генерируем данные карты
-	unsecureCard.Number = security.GenerateCardNumber("4", 16)
+	unsecureCard.Number = number
 	unsecureCard.CVV = security.GenerateCVV()
-	unsecureCard.ExpiryDate = security.GenerateExpiryDate()
+	unsecureCard.ExpiryDate = security.GenerateExpiryDate(product.ValidityYears)
+	if card.ExpiresAt != nil {
+		// Для карт с коротким сроком действия печатаем месяц фактического окончания
+		unsecureCard.ExpiryDate = card.ExpiresAt.Format("01/06")
+	}
 	unsecureCard.AccountName = accountName
 	unsecureCard.AccountID = card.AccountID
+	unsecureCard.ProductCode = product.Code
+	unsecureCard.PaymentSystem = string(product.PaymentSystem)
 
 	// Проверка валидности номера карты
 	if !security.IsValidCardNumber(unsecureCard.Number) {
@@ -103,11 +168,11 @@ func (s *cardService) CreateCard(card *model.Card, userID uint) (*dto.UnsecureCa
 	}
 
 	// Шифрование номера карты и срока действия
-	encryptedNumber, err := security.EncryptData(unsecureCard.Number)
+	numberKeyID, encryptedNumber, err := s.keys.Encrypt(unsecureCard.Number)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt card number: %v", err)
 	}
-	encryptedExpiryDate, err := security.EncryptData(unsecureCard.ExpiryDate)
+	expiryKeyID, encryptedExpiryDate, err := s.keys.Encrypt(unsecureCard.ExpiryDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt expiry date: %v", err)
 	}
@@ -120,13 +185,24 @@ func (s *cardService) CreateCard(card *model.Card, userID uint) (*dto.UnsecureCa
 
 	// Сохранение зашифрованных данных в структуру
 	card.Number = encryptedNumber
+	card.NumberHash = numberHash
+	card.NumberKeyID = numberKeyID
 	card.ExpiryDate = encryptedExpiryDate
+	card.ExpiryKeyID = expiryKeyID
 	card.CVV = hashedCVV
 	card.CreatedAt = time.Now()
 	card.UserID = userID
 	card.AccountID = unsecureCard.AccountID
 	card.IsActive = true
 
+	// Параметры карты берем из продукта
+	card.ProductID = product.ID
+	card.PaymentSystem = product.PaymentSystem
+	card.FundingType = product.FundingType
+	card.Format = product.Format
+	card.DailyLimit = product.DailyLimit
+	card.MonthlyLimit = product.MonthlyLimit
+
 	// Сохранение карты в базе данных
 	if err := s.cardRepo.Create(context.Background(), card); err != nil {
 		return nil, fmt.Errorf("failed to save card: %v", err)
@@ -138,6 +214,213 @@ func (s *cardService) CreateCard(card *model.Card, userID uint) (*dto.UnsecureCa
 	return &unsecureCard, nil
 }
 
+// CreateVirtualCard мгновенно выпускает виртуальную карту к счету пользователя.
+// singleUse закрывает карту после первой оплаты, amountCap ограничивает сумму трат,
+// expiryDays задает срок действия в днях (0 - срок продукта).
+func (s *cardService) CreateVirtualCard(userID, accountID uint, productCode string, singleUse bool, amountCap float64, expiryDays int) (*dto.UnsecureCard, error) {
+	if productCode == "" {
+		productCode = model.VirtualCardProductCode
+	}
+	product, err := s.productRepo.GetByCode(context.Background(), productCode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", model.ErrCardProductNotFound, productCode)
+	}
+	if product.Format != model.CardFormatVirtual {
+		return nil, fmt.Errorf("card product %s is not virtual", productCode)
+	}
+	if amountCap < 0 {
+		return nil, errors.New("amount cap must not be negative")
+	}
+	if expiryDays < 0 || expiryDays > maxVirtualCardDays {
+		return nil, fmt.Errorf("expiry days must be between 0 and %d", maxVirtualCardDays)
+	}
+
+	card := &model.Card{
+		AccountID: accountID,
+		SingleUse: singleUse,
+		AmountCap: amountCap,
+	}
+	if expiryDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, expiryDays)
+		card.ExpiresAt = &expiresAt
+	}
+
+	unsecureCard, err := s.CreateCard(card, productCode, userID)
+	if err != nil {
+		return nil, err
+	}
+	unsecureCard.SingleUse = card.SingleUse
+	unsecureCard.AmountCap = card.AmountCap
+	unsecureCard.ExpiresAt = card.ExpiresAt
+
+	return unsecureCard, nil
+}
+
+// RecordPayment учитывает успешную оплату картой: сумму трат, дату использования
+// и закрытие одноразовой карты. ctx передает транзакцию, в которой проведено списание.
+func (s *cardService) RecordPayment(ctx context.Context, card *model.Card, amount float64) error {
+	card.RegisterPayment(amount)
+	if err := s.cardRepo.UpdateUsage(ctx, card); err != nil {
+		return fmt.Errorf("failed to update card usage: %v", err)
+	}
+	return nil
+}
+
+// ProcessCardPayment проводит оплату картой со списанием со связанного счета.
+// Если операция запрещена ограничениями карты, лимитами или балансом, сохраняется
+// неуспешная транзакция и возвращается код причины отказа.
+func (s *cardService) ProcessCardPayment(userID, cardID uint, payment *model.CardPayment) (*model.Transaction, model.DeclineReason, error) {
+	if err := payment.Validate(); err != nil {
+		return nil, model.DeclineNone, err
+	}
+
+	card, err := s.getUserCard(userID, cardID)
+	if err != nil {
+		return nil, model.DeclineNone, err
+	}
+
+	transaction, err := newCardTransaction(card, payment)
+	if err != nil {
+		return nil, model.DeclineNone, err
+	}
+
+	reason, err := s.AuthorizePayment(card, payment)
+	if err != nil {
+		return nil, model.DeclineNone, err
+	}
+	if reason != model.DeclineNone {
+		return s.declinePayment(transaction, reason)
+	}
+
+	// Списание, транзакция и учет трат по карте сохраняются вместе. Остаток проверяется
+	// в самом списании: параллельные оплаты не уводят счет за доступные средства.
+	err = s.accountRepo.InTransaction(context.Background(), func(ctx context.Context) error {
+		debited, err := s.accountRepo.Debit(ctx, card.AccountID, payment.Amount)
+		if err != nil {
+			return fmt.Errorf("failed to update account balance: %v", err)
+		}
+		if !debited {
+			return model.ErrInsufficientFunds
+		}
+		transaction.Complete()
+		if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to create transaction: %v", err)
+		}
+		return s.RecordPayment(ctx, card, payment.Amount)
+	})
+	if errors.Is(err, model.ErrInsufficientFunds) {
+		return s.declinePayment(transaction, model.DeclineInsufficientFunds)
+	}
+	if err != nil {
+		return nil, model.DeclineNone, err
+	}
+
+	return transaction, model.DeclineNone, nil
+}
+
+// declinePayment сохраняет операцию по карте как неуспешную с кодом причины отказа
+func (s *cardService) declinePayment(transaction *model.Transaction, reason model.DeclineReason) (*model.Transaction, model.DeclineReason, error) {
+	transaction.Fail(errors.New(string(reason)))
+	if err := s.transactionRepo.Create(context.Background(), transaction); err != nil {
+		return nil, reason, fmt.Errorf("failed to create transaction: %v", err)
+	}
+	return transaction, reason, nil
+}
+
+// AuthorizePayment проверяет операцию по ограничениям карты, лимитам и балансу счета
+func (s *cardService) AuthorizePayment(card *model.Card, payment *model.CardPayment) (model.DeclineReason, error) {
+	if reason := card.CheckControls(payment); reason != model.DeclineNone {
+		return reason, nil
+	}
+
+	switch err := card.CanPay(payment.Amount); {
+	case errors.Is(err, model.ErrCardNotActive):
+		return model.DeclineCardInactive, nil
+	case errors.Is(err, model.ErrCardExpired):
+		return model.DeclineCardExpired, nil
+	case errors.Is(err, model.ErrCardCapExceeded):
+		return model.DeclineAmountCapExceeded, nil
+	case err != nil:
+		return model.DeclineNone, err
+	}
+
+	now := time.Now()
+	dailyUsage, err := s.cardRepo.GetDailyUsage(context.Background(), card.ID, now)
+	if err != nil {
+		return model.DeclineNone, fmt.Errorf("could not get daily card usage: %v", err)
+	}
+	if card.DailyLimit > 0 && dailyUsage+payment.Amount > card.DailyLimit {
+		return model.DeclineDailyLimitExceeded, nil
+	}
+
+	monthlyUsage, err := s.cardRepo.GetMonthlyUsage(context.Background(), card.ID, now.Year(), now.Month())
+	if err != nil {
+		return model.DeclineNone, fmt.Errorf("could not get monthly card usage: %v", err)
+	}
+	if card.MonthlyLimit > 0 && monthlyUsage+payment.Amount > card.MonthlyLimit {
+		return model.DeclineMonthlyLimitExceeded, nil
+	}
+
+	account, err := s.accountRepo.GetByID(context.Background(), card.AccountID)
+	if err != nil {
+		return model.DeclineNone, fmt.Errorf("failed to get account: %v", err)
+	}
+	if account.AvailableBalance() < payment.Amount {
+		return model.DeclineInsufficientFunds, nil
+	}
+
+	return model.DeclineNone, nil
+}
+
+// VerifyCardData сверяет срок действия (MM/YY) и CVV, переданные терминалом, с данными карты.
+// Пустые значения не проверяются: CVV передается не во всех операциях.
+func (s *cardService) VerifyCardData(card *model.Card, expiryDate, cvv string) (model.DeclineReason, error) {
+	if expiryDate != "" {
+		cardExpiryDate, err := s.keys.Decrypt(card.ExpiryKeyID, card.ExpiryDate)
+		if err != nil {
+			return model.DeclineNone, fmt.Errorf("failed to decrypt expiry date: %v", err)
+		}
+		if cardExpiryDate != expiryDate {
+			return model.DeclineInvalidExpiry, nil
+		}
+	}
+	if cvv != "" && !security.CheckCVV(cvv, card.CVV) {
+		return model.DeclineInvalidCVV, nil
+	}
+	return model.DeclineNone, nil
+}
+
+// UpdateControls меняет ограничения операций по карте пользователя
+func (s *cardService) UpdateControls(userID, cardID uint, controls *model.CardControls) (*model.Card, error) {
+	if err := controls.Validate(); err != nil {
+		return nil, err
+	}
+
+	card, err := s.getUserCard(userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	card.ApplyControls(controls)
+	if err := s.cardRepo.UpdateControls(context.Background(), card); err != nil {
+		return nil, fmt.Errorf("failed to update card controls: %v", err)
+	}
+
+	return card, nil
+}
+
+// getUserCard получает карту и проверяет, что она принадлежит пользователю
+func (s *cardService) getUserCard(userID, cardID uint) (*model.Card, error) {
+	card, err := s.cardRepo.GetByID(context.Background(), cardID)
+	if err != nil {
+		return nil, err
+	}
+	if card.UserID != userID {
+		return nil, ErrCardNotOwned
+	}
+	return card, nil
+}
+
 func (s *cardService) GetCardByID(id uint) (*model.Card, error) {
 	card, err := s.cardRepo.GetByID(context.Background(), id)
 	if err != nil {
@@ -146,6 +429,11 @@ func (s *cardService) GetCardByID(id uint) (*model.Card, error) {
 	return card, nil
 }
 
+// GetCardByNumber ищет карту по открытому номеру через слепой индекс
+func (s *cardService) GetCardByNumber(number string) (*model.Card, error) {
+	return s.cardRepo.GetByNumber(context.Background(), s.numberHash(number))
+}
+
 func (s *cardService) GetUserCards(userID uint) ([]model.Card, error) {
 	// Получаем все счета пользователя
 	accounts, err := s.accountRepo.GetByUserID(context.Background(), userID)
@@ -171,3 +459,126 @@ func (s *cardService) GetUserCards(userID uint) ([]model.Card, error) {
 
 	return allCards, nil
 }
+
+// BackfillNumberHashes заполняет слепой индекс для карт, выпущенных до его появления.
+// Возвращает количество карт, для которых индекс заполнить не удалось.
+func (s *cardService) BackfillNumberHashes() (int, error) {
+	cards, err := s.cardRepo.GetWithoutNumberHash(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("could not get cards without number hash: %v", err)
+	}
+
+	failed := 0
+	for _, card := range cards {
+		number, err := s.keys.Decrypt(card.NumberKeyID, card.Number)
+		if err == nil {
+			// Повторяющийся номер нарушает уникальность индекса
+			err = s.cardRepo.UpdateNumberHash(context.Background(), card.ID, s.numberHash(number))
+		}
+		if err != nil {
+			// Карты, зашифрованные обрезанным шифртекстом, расшифровать нельзя
+			logrus.WithFields(logrus.Fields{
+				"card_id": card.ID,
+				"error":   err.Error(),
+			}).Error("Не удалось заполнить индекс номера карты")
+			failed++
+		}
+	}
+
+	return failed, nil
+}
+
+// ReencryptCards перешифровывает активным ключом все карты, зашифрованные старыми версиями.
+// Карты обрабатываются пачками по batchSize, каждая пачка сохраняется в одной транзакции.
+// Возвращает количество перешифрованных карт и карт, которые перешифровать не удалось.
+func (s *cardService) ReencryptCards(batchSize int) (int, int, error) {
+	if batchSize <= 0 {
+		return 0, 0, fmt.Errorf("invalid batch size: %d", batchSize)
+	}
+
+	activeKeyID := s.keys.ActiveKeyID()
+	total, failed := 0, 0
+	var lastID uint
+
+	for {
+		cards, err := s.cardRepo.GetForReencryption(context.Background(), activeKeyID, lastID, batchSize)
+		if err != nil {
+			return total, failed, fmt.Errorf("could not get cards for re-encryption: %v", err)
+		}
+		if len(cards) == 0 {
+			return total, failed, nil
+		}
+		lastID = cards[len(cards)-1].ID
+
+		batch := make([]model.Card, 0, len(cards))
+		for _, card := range cards {
+			if err := s.reencryptCard(&card); err != nil {
+				logrus.WithFields(logrus.Fields{
+					"card_id": card.ID,
+					"error":   err.Error(),
+				}).Error("Не удалось перешифровать карту")
+				failed++
+				continue
+			}
+			batch = append(batch, card)
+		}
+
+		if err := s.cardRepo.UpdateEncryptedFields(context.Background(), batch); err != nil {
+			return total, failed, fmt.Errorf("failed to save re-encrypted cards: %v", err)
+		}
+		total += len(batch)
+	}
+}
+
+// reencryptCard расшифровывает поля карты их ключами и шифрует активным ключом.
+// Слепой индекс пересчитывается, так как номер все равно расшифрован.
+func (s *cardService) reencryptCard(card *model.Card) error {
+	number, err := s.keys.Decrypt(card.NumberKeyID, card.Number)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt card number: %v", err)
+	}
+	expiryDate, err := s.keys.Decrypt(card.ExpiryKeyID, card.ExpiryDate)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt expiry date: %v", err)
+	}
+
+	if card.NumberKeyID, card.Number, err = s.keys.Encrypt(number); err != nil {
+		return fmt.Errorf("failed to encrypt card number: %v", err)
+	}
+	if card.ExpiryKeyID, card.ExpiryDate, err = s.keys.Encrypt(expiryDate); err != nil {
+		return fmt.Errorf("failed to encrypt expiry date: %v", err)
+	}
+	card.NumberHash = s.numberHash(number)
+
+	return nil
+}
+
+// GetCardProducts возвращает активные продукты каталога
+func (s *cardService) GetCardProducts() ([]model.CardProduct, error) {
+	return s.productRepo.GetActive(context.Background())
+}
+
+// generateUniqueNumber генерирует номер карты из диапазона BIN продукта, которого еще нет в базе
+func (s *cardService) generateUniqueNumber(product *model.CardProduct) (string, string, error) {
+	for i := 0; i < maxCardNumberAttempts; i++ {
+		number := security.GenerateCardNumber(product.RandomBIN(), 16)
+		if !product.ContainsBIN(number) {
+			return "", "", fmt.Errorf("generated number is outside of BIN range of product %s", product.Code)
+		}
+		numberHash := s.numberHash(number)
+
+		_, err := s.cardRepo.GetByNumber(context.Background(), numberHash)
+		if errors.Is(err, repository.ErrNotFound) {
+			return number, numberHash, nil
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("could not check card number uniqueness: %v", err)
+		}
+	}
+	return "", "", fmt.Errorf("could not generate unique card number after %d attempts", maxCardNumberAttempts)
+}
+
+// numberHash вычисляет слепой индекс номера карты
+func (s *cardService) numberHash(number string) string {
+	return security.GenerateHMAC(number, s.hmacSecret)
+}
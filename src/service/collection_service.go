@@ -0,0 +1,354 @@
+package service
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CollectionQueueFilter отбор дел в очереди взыскания. Пустые поля не ограничивают отбор.
+type CollectionQueueFilter struct {
+	Stage     model.CollectionStage
+	Bucket    model.CollectionBucket
+	CallTasks bool // только дела, по которым оператору нужно связаться с заемщиком
+}
+
+// CollectionCaseDetails дело о взыскании с кредитом и историей взыскания
+type CollectionCaseDetails struct {
+	Case       *model.CollectionCase      `json:"case"`
+	Credit     map[string]interface{}     `json:"credit"`
+	Activities []model.CollectionActivity `json:"activities"`
+}
+
+// CollectionService взыскание просроченной задолженности по кредитам: распределяет просроченные
+// кредиты по группам просрочки, повышает стадию взыскания, ведет попытки связаться с заемщиком
+// и обещания оплаты
+type CollectionService interface {
+	ProcessCollections(now time.Time) ([]model.CollectionCase, error)
+	GetQueue(filter CollectionQueueFilter) (*model.CollectionQueue, error)
+	GetCase(caseID uint) (*CollectionCaseDetails, error)
+	LogContact(operatorID uint, caseID uint, contact *model.CollectionActivity) (*model.CollectionActivity, error)
+	AddPromise(operatorID uint, caseID uint, promise *model.CollectionActivity) (*model.CollectionActivity, error)
+}
+
+type collectionService struct {
+	collectionRepo repository.CollectionRepository
+	creditRepo     repository.CreditRepository
+}
+
+func CollectionServiceInstance(
+	collectionRepo repository.CollectionRepository,
+	creditRepo repository.CreditRepository,
+) CollectionService {
+	return &collectionService{
+		collectionRepo: collectionRepo,
+		creditRepo:     creditRepo,
+	}
+}
+
+// ProcessCollections открывает дела по новым просроченным кредитам, обновляет просрочку и стадию
+// по открытым делам, закрывает дела с погашенной просрочкой и подводит итог обещаний оплаты.
+// Возвращает дела, стадия которых изменилась.
+func (s *collectionService) ProcessCollections(now time.Time) ([]model.CollectionCase, error) {
+	credits, err := s.creditRepo.GetCreditsByStatus(context.Background(), model.CreditStatusOverdue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overdue credits: %v", err)
+	}
+
+	var escalated []model.CollectionCase
+	overdue := make(map[uint]bool, len(credits))
+	for i := range credits {
+		credit := &credits[i]
+		overdue[credit.ID] = true
+
+		collectionCase, changed, err := s.refreshCase(credit, now)
+		if err != nil {
+			return escalated, err
+		}
+		if changed {
+			escalated = append(escalated, *collectionCase)
+		}
+	}
+
+	if err := s.resolvePromises(now); err != nil {
+		return escalated, err
+	}
+
+	// Дела по кредитам без просрочки закрываются
+	cases, err := s.collectionRepo.GetOpenCases(context.Background())
+	if err != nil {
+		return escalated, fmt.Errorf("failed to get collection cases: %v", err)
+	}
+	for i := range cases {
+		collectionCase := &cases[i]
+		if overdue[collectionCase.CreditID] {
+			continue
+		}
+		collectionCase.Close(now)
+		if err := s.collectionRepo.Update(context.Background(), collectionCase); err != nil {
+			return escalated, fmt.Errorf("failed to close collection case: %v", err)
+		}
+		if err := s.recordActivity(collectionCase, &model.CollectionActivity{
+			Type:    model.CollectionActivityClosed,
+			Comment: "overdue debt repaid",
+		}); err != nil {
+			return escalated, err
+		}
+		logrus.WithFields(logrus.Fields{
+			"case_id":   collectionCase.ID,
+			"credit_id": collectionCase.CreditID,
+		}).Info("Дело о взыскании закрыто")
+	}
+
+	return escalated, nil
+}
+
+// refreshCase обновляет дело о взыскании по просроченному кредиту, открывая его при первой просрочке
+func (s *collectionService) refreshCase(credit *model.Credit, now time.Time) (*model.CollectionCase, bool, error) {
+	collectionCase, err := s.collectionRepo.GetOpenByCreditID(context.Background(), credit.ID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		collectionCase = model.NewCollectionCase(credit)
+	case err != nil:
+		return nil, false, fmt.Errorf("failed to get collection case: %v", err)
+	}
+
+	changed := collectionCase.Refresh(credit, now)
+	if collectionCase.ID == 0 {
+		err = s.collectionRepo.Create(context.Background(), collectionCase)
+	} else {
+		err = s.collectionRepo.Update(context.Background(), collectionCase)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to save collection case: %v", err)
+	}
+
+	if changed {
+		if err := s.recordActivity(collectionCase, &model.CollectionActivity{
+			Type:    model.CollectionActivityStageChanged,
+			Comment: fmt.Sprintf("%d days past due", collectionCase.DaysPastDue),
+		}); err != nil {
+			return nil, false, err
+		}
+		logrus.WithFields(logrus.Fields{
+			"case_id":       collectionCase.ID,
+			"credit_id":     credit.ID,
+			"stage":         collectionCase.Stage,
+			"days_past_due": collectionCase.DaysPastDue,
+		}).Info("Стадия взыскания изменена")
+	}
+	return collectionCase, changed, nil
+}
+
+// resolvePromises подводит итог обещаний оплаты. По нарушенному обещанию оператору снова
+// ставится задача связаться с заемщиком.
+func (s *collectionService) resolvePromises(now time.Time) error {
+	promises, err := s.collectionRepo.GetPendingPromises(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get promises to pay: %v", err)
+	}
+
+	for i := range promises {
+		promise := &promises[i]
+		collectionCase, err := s.collectionRepo.GetByID(context.Background(), promise.CaseID)
+		if err != nil {
+			return fmt.Errorf("failed to get collection case: %v", err)
+		}
+		credit, err := s.creditRepo.GetByID(context.Background(), promise.CreditID)
+		if err != nil {
+			return fmt.Errorf("failed to get credit: %v", err)
+		}
+
+		if !promise.ResolvePromise(credit.TotalPaid, !collectionCase.IsOpen(), now) {
+			continue
+		}
+		if err := s.collectionRepo.UpdateActivity(context.Background(), promise); err != nil {
+			return fmt.Errorf("failed to update promise to pay: %v", err)
+		}
+		if promise.PromiseStatus == model.PromiseStatusBroken && collectionCase.IsOpen() {
+			collectionCase.CallTaskOpen = true
+			if err := s.collectionRepo.Update(context.Background(), collectionCase); err != nil {
+				return fmt.Errorf("failed to update collection case: %v", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetQueue возвращает открытые дела по убыванию стадии и просрочки и сводку по группам просрочки.
+// Сводка и число задач на звонок считаются по всей очереди без учета отбора.
+func (s *collectionService) GetQueue(filter CollectionQueueFilter) (*model.CollectionQueue, error) {
+	if filter.Stage != "" {
+		if err := filter.Stage.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if filter.Bucket != "" {
+		if err := filter.Bucket.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	cases, err := s.collectionRepo.GetOpenCases(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection cases: %v", err)
+	}
+
+	queue := &model.CollectionQueue{
+		Cases:   []model.CollectionCase{},
+		Summary: make([]model.CollectionBucketSummary, len(model.CollectionBuckets)),
+	}
+	summary := make(map[model.CollectionBucket]*model.CollectionBucketSummary, len(model.CollectionBuckets))
+	for i, bucket := range model.CollectionBuckets {
+		queue.Summary[i].Bucket = bucket
+		summary[bucket] = &queue.Summary[i]
+	}
+
+	for _, collectionCase := range cases {
+		if bucket, ok := summary[collectionCase.Bucket]; ok {
+			bucket.Cases++
+			bucket.OverdueAmount = math.Round((bucket.OverdueAmount+collectionCase.OverdueAmount)*100) / 100
+		}
+		if collectionCase.CallTaskOpen {
+			queue.CallTasks++
+		}
+
+		if (filter.Stage != "" && collectionCase.Stage != filter.Stage) ||
+			(filter.Bucket != "" && collectionCase.Bucket != filter.Bucket) ||
+			(filter.CallTasks && !collectionCase.CallTaskOpen) {
+			continue
+		}
+		queue.Cases = append(queue.Cases, collectionCase)
+	}
+
+	sort.SliceStable(queue.Cases, func(i, j int) bool {
+		a, b := queue.Cases[i], queue.Cases[j]
+		if a.Stage.Rank() != b.Stage.Rank() {
+			return a.Stage.Rank() > b.Stage.Rank()
+		}
+		return a.DaysPastDue > b.DaysPastDue
+	})
+
+	return queue, nil
+}
+
+// GetCase возвращает дело о взыскании с кредитом и историей
+func (s *collectionService) GetCase(caseID uint) (*CollectionCaseDetails, error) {
+	collectionCase, err := s.collectionRepo.GetByID(context.Background(), caseID)
+	if err != nil {
+		return nil, err
+	}
+
+	credit, err := s.creditRepo.GetByID(context.Background(), collectionCase.CreditID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit: %v", err)
+	}
+
+	activities, err := s.collectionRepo.GetActivities(context.Background(), collectionCase.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection history: %v", err)
+	}
+
+	return &CollectionCaseDetails{Case: collectionCase, Credit: credit.ToDTO(), Activities: activities}, nil
+}
+
+// LogContact записывает попытку оператора связаться с заемщиком. Если заемщик ответил,
+// задача на звонок закрывается.
+func (s *collectionService) LogContact(operatorID uint, caseID uint, contact *model.CollectionActivity) (*model.CollectionActivity, error) {
+	collectionCase, err := s.getOpenCase(caseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := contact.ValidateContact(); err != nil {
+		return nil, err
+	}
+
+	contact.Type = model.CollectionActivityContact
+	contact.ActorID = operatorID
+	if err := s.recordActivity(collectionCase, contact); err != nil {
+		return nil, err
+	}
+
+	if contact.Result == model.ContactResultReached || contact.Result == model.ContactResultRefused {
+		collectionCase.CallTaskOpen = false
+		if err := s.collectionRepo.Update(context.Background(), collectionCase); err != nil {
+			return nil, fmt.Errorf("failed to update collection case: %v", err)
+		}
+	}
+
+	return contact, nil
+}
+
+// AddPromise записывает обещание заемщика внести сумму к дате. По делу может быть только одно
+// обещание, по которому не подведен итог. Задача на звонок закрывается до итога обещания.
+func (s *collectionService) AddPromise(operatorID uint, caseID uint, promise *model.CollectionActivity) (*model.CollectionActivity, error) {
+	collectionCase, err := s.getOpenCase(caseID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := promise.ValidatePromise(now); err != nil {
+		return nil, err
+	}
+
+	activities, err := s.collectionRepo.GetActivities(context.Background(), collectionCase.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection history: %v", err)
+	}
+	for _, activity := range activities {
+		if activity.Type == model.CollectionActivityPromise && activity.PromiseStatus == model.PromiseStatusPending {
+			return nil, model.ErrPromiseToPayPending
+		}
+	}
+
+	credit, err := s.creditRepo.GetByID(context.Background(), collectionCase.CreditID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit: %v", err)
+	}
+
+	promise.Type = model.CollectionActivityPromise
+	promise.ActorID = operatorID
+	promise.PaidBase = credit.TotalPaid
+	promise.PromiseStatus = model.PromiseStatusPending
+	if err := s.recordActivity(collectionCase, promise); err != nil {
+		return nil, err
+	}
+
+	collectionCase.CallTaskOpen = false
+	if err := s.collectionRepo.Update(context.Background(), collectionCase); err != nil {
+		return nil, fmt.Errorf("failed to update collection case: %v", err)
+	}
+
+	return promise, nil
+}
+
+// getOpenCase получает дело, по которому ведется взыскание
+func (s *collectionService) getOpenCase(caseID uint) (*model.CollectionCase, error) {
+	collectionCase, err := s.collectionRepo.GetByID(context.Background(), caseID)
+	if err != nil {
+		return nil, err
+	}
+	if !collectionCase.IsOpen() {
+		return nil, model.ErrCollectionCaseClosed
+	}
+	return collectionCase, nil
+}
+
+// recordActivity сохраняет запись в истории дела с текущей стадией взыскания
+func (s *collectionService) recordActivity(collectionCase *model.CollectionCase, activity *model.CollectionActivity) error {
+	activity.CaseID = collectionCase.ID
+	activity.CreditID = collectionCase.CreditID
+	activity.Stage = collectionCase.Stage
+	if err := s.collectionRepo.AddActivity(context.Background(), activity); err != nil {
+		return fmt.Errorf("failed to record collection history: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrApplicationNotOwned = errors.New("credit application does not belong to the user")
+	ErrAccountNotOwned     = errors.New("account does not belong to the user")
+)
+
+// CreditApplicationRequest данные заявки на кредит
+type CreditApplicationRequest struct {
+	AccountID     uint
+	ProductCode   string
+	Amount        float64
+	TermMonths    int
+	RepaymentType model.RepaymentType
+	Description   string
+}
+
+// CreditApplicationDetails заявка с историей изменения статусов и обеспечением
+type CreditApplicationDetails struct {
+	Application *model.CreditApplication       `json:"application"`
+	Events      []model.CreditApplicationEvent `json:"events"`
+	Security    *model.CreditSecurity          `json:"security"`
+}
+
+type CreditApplicationService interface {
+	Submit(userID uint, request CreditApplicationRequest) (*model.CreditApplication, error)
+	GetUserApplications(userID uint) ([]model.CreditApplication, error)
+	GetUserApplication(userID uint, applicationID uint) (*CreditApplicationDetails, error)
+	ListApplications(status model.CreditApplicationStatus) ([]model.CreditApplication, error)
+	GetApplication(applicationID uint) (*CreditApplicationDetails, error)
+	Approve(reviewerID uint, applicationID uint, comment string) (*model.CreditApplication, *model.Credit, error)
+	Reject(reviewerID uint, applicationID uint, comment string) (*model.CreditApplication, error)
+	AddCollateral(applicationID uint, collateral *model.Collateral) (*model.Collateral, error)
+	AddGuarantor(applicationID uint, guarantor *model.Guarantor) (*model.Guarantor, error)
+}
+
+type creditApplicationService struct {
+	applicationRepo repository.CreditApplicationRepository
+	creditRepo      repository.CreditRepository
+	accountRepo     repository.AccountRepository
+	userRepo        repository.UserRepository
+	creditService   CreditService
+	productService  CreditProductService
+	scoringService  ScoringService
+}
+
+func CreditApplicationServiceInstance(
+	applicationRepo repository.CreditApplicationRepository,
+	creditRepo repository.CreditRepository,
+	accountRepo repository.AccountRepository,
+	userRepo repository.UserRepository,
+	creditService CreditService,
+	productService CreditProductService,
+	scoringService ScoringService,
+) CreditApplicationService {
+	return &creditApplicationService{
+		applicationRepo: applicationRepo,
+		creditRepo:      creditRepo,
+		accountRepo:     accountRepo,
+		userRepo:        userRepo,
+		creditService:   creditService,
+		productService:  productService,
+		scoringService:  scoringService,
+	}
+}
+
+// Submit принимает заявку по продукту, выполняет автоматические проверки и скоринг.
+// Заявка, не прошедшая проверки или с низким баллом, отклоняется автоматически,
+// с высоким баллом — одобряется, остальные передаются на рассмотрение сотруднику.
+func (s *creditApplicationService) Submit(userID uint, request CreditApplicationRequest) (*model.CreditApplication, error) {
+	product, err := s.productService.GetProductByCode(request.ProductCode)
+	if err != nil {
+		return nil, err
+	}
+	repaymentType, err := product.CheckTerms(request.Amount, request.TermMonths, request.RepaymentType)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Description == "" {
+		request.Description = product.Name
+	}
+
+	account, err := s.accountRepo.GetByID(context.Background(), request.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if account.UserID != userID {
+		return nil, ErrAccountNotOwned
+	}
+
+	application := &model.CreditApplication{
+		UserID:        userID,
+		AccountID:     request.AccountID,
+		ProductID:     product.ID,
+		Amount:        request.Amount,
+		Term:          request.TermMonths,
+		RepaymentType: repaymentType,
+		Description:   request.Description,
+		Status:        model.CreditApplicationSubmitted,
+	}
+	if err := application.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.applicationRepo.Create(context.Background(), application); err != nil {
+		return nil, fmt.Errorf("failed to create credit application: %v", err)
+	}
+	if err := s.recordEvent(context.Background(), application, "", 0, ""); err != nil {
+		return nil, err
+	}
+
+	// Автоматические проверки
+	prechecks, err := s.runPrechecks(application)
+	if err != nil {
+		return nil, err
+	}
+	application.Prechecks = prechecks
+	if !application.PrechecksPassed() {
+		if err := s.changeStatus(context.Background(), application, model.CreditApplicationRejected, 0, "automatic pre-checks failed"); err != nil {
+			return nil, err
+		}
+		return application, nil
+	}
+
+	// Скоринг
+	score, err := s.scoringService.ScoreApplication(application)
+	if err != nil {
+		return nil, fmt.Errorf("failed to score application: %v", err)
+	}
+	application.ApplyScore(score)
+
+	if score.Decision == model.ScoreDecisionDecline {
+		if err := s.changeStatus(context.Background(), application, model.CreditApplicationRejected, 0, "declined by scoring"); err != nil {
+			return nil, err
+		}
+		return application, nil
+	}
+
+	if err := s.changeStatus(context.Background(), application, model.CreditApplicationPendingReview, 0, ""); err != nil {
+		return nil, err
+	}
+
+	// При высоком балле кредит выдается без участия сотрудника. Если выдать кредит
+	// не удалось, заявка остается на рассмотрении.
+	if score.Decision == model.ScoreDecisionApprove {
+		if _, err := s.approve(application, 0, "approved by scoring"); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"application_id": application.ID,
+				"error":          err.Error(),
+			}).Warn("Автоматическое одобрение заявки не выполнено")
+		}
+	}
+
+	return application, nil
+}
+
+func (s *creditApplicationService) GetUserApplications(userID uint) ([]model.CreditApplication, error) {
+	return s.applicationRepo.GetByUserID(context.Background(), userID)
+}
+
+func (s *creditApplicationService) GetUserApplication(userID uint, applicationID uint) (*CreditApplicationDetails, error) {
+	details, err := s.GetApplication(applicationID)
+	if err != nil {
+		return nil, err
+	}
+	if details.Application.UserID != userID {
+		return nil, ErrApplicationNotOwned
+	}
+	return details, nil
+}
+
+func (s *creditApplicationService) ListApplications(status model.CreditApplicationStatus) ([]model.CreditApplication, error) {
+	if status == "" {
+		return s.applicationRepo.List(context.Background(), 0, -1)
+	}
+	return s.applicationRepo.GetByStatus(context.Background(), status)
+}
+
+func (s *creditApplicationService) GetApplication(applicationID uint) (*CreditApplicationDetails, error) {
+	application, err := s.applicationRepo.GetByID(context.Background(), applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.applicationRepo.GetEvents(context.Background(), application.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get application history: %v", err)
+	}
+
+	security, err := s.creditRepo.GetApplicationSecurity(context.Background(), application.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get application security: %v", err)
+	}
+
+	return &CreditApplicationDetails{Application: application, Events: events, Security: security}, nil
+}
+
+// Approve одобряет заявку и выдает кредит на счет клиента
+func (s *creditApplicationService) Approve(reviewerID uint, applicationID uint, comment string) (*model.CreditApplication, *model.Credit, error) {
+	application, err := s.getPendingApplication(applicationID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	credit, err := s.approve(application, reviewerID, comment)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return application, credit, nil
+}
+
+// approve выдает кредит по продукту заявки со скидкой к ставке по результату скоринга
+func (s *creditApplicationService) approve(application *model.CreditApplication, reviewerID uint, comment string) (*model.Credit, error) {
+	// Заявки, поданные до появления каталога, выдаются по продукту по умолчанию
+	var product *model.CreditProduct
+	var err error
+	if application.ProductID != 0 {
+		product, err = s.productService.GetProduct(application.ProductID)
+	} else {
+		product, err = s.productService.GetProductByCode("")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Залог проверяется на дату одобрения: вид, срок оценки и отношение суммы кредита к стоимости
+	security, err := s.creditRepo.GetApplicationSecurity(context.Background(), application.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get application security: %v", err)
+	}
+	loanToValue, err := product.CheckCollateral(application.Amount, security.Collateral, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Заявка переводится в APPROVED условным обновлением до выдачи кредита и в той же
+	// транзакции базы данных: параллельное одобрение той же заявки не проходит этот шаг,
+	// а ошибка выдачи откатывает и выдачу, и решение по заявке
+	previous := *application
+	var credit *model.Credit
+	err = s.applicationRepo.InTransaction(context.Background(), func(ctx context.Context) error {
+		s.setReview(application, reviewerID, comment)
+		if err := s.changeStatus(ctx, application, model.CreditApplicationApproved, reviewerID, comment); err != nil {
+			return err
+		}
+
+		credit, err = s.creditService.CreateCredit(
+			ctx,
+			application.UserID,
+			application.AccountID,
+			product,
+			application.Amount,
+			application.Term,
+			application.RepaymentType,
+			application.RateDiscount(),
+			application.Description,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to disburse credit: %v", err)
+		}
+
+		if err := s.creditRepo.AttachSecurity(ctx, application.ID, credit.ID); err != nil {
+			return fmt.Errorf("failed to pledge credit security: %v", err)
+		}
+		if loanToValue > 0 {
+			credit.LoanToValue = loanToValue
+			if err := s.creditRepo.Update(ctx, credit); err != nil {
+				return fmt.Errorf("failed to update credit: %v", err)
+			}
+		}
+
+		application.CreditID = &credit.ID
+		if err := s.applicationRepo.Update(ctx, application); err != nil {
+			return fmt.Errorf("failed to update credit application: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		*application = previous
+		return nil, err
+	}
+
+	return credit, nil
+}
+
+// Reject отклоняет заявку. Комментарий с причиной обязателен.
+func (s *creditApplicationService) Reject(reviewerID uint, applicationID uint, comment string) (*model.CreditApplication, error) {
+	if comment == "" {
+		return nil, model.ErrReviewCommentRequired
+	}
+
+	application, err := s.getPendingApplication(applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.setReview(application, reviewerID, comment)
+	if err := s.changeStatus(context.Background(), application, model.CreditApplicationRejected, reviewerID, comment); err != nil {
+		return nil, err
+	}
+
+	return application, nil
+}
+
+// AddCollateral регистрирует предмет залога по заявке, ожидающей решения
+func (s *creditApplicationService) AddCollateral(applicationID uint, collateral *model.Collateral) (*model.Collateral, error) {
+	application, err := s.getPendingApplication(applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	collateral.ApplicationID = application.ID
+	collateral.CreditID = nil
+	collateral.Status = model.CollateralStatusRegistered
+	if err := collateral.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.creditRepo.AddCollateral(context.Background(), collateral); err != nil {
+		return nil, fmt.Errorf("failed to add collateral: %v", err)
+	}
+
+	return collateral, nil
+}
+
+// AddGuarantor регистрирует поручителя по заявке, ожидающей решения. Поручителем может быть
+// только другой клиент банка, каждый — один раз по заявке.
+func (s *creditApplicationService) AddGuarantor(applicationID uint, guarantor *model.Guarantor) (*model.Guarantor, error) {
+	application, err := s.getPendingApplication(applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	guarantor.ApplicationID = application.ID
+	guarantor.CreditID = nil
+	guarantor.Status = model.GuarantorStatusRegistered
+	if err := guarantor.Validate(); err != nil {
+		return nil, err
+	}
+	if guarantor.UserID == application.UserID {
+		return nil, fmt.Errorf("%w: borrower cannot guarantee own credit", model.ErrInvalidGuarantor)
+	}
+	if guarantor.LiabilityAmount > application.Amount {
+		return nil, fmt.Errorf("%w: liability exceeds credit amount", model.ErrInvalidGuarantor)
+	}
+	if _, err := s.userRepo.GetByID(context.Background(), guarantor.UserID); err != nil {
+		return nil, fmt.Errorf("%w: user %d: %v", model.ErrInvalidGuarantor, guarantor.UserID, err)
+	}
+
+	security, err := s.creditRepo.GetApplicationSecurity(context.Background(), application.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get application security: %v", err)
+	}
+	for _, existing := range security.Guarantors {
+		if existing.UserID == guarantor.UserID {
+			return nil, fmt.Errorf("%w: user %d is already a guarantor", model.ErrInvalidGuarantor, guarantor.UserID)
+		}
+	}
+
+	if err := s.creditRepo.AddGuarantor(context.Background(), guarantor); err != nil {
+		return nil, fmt.Errorf("failed to add guarantor: %v", err)
+	}
+
+	return guarantor, nil
+}
+
+// runPrechecks выполняет автоматические проверки заявки
+func (s *creditApplicationService) runPrechecks(application *model.CreditApplication) ([]model.PrecheckResult, error) {
+	credits, err := s.creditRepo.GetCreditsByUserID(context.Background(), application.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user credits: %v", err)
+	}
+
+	overdue := 0
+	active := 0
+	for _, credit := range credits {
+		switch credit.Status {
+		case model.CreditStatusOverdue:
+			overdue++
+			active++
+		case model.CreditStatusActive:
+			active++
+		}
+	}
+
+	applications, err := s.applicationRepo.GetByUserID(context.Background(), application.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user applications: %v", err)
+	}
+	open := 0
+	for _, other := range applications {
+		if other.ID != application.ID && other.IsOpen() {
+			open++
+		}
+	}
+
+	return []model.PrecheckResult{
+		precheck(model.PrecheckNoOverdueCredits, overdue == 0,
+			fmt.Sprintf("%d overdue credits", overdue)),
+		precheck(model.PrecheckActiveCredits, active < model.MaxActiveCredits,
+			fmt.Sprintf("%d active credits, limit %d", active, model.MaxActiveCredits)),
+		precheck(model.PrecheckNoOpenApplication, open == 0,
+			fmt.Sprintf("%d applications pending review", open)),
+	}, nil
+}
+
+// getPendingApplication получает заявку, ожидающую решения
+func (s *creditApplicationService) getPendingApplication(applicationID uint) (*model.CreditApplication, error) {
+	application, err := s.applicationRepo.GetByID(context.Background(), applicationID)
+	if err != nil {
+		return nil, err
+	}
+	if application.Status != model.CreditApplicationPendingReview {
+		return nil, model.ErrApplicationNotPending
+	}
+	return application, nil
+}
+
+// setReview заполняет решение по заявке. Для автоматического решения сотрудник не указывается.
+func (s *creditApplicationService) setReview(application *model.CreditApplication, reviewerID uint, comment string) {
+	now := time.Now()
+	if reviewerID != 0 {
+		application.ReviewerID = &reviewerID
+	}
+	application.ReviewComment = comment
+	application.ReviewedAt = &now
+}
+
+// changeStatus сохраняет новый статус заявки и записывает переход в историю. Статус меняется
+// условным обновлением: если заявку уже перевел другой запрос, возвращается ErrApplicationNotPending.
+func (s *creditApplicationService) changeStatus(ctx context.Context, application *model.CreditApplication, status model.CreditApplicationStatus, actorID uint, comment string) error {
+	from := application.Status
+	application.Status = status
+	updated, err := s.applicationRepo.UpdateFromStatus(ctx, application, from)
+	if err != nil {
+		return fmt.Errorf("failed to update credit application: %v", err)
+	}
+	if !updated {
+		application.Status = from
+		return model.ErrApplicationNotPending
+	}
+	return s.recordEvent(ctx, application, from, actorID, comment)
+}
+
+// recordEvent записывает переход заявки в текущий статус
+func (s *creditApplicationService) recordEvent(ctx context.Context, application *model.CreditApplication, from model.CreditApplicationStatus, actorID uint, comment string) error {
+	event := &model.CreditApplicationEvent{
+		ApplicationID: application.ID,
+		FromStatus:    from,
+		ToStatus:      application.Status,
+		ActorID:       actorID,
+		Comment:       comment,
+	}
+	if err := s.applicationRepo.AddEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to record application history: %v", err)
+	}
+	return nil
+}
+
+// precheck формирует результат проверки. Пояснение сохраняется только для непройденной проверки.
+func precheck(check string, passed bool, message string) model.PrecheckResult {
+	if passed {
+		message = ""
+	}
+	return model.PrecheckResult{Check: check, Passed: passed, Message: message}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// CreditBureauClient получает кредитный отчет заемщика из бюро кредитных историй
+type CreditBureauClient interface {
+	GetReport(user *model.User) (*model.CreditBureauReport, error)
+	// Source возвращает источник отчетов клиента (model.CreditBureauSourceHTTP или Stub)
+	Source() string
+}
+
+// creditBureauInquiry запрос кредитного отчета в бюро
+type creditBureauInquiry struct {
+	MemberCode string `json:"member_code"`
+	SubjectID  uint   `json:"subject_id"`
+	Fio        string `json:"fio"`
+	Email      string `json:"email"`
+}
+
+// creditBureauResponse ответ бюро с кредитным отчетом
+type creditBureauResponse struct {
+	Found           bool    `json:"found"`
+	BureauScore     int     `json:"bureau_score"`
+	ActiveCredits   int     `json:"active_credits"`
+	MonthlyPayments float64 `json:"monthly_payments"`
+	OverdueAmount   float64 `json:"overdue_amount"`
+	LatePayments    int     `json:"late_payments"`
+	MaxDaysPastDue  int     `json:"max_days_past_due"`
+}
+
+// httpCreditBureauClient запрашивает отчеты у бюро по HTTP: POST JSON-запроса на endpoint
+type httpCreditBureauClient struct {
+	endpoint   string
+	token      string
+	memberCode string
+	client     *http.Client
+}
+
+// HTTPCreditBureauClientInstance создает клиент бюро по адресу endpoint.
+// Непустой token передается в заголовке Authorization.
+func HTTPCreditBureauClientInstance(endpoint, token, memberCode string, timeout time.Duration) CreditBureauClient {
+	return &httpCreditBureauClient{
+		endpoint:   endpoint,
+		token:      token,
+		memberCode: memberCode,
+		client:     &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *httpCreditBureauClient) Source() string {
+	return model.CreditBureauSourceHTTP
+}
+
+func (c *httpCreditBureauClient) GetReport(user *model.User) (*model.CreditBureauReport, error) {
+	body, err := json.Marshal(creditBureauInquiry{
+		MemberCode: c.memberCode,
+		SubjectID:  user.ID,
+		Fio:        user.Fio,
+		Email:      user.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding credit bureau inquiry: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating credit bureau request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting credit bureau: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("credit bureau returned status %d", resp.StatusCode)
+	}
+
+	var response creditBureauResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("error decoding credit bureau response: %v", err)
+	}
+
+	return &model.CreditBureauReport{
+		UserID:          user.ID,
+		Source:          model.CreditBureauSourceHTTP,
+		Found:           response.Found,
+		BureauScore:     response.BureauScore,
+		ActiveCredits:   response.ActiveCredits,
+		MonthlyPayments: response.MonthlyPayments,
+		OverdueAmount:   response.OverdueAmount,
+		LatePayments:    response.LatePayments,
+		MaxDaysPastDue:  response.MaxDaysPastDue,
+		ReceivedAt:      time.Now(),
+	}, nil
+}
+
+// stubCreditBureauFixtures кредитные истории заглушки, выбираются по остатку от деления ID пользователя
+var stubCreditBureauFixtures = []creditBureauResponse{
+	// хорошая история: один кредит без просрочек
+	{Found: true, BureauScore: 780, ActiveCredits: 1, MonthlyPayments: 8000},
+	// кредитной истории нет
+	{Found: false},
+	// текущая просрочка и платежи с опозданием
+	{Found: true, BureauScore: 420, ActiveCredits: 2, MonthlyPayments: 25000, OverdueAmount: 18000, LatePayments: 4, MaxDaysPastDue: 45},
+}
+
+// stubCreditBureauClient возвращает детерминированные тестовые отчеты без обращения к бюро.
+// Используется при разработке и в тестах.
+type stubCreditBureauClient struct{}
+
+// StubCreditBureauClientInstance создает заглушку бюро кредитных историй
+func StubCreditBureauClientInstance() CreditBureauClient {
+	return &stubCreditBureauClient{}
+}
+
+func (c *stubCreditBureauClient) Source() string {
+	return model.CreditBureauSourceStub
+}
+
+func (c *stubCreditBureauClient) GetReport(user *model.User) (*model.CreditBureauReport, error) {
+	fixture := stubCreditBureauFixtures[user.ID%uint(len(stubCreditBureauFixtures))]
+	return &model.CreditBureauReport{
+		UserID:          user.ID,
+		Source:          model.CreditBureauSourceStub,
+		Found:           fixture.Found,
+		BureauScore:     fixture.BureauScore,
+		ActiveCredits:   fixture.ActiveCredits,
+		MonthlyPayments: fixture.MonthlyPayments,
+		OverdueAmount:   fixture.OverdueAmount,
+		LatePayments:    fixture.LatePayments,
+		MaxDaysPastDue:  fixture.MaxDaysPastDue,
+		ReceivedAt:      time.Now(),
+	}, nil
+}
+
+// cachedCreditBureauClient сохраняет полученные отчеты и в течение ttl отдает
+// последний отчет пользователя без повторного запроса в бюро
+type cachedCreditBureauClient struct {
+	client     CreditBureauClient
+	bureauRepo repository.CreditBureauRepository
+	ttl        time.Duration
+}
+
+// CachedCreditBureauClientInstance создает клиент бюро с хранением отчетов в течение ttl
+func CachedCreditBureauClientInstance(client CreditBureauClient, bureauRepo repository.CreditBureauRepository, ttl time.Duration) CreditBureauClient {
+	return &cachedCreditBureauClient{
+		client:     client,
+		bureauRepo: bureauRepo,
+		ttl:        ttl,
+	}
+}
+
+func (c *cachedCreditBureauClient) Source() string {
+	return c.client.Source()
+}
+
+// GetReport отдает сохраненный отчет только из того же источника: отчет заглушки,
+// полученный до подключения бюро, не используется для скоринга
+func (c *cachedCreditBureauClient) GetReport(user *model.User) (*model.CreditBureauReport, error) {
+	report, err := c.bureauRepo.GetLastReport(context.Background(), user.ID, c.client.Source())
+	switch {
+	case err == nil && report.IsFresh(c.ttl, time.Now()):
+		return report, nil
+	case err != nil && !errors.Is(err, repository.ErrNotFound):
+		return nil, fmt.Errorf("failed to get cached credit report: %v", err)
+	}
+
+	report, err = c.client.GetReport(user)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.bureauRepo.AddReport(context.Background(), report); err != nil {
+		return nil, fmt.Errorf("failed to save credit report: %v", err)
+	}
+	return report, nil
+}
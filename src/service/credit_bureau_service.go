@@ -0,0 +1,208 @@
+package service
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"FinanceGolang/src/security"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CreditBureauService выгрузка кредитной истории в бюро кредитных историй: формирует
+// инкрементальные файлы с выдачами, платежами, просрочками и погашениями кредитов
+// и подписывает их PGP-ключом банка
+type CreditBureauService interface {
+	GenerateExport(now time.Time) (*model.CreditBureauExport, error)
+	ListExports(offset, limit int) ([]model.CreditBureauExport, error)
+	GetExport(id uint) (*model.CreditBureauExport, error)
+	GetPublicKey(keyID string) (string, error)
+}
+
+type creditBureauService struct {
+	bureauRepo repository.CreditBureauRepository
+	creditRepo repository.CreditRepository
+	userRepo   repository.UserRepository
+	keys       *security.KeyManager
+	memberCode string
+}
+
+func CreditBureauServiceInstance(
+	bureauRepo repository.CreditBureauRepository,
+	creditRepo repository.CreditRepository,
+	userRepo repository.UserRepository,
+	keys *security.KeyManager,
+	memberCode string,
+) CreditBureauService {
+	return &creditBureauService{
+		bureauRepo: bureauRepo,
+		creditRepo: creditRepo,
+		userRepo:   userRepo,
+		keys:       keys,
+		memberCode: memberCode,
+	}
+}
+
+// GenerateExport формирует выгрузку за период с конца предыдущей выгрузки до now
+// (первая выгрузка включает всю историю), подписывает файл и сохраняет его
+func (s *creditBureauService) GenerateExport(now time.Time) (*model.CreditBureauExport, error) {
+	var from time.Time
+	last, err := s.bureauRepo.GetLast(context.Background())
+	switch {
+	case err == nil:
+		from = last.PeriodTo
+	case !errors.Is(err, repository.ErrNotFound):
+		return nil, fmt.Errorf("failed to get last credit bureau export: %v", err)
+	}
+
+	records, err := s.collectRecords(from, now)
+	if err != nil {
+		return nil, err
+	}
+
+	content := model.FormatCreditBureauFile(s.memberCode, from, now, records)
+	keyID, signature, err := s.keys.Sign([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign credit bureau export: %v", err)
+	}
+
+	export := &model.CreditBureauExport{
+		PeriodFrom:  from,
+		PeriodTo:    now,
+		FileName:    model.CreditBureauFileName(s.memberCode, now),
+		RecordCount: len(records),
+		Content:     content,
+		Signature:   signature,
+		KeyID:       keyID,
+	}
+	if err := s.bureauRepo.Create(context.Background(), export); err != nil {
+		return nil, fmt.Errorf("failed to save credit bureau export: %v", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"file_name":    export.FileName,
+		"record_count": export.RecordCount,
+	}).Info("Сформирована выгрузка кредитной истории")
+	return export, nil
+}
+
+// ListExports возвращает выгрузки без файлов, начиная с последней
+func (s *creditBureauService) ListExports(offset, limit int) ([]model.CreditBureauExport, error) {
+	return s.bureauRepo.List(context.Background(), offset, limit)
+}
+
+// GetExport возвращает выгрузку с файлом и подписью
+func (s *creditBureauService) GetExport(id uint) (*model.CreditBureauExport, error) {
+	return s.bureauRepo.GetByID(context.Background(), id)
+}
+
+// GetPublicKey возвращает открытый ключ банка для проверки подписи, пустой keyID — активный ключ
+func (s *creditBureauService) GetPublicKey(keyID string) (string, error) {
+	return s.keys.PublicKey(keyID)
+}
+
+// collectRecords собирает события кредитов за период (from, to] в хронологическом порядке.
+// Просрочка передается по состоянию на дату выгрузки.
+func (s *creditBureauService) collectRecords(from, to time.Time) ([]model.CreditBureauRecord, error) {
+	var records []model.CreditBureauRecord
+	credits := make(map[uint]*model.Credit)
+	fios := make(map[uint]string)
+
+	opened, err := s.bureauRepo.GetOpenedCredits(context.Background(), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get opened credits: %v", err)
+	}
+	for i := range opened {
+		credit := &opened[i]
+		credits[credit.ID] = credit
+		record, err := s.newRecord(credit, model.CreditBureauEventOpened, credit.StartDate, fios)
+		if err != nil {
+			return nil, err
+		}
+		record.Amount = credit.Amount
+		records = append(records, *record)
+	}
+
+	payments, err := s.bureauRepo.GetPaidPayments(context.Background(), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paid payments: %v", err)
+	}
+	for _, payment := range payments {
+		credit, ok := credits[payment.CreditID]
+		if !ok {
+			credit, err = s.creditRepo.GetByID(context.Background(), payment.CreditID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get credit #%d: %v", payment.CreditID, err)
+			}
+			credits[credit.ID] = credit
+		}
+		record, err := s.newRecord(credit, model.CreditBureauEventPayment, *payment.PaidAt, fios)
+		if err != nil {
+			return nil, err
+		}
+		record.PaymentNumber = payment.PaymentNumber
+		record.Amount = payment.PaidAmount
+		record.DaysPastDue = payment.DaysPastDue
+		records = append(records, *record)
+	}
+
+	delinquent, err := s.bureauRepo.GetDelinquentCredits(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delinquent credits: %v", err)
+	}
+	for i := range delinquent {
+		record, err := s.newRecord(&delinquent[i], model.CreditBureauEventDelinquency, to, fios)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *record)
+	}
+
+	closed, err := s.bureauRepo.GetClosedCredits(context.Background(), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get closed credits: %v", err)
+	}
+	for i := range closed {
+		credit := &closed[i]
+		record, err := s.newRecord(credit, model.CreditBureauEventClosed, credit.LastPayment, fios)
+		if err != nil {
+			return nil, err
+		}
+		record.Amount = credit.TotalPaid
+		records = append(records, *record)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Date.Before(records[j].Date)
+	})
+	return records, nil
+}
+
+// newRecord создает запись о событии кредита с состоянием задолженности на дату выгрузки
+func (s *creditBureauService) newRecord(credit *model.Credit, event model.CreditBureauEvent, date time.Time, fios map[uint]string) (*model.CreditBureauRecord, error) {
+	fio, ok := fios[credit.UserID]
+	if !ok {
+		user, err := s.userRepo.GetByID(context.Background(), credit.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get borrower of credit #%d: %v", credit.ID, err)
+		}
+		fio = user.Fio
+		fios[credit.UserID] = fio
+	}
+
+	return &model.CreditBureauRecord{
+		CreditID:      credit.ID,
+		UserID:        credit.UserID,
+		Fio:           fio,
+		Event:         event,
+		Date:          date,
+		Balance:       credit.RemainingDebt,
+		OverdueAmount: credit.OverdueAmount,
+		DaysPastDue:   credit.DaysPastDue,
+		Status:        credit.Status,
+	}, nil
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"FinanceGolang/src/document"
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"FinanceGolang/src/security"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CreditDocumentGenerator формирует и подписывает документы по версии графика кредита.
+// Вызывается при выдаче кредита и при каждой смене версии графика в той же транзакции.
+type CreditDocumentGenerator interface {
+	GenerateDocuments(ctx context.Context, credit *model.Credit, schedule []model.PaymentSchedule, date time.Time) error
+}
+
+// CreditDocumentService документы по кредиту для заемщика: кредитный договор и график
+// платежей в форматах PDF и HTML с отсоединенной PGP-подписью банка
+type CreditDocumentService interface {
+	CreditDocumentGenerator
+	BackfillDocuments() (int, error)
+	GetDocuments(userID uint, creditID uint) ([]model.CreditDocument, error)
+	GetDocument(userID uint, creditID uint, documentID uint) (*model.CreditDocument, error)
+	GetPublicKey(keyID string) (string, error)
+}
+
+type creditDocumentService struct {
+	documentRepo repository.CreditDocumentRepository
+	creditRepo   repository.CreditRepository
+	userRepo     repository.UserRepository
+	renderer     *document.Renderer
+	keys         *security.KeyManager
+	bankName     string
+}
+
+func CreditDocumentServiceInstance(
+	documentRepo repository.CreditDocumentRepository,
+	creditRepo repository.CreditRepository,
+	userRepo repository.UserRepository,
+	renderer *document.Renderer,
+	keys *security.KeyManager,
+	bankName string,
+) CreditDocumentService {
+	return &creditDocumentService{
+		documentRepo: documentRepo,
+		creditRepo:   creditRepo,
+		userRepo:     userRepo,
+		renderer:     renderer,
+		keys:         keys,
+		bankName:     bankName,
+	}
+}
+
+// GetDocuments возвращает документы по действующей версии графика без файлов.
+// Документы формируются при выдаче кредита и при каждой смене версии графика.
+func (s *creditDocumentService) GetDocuments(userID uint, creditID uint) ([]model.CreditDocument, error) {
+	credit, err := s.getUserCredit(userID, creditID)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := s.documentRepo.GetByCreditID(context.Background(), credit.ID, credit.ScheduleVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit documents: %v", err)
+	}
+	return documents, nil
+}
+
+// GetDocument возвращает документ кредита с файлом и подписью
+func (s *creditDocumentService) GetDocument(userID uint, creditID uint, documentID uint) (*model.CreditDocument, error) {
+	credit, err := s.getUserCredit(userID, creditID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.documentRepo.GetByID(context.Background(), documentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc.CreditID != credit.ID {
+		return nil, repository.ErrNotFound
+	}
+	return doc, nil
+}
+
+// GetPublicKey возвращает открытый ключ банка для проверки подписи, пустой keyID — активный ключ
+func (s *creditDocumentService) GetPublicKey(keyID string) (string, error) {
+	return s.keys.PublicKey(keyID)
+}
+
+// GenerateDocuments формирует, подписывает и сохраняет договор и график платежей по версии
+// графика кредита на дату date. Если в ctx открыта транзакция, документы сохраняются в ней.
+func (s *creditDocumentService) GenerateDocuments(ctx context.Context, credit *model.Credit, schedule []model.PaymentSchedule, date time.Time) error {
+	borrower, err := s.userRepo.GetByID(ctx, credit.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get borrower: %v", err)
+	}
+
+	data := &document.CreditData{
+		BankName: s.bankName,
+		Date:     date,
+		Credit:   credit,
+		Borrower: borrower,
+		Schedule: schedule,
+	}
+
+	var documents []model.CreditDocument
+	for _, docType := range model.CreditDocumentTypes {
+		for _, format := range model.CreditDocumentFormats {
+			content, err := s.renderer.Render(docType, format, data)
+			if err != nil {
+				return fmt.Errorf("failed to generate credit document: %v", err)
+			}
+			keyID, signature, err := s.keys.Sign(content)
+			if err != nil {
+				return fmt.Errorf("failed to sign credit document: %v", err)
+			}
+
+			documents = append(documents, model.CreditDocument{
+				CreditID:        credit.ID,
+				UserID:          credit.UserID,
+				Type:            docType,
+				Format:          format,
+				ScheduleVersion: credit.ScheduleVersion,
+				FileName:        model.CreditDocumentFileName(credit.ID, credit.ScheduleVersion, docType, format),
+				Content:         content,
+				Signature:       signature,
+				KeyID:           keyID,
+			})
+		}
+	}
+
+	if err := s.documentRepo.CreateDocuments(ctx, documents); err != nil {
+		return fmt.Errorf("failed to save credit documents: %v", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"credit_id":        credit.ID,
+		"schedule_version": credit.ScheduleVersion,
+	}).Info("Сформированы документы по кредиту")
+	return nil
+}
+
+// BackfillDocuments формирует документы по действующей версии графика для кредитов, выданных
+// до формирования документов при выдаче. Документы первой версии датируются датой выдачи,
+// последующих — датой последнего изменения кредита. Возвращает количество кредитов,
+// получивших документы.
+func (s *creditDocumentService) BackfillDocuments() (int, error) {
+	credits, err := s.documentRepo.GetCreditsWithoutDocuments(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("could not get credits without documents: %v", err)
+	}
+
+	count := 0
+	for i := range credits {
+		credit := &credits[i]
+		schedule, err := s.creditRepo.GetPaymentSchedule(context.Background(), credit.ID)
+		if err != nil {
+			return count, fmt.Errorf("failed to get payment schedule for credit %d: %v", credit.ID, err)
+		}
+
+		date := credit.UpdatedAt
+		if credit.ScheduleVersion == 1 {
+			date = credit.StartDate
+		}
+		if err := s.GenerateDocuments(context.Background(), credit, schedule, date); err != nil {
+			return count, fmt.Errorf("credit %d: %v", credit.ID, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// getUserCredit получает кредит и проверяет, что он принадлежит пользователю
+func (s *creditDocumentService) getUserCredit(userID uint, creditID uint) (*model.Credit, error) {
+	credit, err := s.creditRepo.GetByID(context.Background(), creditID)
+	if err != nil {
+		return nil, err
+	}
+	if credit.UserID != userID {
+		return nil, ErrCreditNotOwned
+	}
+	return credit, nil
+}
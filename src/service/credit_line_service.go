@@ -0,0 +1,322 @@
+package service
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrCreditLineNotOwned кредитная линия принадлежит другому пользователю
+var ErrCreditLineNotOwned = errors.New("credit line does not belong to the user")
+
+// CreditLineService возобновляемые кредитные линии по счетам типа CREDIT: открытие и изменение
+// лимита сотрудниками банка, ежемесячные выписки с минимальным платежом и начисление процентов
+// при потере льготного периода
+type CreditLineService interface {
+	OpenCreditLine(userID uint, creditLimit float64, line *model.CreditLine) (*model.CreditLine, error)
+	ChangeLimit(creditLineID uint, creditLimit float64) (*model.CreditLine, error)
+	GetUserCreditLines(userID uint) ([]model.CreditLine, error)
+	GetUserCreditLine(userID uint, creditLineID uint) (*model.CreditLine, error)
+	GetStatements(userID uint, creditLineID uint) ([]model.CreditStatement, error)
+	ProcessStatements(now time.Time) ([]model.CreditStatement, error)
+}
+
+type creditLineService struct {
+	creditLineRepo  repository.CreditLineRepository
+	accountRepo     repository.AccountRepository
+	transactionRepo repository.TransactionRepository
+	userRepo        repository.UserRepository
+}
+
+func CreditLineServiceInstance(
+	creditLineRepo repository.CreditLineRepository,
+	accountRepo repository.AccountRepository,
+	transactionRepo repository.TransactionRepository,
+	userRepo repository.UserRepository,
+) CreditLineService {
+	return &creditLineService{
+		creditLineRepo:  creditLineRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		userRepo:        userRepo,
+	}
+}
+
+// OpenCreditLine открывает пользователю счет типа CREDIT с кредитным лимитом и кредитную линию
+// на условиях line. Незаданные условия берутся по умолчанию, день выписки — день открытия.
+func (s *creditLineService) OpenCreditLine(userID uint, creditLimit float64, line *model.CreditLine) (*model.CreditLine, error) {
+	if creditLimit <= 0 {
+		return nil, model.ErrInvalidCreditLine
+	}
+	if _, err := s.userRepo.GetByID(context.Background(), userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if line.GracePeriodDays == 0 {
+		line.GracePeriodDays = model.DefaultGracePeriodDays
+	}
+	if line.MinPaymentPercent == 0 {
+		line.MinPaymentPercent = model.DefaultMinPaymentPercent
+	}
+	if line.MinPaymentAmount == 0 {
+		line.MinPaymentAmount = model.DefaultMinPaymentAmount
+	}
+	if line.StatementDay == 0 {
+		line.StatementDay = min(now.Day(), 28)
+	}
+	if err := line.Validate(); err != nil {
+		return nil, err
+	}
+
+	account := &model.Account{
+		UserID:      userID,
+		Type:        model.AccountTypeCredit,
+		CreditLimit: creditLimit,
+		IsActive:    true,
+	}
+	if err := s.accountRepo.Create(context.Background(), account); err != nil {
+		return nil, fmt.Errorf("failed to create credit account: %v", err)
+	}
+
+	line.UserID = userID
+	line.AccountID = account.ID
+	line.Status = model.CreditLineStatusActive
+	line.NextStatementDate = line.StatementDateAfter(now)
+	if err := s.creditLineRepo.Create(context.Background(), line); err != nil {
+		return nil, fmt.Errorf("failed to create credit line: %v", err)
+	}
+	line.Account = account
+
+	logrus.WithFields(logrus.Fields{
+		"credit_line_id": line.ID,
+		"credit_limit":   creditLimit,
+		"account_id":     account.ID,
+	}).Info("Открыта кредитная линия")
+	return line, nil
+}
+
+// ChangeLimit изменяет кредитный лимит. Лимит ниже текущей задолженности
+// не требует досрочного погашения, но блокирует новые траты.
+func (s *creditLineService) ChangeLimit(creditLineID uint, creditLimit float64) (*model.CreditLine, error) {
+	if creditLimit < 0 {
+		return nil, model.ErrInvalidCreditLine
+	}
+	line, err := s.creditLineRepo.GetByID(context.Background(), creditLineID)
+	if err != nil {
+		return nil, err
+	}
+	if !line.IsActive() {
+		return nil, model.ErrCreditLineNotActive
+	}
+
+	// Сохраняется только лимит, чтобы не перезаписать баланс, измененный параллельной операцией
+	if err := s.accountRepo.UpdateCreditLimit(context.Background(), line.AccountID, creditLimit); err != nil {
+		return nil, fmt.Errorf("failed to update credit limit: %v", err)
+	}
+	account, err := s.accountRepo.GetByID(context.Background(), line.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit account: %v", err)
+	}
+	line.Account = account
+	return line, nil
+}
+
+// GetUserCreditLines возвращает кредитные линии пользователя вместе со счетами
+func (s *creditLineService) GetUserCreditLines(userID uint) ([]model.CreditLine, error) {
+	lines, err := s.creditLineRepo.GetByUserID(context.Background(), userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lines {
+		account, err := s.accountRepo.GetByID(context.Background(), lines[i].AccountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get credit account: %v", err)
+		}
+		lines[i].Account = account
+	}
+	return lines, nil
+}
+
+// GetUserCreditLine возвращает кредитную линию пользователя вместе со счетом
+func (s *creditLineService) GetUserCreditLine(userID uint, creditLineID uint) (*model.CreditLine, error) {
+	line, err := s.getUserCreditLine(userID, creditLineID)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.accountRepo.GetByID(context.Background(), line.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit account: %v", err)
+	}
+	line.Account = account
+	return line, nil
+}
+
+// GetStatements возвращает выписки по кредитной линии пользователя
+func (s *creditLineService) GetStatements(userID uint, creditLineID uint) ([]model.CreditStatement, error) {
+	line, err := s.getUserCreditLine(userID, creditLineID)
+	if err != nil {
+		return nil, err
+	}
+	return s.creditLineRepo.GetStatements(context.Background(), line.ID)
+}
+
+// ProcessStatements подводит итог по выпискам с прошедшей датой платежа и формирует выписки
+// по кредитным линиям с наступившей датой выписки. Возвращает обработанные выписки.
+func (s *creditLineService) ProcessStatements(now time.Time) ([]model.CreditStatement, error) {
+	lines, err := s.creditLineRepo.GetActive(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit lines: %v", err)
+	}
+
+	var processed []model.CreditStatement
+	for i := range lines {
+		statements, err := s.processCreditLine(&lines[i], now)
+		processed = append(processed, statements...)
+		if err != nil {
+			return processed, fmt.Errorf("failed to process credit line %d: %v", lines[i].ID, err)
+		}
+	}
+	return processed, nil
+}
+
+// processCreditLine подводит итог по выпискам кредитной линии и при наступлении даты выписки
+// формирует новую
+func (s *creditLineService) processCreditLine(line *model.CreditLine, now time.Time) ([]model.CreditStatement, error) {
+	statements, err := s.creditLineRepo.GetStatements(context.Background(), line.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get statements: %v", err)
+	}
+
+	var processed []model.CreditStatement
+	var previous *model.CreditStatement
+	for i := range statements {
+		statement := &statements[i]
+		if statement.IsDue(now) {
+			if err := s.settleStatement(line, statement, previous); err != nil {
+				return processed, err
+			}
+			processed = append(processed, *statement)
+		}
+		previous = statement
+	}
+
+	if now.Before(line.NextStatementDate) {
+		return processed, nil
+	}
+
+	statement, err := s.issueStatement(line, previous, now)
+	if err != nil {
+		return processed, err
+	}
+	return append(processed, *statement), nil
+}
+
+// issueStatement формирует выписку за период до даты выписки. Баланс на дату выписки
+// восстанавливается по текущему балансу счета и операциям после даты выписки.
+func (s *creditLineService) issueStatement(line *model.CreditLine, previous *model.CreditStatement, now time.Time) (*model.CreditStatement, error) {
+	account, err := s.accountRepo.GetByID(context.Background(), line.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit account: %v", err)
+	}
+
+	periodStart := line.PeriodStart(previous)
+	periodEnd := line.NextStatementDate
+	transactions, err := s.transactionRepo.GetByAccountAndDateRange(context.Background(), account.ID, periodStart, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %v", err)
+	}
+
+	period, after := splitTransactions(transactions, periodEnd)
+	closingBalance := account.Balance
+	for _, transaction := range after {
+		closingBalance -= model.BalanceChange(account.ID, transaction)
+	}
+
+	statement := line.IssueStatement(periodStart, periodEnd, closingBalance, period)
+	if err := s.creditLineRepo.CreateStatement(context.Background(), statement); err != nil {
+		return nil, fmt.Errorf("failed to save statement: %v", err)
+	}
+
+	line.NextStatementDate = line.StatementDateAfter(periodEnd)
+	if err := s.creditLineRepo.Update(context.Background(), line); err != nil {
+		return nil, fmt.Errorf("failed to update credit line: %v", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"credit_line_id":  line.ID,
+		"period_end":      periodEnd.Format("2006-01-02"),
+		"debt":            statement.Debt,
+		"minimum_payment": statement.MinimumPayment,
+		"due_date":        statement.DueDate.Format("2006-01-02"),
+	}).Info("Сформирована выписка по кредитной линии")
+	return statement, nil
+}
+
+// settleStatement подводит итог выписки по зачислениям до конца дня платежа и при потере
+// льготного периода списывает проценты за период выписки с кредитного счета
+func (s *creditLineService) settleStatement(line *model.CreditLine, statement *model.CreditStatement, previous *model.CreditStatement) error {
+	transactions, err := s.transactionRepo.GetByAccountAndDateRange(
+		context.Background(), statement.AccountID, statement.PeriodStart, statement.PaymentDeadline(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get transactions: %v", err)
+	}
+
+	period, after := splitTransactions(transactions, statement.PeriodEnd)
+	paid := 0.0
+	for _, transaction := range after {
+		if change := model.BalanceChange(statement.AccountID, transaction); change > 0 {
+			paid += change
+		}
+	}
+
+	interest := line.Settle(statement, previous, paid, period)
+	if interest > 0 {
+		if err := s.accountRepo.UpdateBalance(context.Background(), statement.AccountID, -interest); err != nil {
+			return fmt.Errorf("failed to charge interest: %v", err)
+		}
+		transaction := &model.Transaction{
+			Type:          model.TransactionTypeInterest,
+			Status:        model.TransactionStatusCompleted,
+			Amount:        interest,
+			FromAccountID: statement.AccountID,
+			Description: fmt.Sprintf("Проценты по кредитной линии #%d за период %s — %s",
+				line.ID, statement.PeriodStart.Format("02.01.2006"), statement.PeriodEnd.AddDate(0, 0, -1).Format("02.01.2006")),
+		}
+		if err := s.transactionRepo.Create(context.Background(), transaction); err != nil {
+			return fmt.Errorf("failed to create interest transaction: %v", err)
+		}
+	}
+
+	if err := s.creditLineRepo.UpdateStatement(context.Background(), statement); err != nil {
+		return fmt.Errorf("failed to update statement: %v", err)
+	}
+	return nil
+}
+
+func (s *creditLineService) getUserCreditLine(userID uint, creditLineID uint) (*model.CreditLine, error) {
+	line, err := s.creditLineRepo.GetByID(context.Background(), creditLineID)
+	if err != nil {
+		return nil, err
+	}
+	if line.UserID != userID {
+		return nil, ErrCreditLineNotOwned
+	}
+	return line, nil
+}
+
+// splitTransactions делит упорядоченные по времени операции на проведенные до date и после
+func splitTransactions(transactions []model.Transaction, date time.Time) ([]model.Transaction, []model.Transaction) {
+	for i, transaction := range transactions {
+		if !transaction.CreatedAt.Before(date) {
+			return transactions[:i], transactions[i:]
+		}
+	}
+	return transactions, nil
+}
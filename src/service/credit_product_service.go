@@ -0,0 +1,114 @@
+package service
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// CreditProductService управляет каталогом кредитных продуктов
+type CreditProductService interface {
+	GetActiveProducts() ([]model.CreditProduct, error)
+	ListProducts() ([]model.CreditProduct, error)
+	GetProduct(id uint) (*model.CreditProduct, error)
+	GetProductByCode(code string) (*model.CreditProduct, error)
+	CreateProduct(product *model.CreditProduct) error
+	UpdateProduct(id uint, product *model.CreditProduct) (*model.CreditProduct, error)
+	DeactivateProduct(id uint) error
+}
+
+type creditProductService struct {
+	productRepo repository.CreditProductRepository
+}
+
+func CreditProductServiceInstance(productRepo repository.CreditProductRepository) CreditProductService {
+	return &creditProductService{productRepo: productRepo}
+}
+
+// GetActiveProducts возвращает продукты, доступные для оформления
+func (s *creditProductService) GetActiveProducts() ([]model.CreditProduct, error) {
+	return s.productRepo.GetActive(context.Background())
+}
+
+// ListProducts возвращает весь каталог, включая отключенные продукты
+func (s *creditProductService) ListProducts() ([]model.CreditProduct, error) {
+	return s.productRepo.List(context.Background(), 0, -1)
+}
+
+func (s *creditProductService) GetProduct(id uint) (*model.CreditProduct, error) {
+	product, err := s.productRepo.GetByID(context.Background(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, model.ErrCreditProductNotFound
+	}
+	return product, err
+}
+
+// GetProductByCode возвращает продукт по коду. Пустой код означает продукт по умолчанию.
+func (s *creditProductService) GetProductByCode(code string) (*model.CreditProduct, error) {
+	if code == "" {
+		code = model.DefaultCreditProductCode
+	}
+	product, err := s.productRepo.GetByCode(context.Background(), code)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", model.ErrCreditProductNotFound, code)
+	}
+	return product, err
+}
+
+func (s *creditProductService) CreateProduct(product *model.CreditProduct) error {
+	if err := product.Validate(); err != nil {
+		return err
+	}
+	if err := s.checkCodeAvailable(product.Code, 0); err != nil {
+		return err
+	}
+	return s.productRepo.Create(context.Background(), product)
+}
+
+// UpdateProduct меняет условия продукта. Условия уже выданных кредитов не меняются.
+func (s *creditProductService) UpdateProduct(id uint, product *model.CreditProduct) (*model.CreditProduct, error) {
+	existing, err := s.GetProduct(id)
+	if err != nil {
+		return nil, err
+	}
+
+	product.Model = existing.Model
+	if err := product.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkCodeAvailable(product.Code, id); err != nil {
+		return nil, err
+	}
+	if err := s.productRepo.Update(context.Background(), product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// DeactivateProduct снимает продукт с продажи. Продукт остается в каталоге,
+// так как на него ссылаются выданные кредиты.
+func (s *creditProductService) DeactivateProduct(id uint) error {
+	product, err := s.GetProduct(id)
+	if err != nil {
+		return err
+	}
+	product.IsActive = false
+	return s.productRepo.Update(context.Background(), product)
+}
+
+// checkCodeAvailable проверяет, что код не занят другим продуктом
+func (s *creditProductService) checkCodeAvailable(code string, productID uint) error {
+	existing, err := s.productRepo.GetByCode(context.Background(), code)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	case existing.ID != productID:
+		return fmt.Errorf("%w: credit product %s", repository.ErrAlreadyExists, code)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CreditRestructuringService реструктуризация кредитов сотрудниками банка: кредитные каникулы,
+// увеличение срока и перенос дня платежа
+type CreditRestructuringService interface {
+	Restructure(operatorID uint, creditID uint, request model.RestructuringRequest) (*model.CreditRestructuring, []model.PaymentSchedule, error)
+	GetRestructurings(creditID uint) ([]model.CreditRestructuring, error)
+	GetScheduleVersion(creditID uint, version int) ([]model.ArchivedPayment, error)
+}
+
+type creditRestructuringService struct {
+	creditRepo repository.CreditRepository
+	documents  CreditDocumentGenerator
+}
+
+func CreditRestructuringServiceInstance(creditRepo repository.CreditRepository, documents CreditDocumentGenerator) CreditRestructuringService {
+	return &creditRestructuringService{creditRepo: creditRepo, documents: documents}
+}
+
+// Restructure пересчитывает неоплаченную часть графика по условиям реструктуризации.
+// Действующий график сохраняется в архиве, пересчитанный график получает следующий номер версии,
+// по которому формируются новые договор и график платежей.
+func (s *creditRestructuringService) Restructure(operatorID uint, creditID uint, request model.RestructuringRequest) (*model.CreditRestructuring, []model.PaymentSchedule, error) {
+	credit, err := s.creditRepo.GetByID(context.Background(), creditID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if credit.Status != model.CreditStatusActive && credit.Status != model.CreditStatusOverdue {
+		return nil, nil, model.ErrCreditNotActive
+	}
+
+	schedule, err := s.creditRepo.GetPaymentSchedule(context.Background(), credit.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get payment schedule: %v", err)
+	}
+
+	now := time.Now()
+	restructuring, recalculated, err := credit.Restructure(schedule, request, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Архив, новый график, кредит и запись о реструктуризации сохраняются вместе
+	err = s.creditRepo.InTransaction(context.Background(), func(ctx context.Context) error {
+		schedule, err = replaceSchedule(ctx, s.creditRepo, credit, schedule, restructuring.FromPaymentNumber, recalculated)
+		if err != nil {
+			return err
+		}
+
+		credit.EndDate = restructuring.NewEndDate
+		credit.ApplySchedule(schedule, now)
+		if err := s.creditRepo.Update(ctx, credit); err != nil {
+			return fmt.Errorf("failed to update credit: %v", err)
+		}
+
+		restructuring.Version = credit.ScheduleVersion
+		restructuring.OperatorID = operatorID
+		if err := s.creditRepo.AddRestructuring(ctx, restructuring); err != nil {
+			return fmt.Errorf("failed to save restructuring: %v", err)
+		}
+		return s.documents.GenerateDocuments(ctx, credit, schedule, now)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"credit_id":        credit.ID,
+		"type":             restructuring.Type,
+		"schedule_version": restructuring.Version,
+	}).Info("Кредит реструктурирован")
+	return restructuring, schedule, nil
+}
+
+// replaceSchedule сохраняет действующий график в архиве под текущей версией, заменяет неоплаченные
+// платежи начиная с fromNumber и увеличивает версию графика кредита. Возвращает новый график.
+// Кредит с новой версией и документы по ней сохраняет вызывающий в той же транзакции базы данных
+// (см. InTransaction).
+func replaceSchedule(ctx context.Context, creditRepo repository.CreditRepository, credit *model.Credit, schedule []model.PaymentSchedule, fromNumber int, recalculated []model.PaymentSchedule) ([]model.PaymentSchedule, error) {
+	if err := creditRepo.ArchivePaymentSchedule(ctx, model.ArchiveSchedule(schedule, credit.ScheduleVersion)); err != nil {
+		return nil, fmt.Errorf("failed to archive payment schedule: %v", err)
+	}
+	if err := creditRepo.ReplacePaymentSchedule(ctx, credit.ID, fromNumber, recalculated); err != nil {
+		return nil, fmt.Errorf("failed to update payment schedule: %v", err)
+	}
+	credit.ScheduleVersion++
+
+	schedule, err := creditRepo.GetPaymentSchedule(ctx, credit.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment schedule: %v", err)
+	}
+	return schedule, nil
+}
+
+// GetRestructurings возвращает реструктуризации кредита по порядку
+func (s *creditRestructuringService) GetRestructurings(creditID uint) ([]model.CreditRestructuring, error) {
+	if _, err := s.creditRepo.GetByID(context.Background(), creditID); err != nil {
+		return nil, err
+	}
+	return s.creditRepo.GetRestructurings(context.Background(), creditID)
+}
+
+// GetScheduleVersion возвращает версию графика платежей. Действующая версия берется
+// из текущего графика, предыдущие — из архива.
+func (s *creditRestructuringService) GetScheduleVersion(creditID uint, version int) ([]model.ArchivedPayment, error) {
+	credit, err := s.creditRepo.GetByID(context.Background(), creditID)
+	if err != nil {
+		return nil, err
+	}
+	if version < 1 || version > credit.ScheduleVersion {
+		return nil, fmt.Errorf("schedule version %d: %w", version, repository.ErrNotFound)
+	}
+
+	if version == credit.ScheduleVersion {
+		schedule, err := s.creditRepo.GetPaymentSchedule(context.Background(), credit.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get payment schedule: %v", err)
+		}
+		return model.ArchiveSchedule(schedule, version), nil
+	}
+	return s.creditRepo.GetArchivedSchedule(context.Background(), credit.ID, version)
+}
@@ -6,25 +6,48 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 
 	// "strconv"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
 type CreditService interface {
-	CreateCredit(userID uint, accountID uint, amount float64, termMonths int, description string) (*model.Credit, error)
+	CreateCredit(ctx context.Context, userID uint, accountID uint, product *model.CreditProduct, amount float64, termMonths int, repaymentType model.RepaymentType, rateDiscount float64, description string) (*model.Credit, error)
 	GetCreditByID(id uint) (*model.Credit, error)
 	GetUserCredits(userID uint) ([]model.Credit, error)
 	GetPaymentSchedule(creditID uint) ([]model.PaymentSchedule, error)
+	BackfillPaymentSchedules() (int, error)
 	ProcessPayment(creditID uint, paymentNumber int) error
-	ProcessOverduePayments() error
+	ProcessOverduePayments() ([]model.AutoDebitAttempt, error)
+	GetPayoffQuote(userID uint, creditID uint) (*model.PayoffQuote, error)
+	EarlyRepayment(userID uint, creditID uint, amount float64, mode model.EarlyRepaymentMode) (*model.Credit, []model.PaymentSchedule, error)
+	GetRateHistory(userID uint, creditID uint) ([]model.CreditRateHistory, error)
+	GetCreditSecurity(userID uint, creditID uint) (*model.CreditSecurity, error)
+	GetGuaranteedCredits(userID uint) ([]model.Credit, error)
+	QuoteCredit(product *model.CreditProduct, amount float64, termMonths int, repaymentType model.RepaymentType) (*model.CreditQuote, error)
+	RepriceFloatingCredits(keyRate float64) ([]CreditRateChange, error)
+}
+
+// CreditRateChange пересмотр ставки по кредиту с плавающей ставкой
+type CreditRateChange struct {
+	Credit model.Credit
+	Change model.CreditRateHistory
 }
 
+// ErrCreditNotOwned кредит принадлежит другому пользователю
+var ErrCreditNotOwned = errors.New("credit does not belong to the user")
+
 type creditService struct {
-	creditRepo      repository.CreditRepository
-	accountRepo     repository.AccountRepository
-	transactionRepo repository.TransactionRepository
-	keyRateService  *ExternalService
+	creditRepo       repository.CreditRepository
+	accountRepo      repository.AccountRepository
+	transactionRepo  repository.TransactionRepository
+	keyRateService   *ExternalService
+	overdueService   OverdueService
+	autoDebitService AutoDebitService
+	documents        CreditDocumentGenerator
 }
 
 func CreditServiceInstance(
@@ -32,18 +55,33 @@ func CreditServiceInstance(
 	accountRepo repository.AccountRepository,
 	transactionRepo repository.TransactionRepository,
 	keyRateService *ExternalService,
+	overdueService OverdueService,
+	autoDebitService AutoDebitService,
+	documents CreditDocumentGenerator,
 ) CreditService {
 	return &creditService{
-		creditRepo:      creditRepo,
-		accountRepo:     accountRepo,
-		transactionRepo: transactionRepo,
-		keyRateService:  keyRateService,
+		creditRepo:       creditRepo,
+		accountRepo:      accountRepo,
+		transactionRepo:  transactionRepo,
+		keyRateService:   keyRateService,
+		overdueService:   overdueService,
+		autoDebitService: autoDebitService,
+		documents:        documents,
 	}
 }
 
-func (s *creditService) CreateCredit(userID uint, accountID uint, amount float64, termMonths int, description string) (*model.Credit, error) {
+// CreateCredit выдает кредит по продукту. Ставка продукта уменьшается на скидку по скорингу,
+// комиссия за выдачу и страховая премия списываются со счета после зачисления кредита.
+// Кредит, график, зачисление, комиссии и подписанные документы сохраняются одной транзакцией
+// базы данных;
+// если в ctx уже открыта транзакция, выдача выполняется в ней.
+func (s *creditService) CreateCredit(ctx context.Context, userID uint, accountID uint, product *model.CreditProduct, amount float64, termMonths int, repaymentType model.RepaymentType, rateDiscount float64, description string) (*model.Credit, error) {
+	if _, err := product.CheckTerms(amount, termMonths, repaymentType); err != nil {
+		return nil, err
+	}
+
 	// Проверяем, что счет принадлежит пользователю
-	account, err := s.accountRepo.GetByID(context.Background(), accountID)
+	account, err := s.accountRepo.GetByID(ctx, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get account: %v", err)
 	}
@@ -51,54 +89,151 @@ func (s *creditService) CreateCredit(userID uint, accountID uint, amount float64
 		return nil, errors.New("account does not belong to the user")
 	}
 
-	// Получаем текущую ключевую ставку
-	keyRate, err := s.keyRateService.GetKeyRate()
+	now := time.Now()
+	credit, schedule, err := s.buildCredit(product, amount, termMonths, repaymentType, rateDiscount, now)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get key rate: %v", err)
+		return nil, err
 	}
+	credit.UserID = userID
+	credit.AccountID = accountID
 
-	// Рассчитываем процентную ставку (ключевая ставка + 5%)
-	interestRate := keyRate + 5.0
+	err = s.creditRepo.InTransaction(ctx, func(ctx context.Context) error {
+		// Сохраняем кредит
+		if err := s.creditRepo.Create(ctx, credit); err != nil {
+			return fmt.Errorf("failed to create credit: %v", err)
+		}
 
-	// Создаем кредит
-	credit := &model.Credit{
-		UserID:        userID,
-		AccountID:     accountID,
-		Amount:        amount,
-		Term:          termMonths,
-		InterestRate:  interestRate,
-		Status:        model.CreditStatusActive,
-		StartDate:     time.Now(),
-		EndDate:       time.Now().AddDate(0, termMonths, 0),
-		PaymentDay:    time.Now().Day(),
-		NextPayment:   time.Now().AddDate(0, 1, 0),
-		RemainingDebt: amount,
+		// Сохраняем график платежей
+		for i := range schedule {
+			schedule[i].CreditID = credit.ID
+		}
+		if err := s.creditRepo.CreatePaymentSchedule(ctx, schedule); err != nil {
+			return fmt.Errorf("failed to save payment schedule: %v", err)
+		}
+
+		// Ставка на дату выдачи открывает историю ставок по кредиту
+		if err := s.creditRepo.AddRateHistory(ctx, &model.CreditRateHistory{
+			CreditID:       credit.ID,
+			KeyRate:        credit.KeyRate,
+			InterestRate:   credit.InterestRate,
+			MonthlyPayment: schedule[0].TotalAmount,
+			EffectiveFrom:  now,
+		}); err != nil {
+			return fmt.Errorf("failed to save rate history: %v", err)
+		}
+
+		// Зачисляем сумму кредита на счет пользователя
+		if err := s.accountRepo.UpdateBalance(ctx, accountID, amount); err != nil {
+			return fmt.Errorf("failed to update account balance: %v", err)
+		}
+
+		// Создаем транзакцию о зачислении кредита
+		transaction := &model.Transaction{
+			Type:        model.TransactionTypeCredit,
+			ToAccountID: accountID,
+			Amount:      amount,
+			Description: fmt.Sprintf("Зачисление по кредиту #%d: %s", credit.ID, description),
+			Status:      model.TransactionStatusCompleted,
+		}
+		if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to create transaction: %v", err)
+		}
+
+		// Списываем комиссию за выдачу и страховую премию
+		charges := []struct {
+			amount      float64
+			description string
+		}{
+			{credit.IssueFee, fmt.Sprintf("Комиссия за выдачу кредита #%d", credit.ID)},
+			{credit.InsurancePremium, fmt.Sprintf("Страховая премия по кредиту #%d", credit.ID)},
+		}
+		for _, charge := range charges {
+			if charge.amount <= 0 {
+				continue
+			}
+
+			if err := s.accountRepo.UpdateBalance(ctx, accountID, -charge.amount); err != nil {
+				return fmt.Errorf("failed to update account balance: %v", err)
+			}
+
+			fee := &model.Transaction{
+				Type:          model.TransactionTypeFee,
+				FromAccountID: accountID,
+				Amount:        charge.amount,
+				Description:   charge.description,
+				Status:        model.TransactionStatusCompleted,
+			}
+			if err := s.transactionRepo.Create(ctx, fee); err != nil {
+				return fmt.Errorf("failed to create fee transaction: %v", err)
+			}
+		}
+
+		// Договор и график платежей формируются на дату выдачи
+		return s.documents.GenerateDocuments(ctx, credit, schedule, now)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Сохраняем кредит
-	if err := s.creditRepo.Create(context.Background(), credit); err != nil {
-		return nil, fmt.Errorf("failed to create credit: %v", err)
+	return credit, nil
+}
+
+// QuoteCredit рассчитывает условия кредита по продукту на текущую ключевую ставку без выдачи
+// кредита. Скидка по скорингу определяется только при рассмотрении заявки и не учитывается.
+func (s *creditService) QuoteCredit(product *model.CreditProduct, amount float64, termMonths int, repaymentType model.RepaymentType) (*model.CreditQuote, error) {
+	credit, schedule, err := s.buildCredit(product, amount, termMonths, repaymentType, 0, time.Now())
+	if err != nil {
+		return nil, err
 	}
+	return model.NewCreditQuote(product, credit, schedule), nil
+}
 
-	// Зачисляем сумму кредита на счет пользователя
-	account.Balance += amount
-	if err := s.accountRepo.Update(context.Background(), account); err != nil {
-		return nil, fmt.Errorf("failed to update account balance: %v", err)
+// buildCredit рассчитывает ставку по продукту на текущую ключевую ставку с учетом скидки по скорингу,
+// график платежей и полную стоимость кредита с датой выдачи now
+func (s *creditService) buildCredit(product *model.CreditProduct, amount float64, termMonths int, repaymentType model.RepaymentType, rateDiscount float64, now time.Time) (*model.Credit, []model.PaymentSchedule, error) {
+	repaymentType, err := product.CheckTerms(amount, termMonths, repaymentType)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Создаем транзакцию о зачислении кредита
-	transaction := &model.Transaction{
-		Type:        model.TransactionTypeCredit,
-		ToAccountID: accountID,
-		Amount:      amount,
-		Description: fmt.Sprintf("Зачисление по кредиту #%d: %s", credit.ID, description),
-		Status:      model.TransactionStatusCompleted,
+	// Получаем текущую ключевую ставку
+	keyRate, err := s.keyRateService.GetKeyRate()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get key rate: %v", err)
 	}
-	if err := s.transactionRepo.Create(context.Background(), transaction); err != nil {
-		return nil, fmt.Errorf("failed to create transaction: %v", err)
+
+	interestRate := product.Rate(keyRate) - rateDiscount
+	credit := &model.Credit{
+		ProductID:        &product.ID,
+		Amount:           amount,
+		IssueFee:         product.Fee(amount),
+		InsurancePremium: product.Insurance(amount),
+		Term:             termMonths,
+		InterestRate:     interestRate,
+		RateType:         product.RateType,
+		KeyRate:          keyRate,
+		RepaymentType:    repaymentType,
+		Status:           model.CreditStatusActive,
+		StartDate:        now,
+		PaymentDay:       now.Day(),
+	}
+	if credit.IsFloating() {
+		credit.RateMargin = math.Round((interestRate-keyRate)*100) / 100
 	}
 
-	return credit, nil
+	// Строим график платежей, даты и остаток долга кредита берутся из него
+	schedule := credit.GeneratePaymentSchedule()
+	credit.ApplySchedule(schedule, now)
+	if len(schedule) > 0 {
+		credit.EndDate = schedule[len(schedule)-1].DueDate
+	}
+
+	// Полная стоимость кредита раскрывается по графику на дату выдачи
+	fullCost := credit.CalculateFullCost(schedule)
+	credit.FullCostRate = fullCost.Rate
+	credit.FullCostAmount = fullCost.Amount
+
+	return credit, schedule, nil
 }
 
 func (s *creditService) GetCreditByID(id uint) (*model.Credit, error) {
@@ -115,53 +250,92 @@ func (s *creditService) GetPaymentSchedule(creditID uint) ([]model.PaymentSchedu
 		return nil, err
 	}
 
-	var schedule []model.PaymentSchedule
-	remainingAmount := credit.Amount
-	monthlyRate := credit.InterestRate / 12 / 100
-
-	for i := 1; i <= credit.Term; i++ {
-		interestAmount := remainingAmount * monthlyRate
-		principalAmount := credit.CalculateMonthlyPayment() - interestAmount
-		remainingAmount -= principalAmount
-
-		schedule = append(schedule, model.PaymentSchedule{
-			CreditID:      credit.ID,
-			PaymentNumber: i,
-			DueDate:       credit.StartDate.AddDate(0, i, 0),
-			Amount:        credit.CalculateMonthlyPayment(),
-			Interest:      interestAmount,
-			Principal:     principalAmount,
-			TotalAmount:   credit.CalculateMonthlyPayment(),
-			Status:        model.PaymentStatusPending,
-		})
+	schedule, err := s.creditRepo.GetPaymentSchedule(context.Background(), credit.ID)
+	if err != nil {
+		return nil, err
 	}
 
 	return schedule, nil
 }
 
-func (s *creditService) ProcessPayment(creditID uint, paymentNumber int) error {
-	// Получаем кредит
-	credit, err := s.creditRepo.GetByID(context.Background(), creditID)
+// BackfillPaymentSchedules сохраняет график платежей для кредитов, оформленных до хранения графика.
+// Оплаченными отмечаются платежи, по которым есть проведенные транзакции погашения, а также
+// первые платежи в пределах суммы, уже внесенной по кредиту. Возвращает количество кредитов,
+// получивших график.
+func (s *creditService) BackfillPaymentSchedules() (int, error) {
+	credits, err := s.creditRepo.GetCreditsWithoutSchedule(context.Background())
 	if err != nil {
-		return fmt.Errorf("failed to get credit: %v", err)
+		return 0, fmt.Errorf("could not get credits without payment schedule: %v", err)
+	}
+	if len(credits) == 0 {
+		return 0, nil
 	}
 
-	// Проверяем, не был ли уже оплачен этот платеж
 	transactions, err := s.transactionRepo.GetByType(context.Background(), model.TransactionTypePayment)
 	if err != nil {
-		return fmt.Errorf("failed to get payment transactions: %v", err)
+		return 0, fmt.Errorf("failed to get payment transactions: %v", err)
 	}
 
-	for _, t := range transactions {
-		if t.FromAccountID == credit.AccountID && t.Status == model.TransactionStatusCompleted {
-			// Проверяем номер платежа в описании
-			var paidPaymentNumber int
-			_, err := fmt.Sscanf(t.Description, "Платеж по кредиту #%d, платеж #%d", &creditID, &paidPaymentNumber)
-			if err == nil && paidPaymentNumber == paymentNumber {
-				return fmt.Errorf("payment #%d already processed", paymentNumber)
+	count := 0
+	for i := range credits {
+		credit := &credits[i]
+		schedule := credit.GeneratePaymentSchedule()
+		if len(schedule) == 0 {
+			continue
+		}
+
+		paidAt := legacyPaymentDates(credit, transactions)
+		paid := 0.0
+		for j := range schedule {
+			payment := &schedule[j]
+			at, found := paidAt[payment.PaymentNumber]
+			// Суммы старого графика могут отличаться от нового на копейки округления
+			covered := paid+payment.TotalAmount <= credit.TotalPaid+0.01*float64(payment.PaymentNumber)
+			if !found && !covered && credit.Status != model.CreditStatusPaid {
+				continue
 			}
+			if !found {
+				at = credit.UpdatedAt
+			}
+			payment.ApplyPayment(payment.TotalAmount, at)
+			paid += payment.TotalAmount
+		}
+
+		if err := s.creditRepo.CreatePaymentSchedule(context.Background(), schedule); err != nil {
+			return count, fmt.Errorf("failed to save payment schedule for credit %d: %v", credit.ID, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// legacyPaymentDates возвращает даты проведенных транзакций погашения кредита по номерам платежей
+func legacyPaymentDates(credit *model.Credit, transactions []model.Transaction) map[int]time.Time {
+	dates := make(map[int]time.Time)
+	for _, t := range transactions {
+		if t.FromAccountID != credit.AccountID || t.Status != model.TransactionStatusCompleted {
+			continue
+		}
+		var creditID uint
+		var paymentNumber int
+		if _, err := fmt.Sscanf(t.Description, "Платеж по кредиту #%d, платеж #%d", &creditID, &paymentNumber); err != nil || creditID != credit.ID {
+			continue
+		}
+		dates[paymentNumber] = t.CreatedAt
+		if t.CompletedAt != nil {
+			dates[paymentNumber] = *t.CompletedAt
 		}
 	}
+	return dates
+}
+
+func (s *creditService) ProcessPayment(creditID uint, paymentNumber int) error {
+	// Получаем кредит
+	credit, err := s.creditRepo.GetByID(context.Background(), creditID)
+	if err != nil {
+		return fmt.Errorf("failed to get credit: %v", err)
+	}
 
 	// Получаем график платежей
 	schedule, err := s.GetPaymentSchedule(creditID)
@@ -181,56 +355,59 @@ func (s *creditService) ProcessPayment(creditID uint, paymentNumber int) error {
 		return errors.New("payment not found")
 	}
 
+	// Проверяем, не был ли уже оплачен этот платеж
+	if payment.IsPaid() {
+		return fmt.Errorf("payment #%d already processed", paymentNumber)
+	}
+
+	// По просроченному платежу доначисляем неустойку на дату списания
+	now := time.Now()
+	if payment.IsDue(now) {
+		if err := s.overdueService.AccrueCredit(credit, schedule, now); err != nil {
+			return err
+		}
+	}
+
 	// Проверяем баланс счета
 	account, err := s.accountRepo.GetByID(context.Background(), credit.AccountID)
 	if err != nil {
 		return fmt.Errorf("failed to get account: %v", err)
 	}
 
-	if account.Balance < payment.TotalAmount {
-		// Если средств недостаточно, начисляем штраф
-		penalty := payment.TotalAmount * 0.1
-		payment.TotalAmount += penalty
-		credit.Status = model.CreditStatusOverdue
-		if err := s.creditRepo.Update(context.Background(), credit); err != nil {
-			return fmt.Errorf("failed to update credit status: %v", err)
-		}
-		return errors.New("insufficient funds, penalty applied")
+	amount := payment.AmountDue()
+	if account.Balance < amount {
+		return model.ErrInsufficientFunds
 	}
 
 	// Списываем средства со счета
-	account.Balance -= payment.TotalAmount
+	account.Balance -= amount
 	if err := s.accountRepo.Update(context.Background(), account); err != nil {
 		return fmt.Errorf("failed to update account balance: %v", err)
 	}
 
+	// Распределяем платеж: неустойка, проценты, основной долг
+	allocation := payment.ApplyPayment(amount, now)
+	if err := s.creditRepo.UpdatePaymentSchedule(context.Background(), payment); err != nil {
+		return fmt.Errorf("failed to update payment schedule: %v", err)
+	}
+
 	// Создаем транзакцию о платеже
 	transaction := &model.Transaction{
 		Type:          model.TransactionTypePayment,
 		FromAccountID: credit.AccountID,
-		Amount:        payment.TotalAmount,
-		Description:   fmt.Sprintf("Платеж по кредиту #%d, платеж #%d", credit.ID, paymentNumber),
-		Status:        model.TransactionStatusCompleted,
+		Amount:        amount,
+		Description: fmt.Sprintf("Платеж по кредиту #%d, платеж #%d: неустойка %.2f, проценты %.2f, основной долг %.2f",
+			credit.ID, paymentNumber, allocation.Penalty, allocation.Interest, allocation.Principal),
+		Status: model.TransactionStatusCompleted,
 	}
 	if err := s.transactionRepo.Create(context.Background(), transaction); err != nil {
 		return fmt.Errorf("failed to create transaction: %v", err)
 	}
 
-	// Обновляем статус платежа
-	payment.Status = model.PaymentStatusPaid
-	now := time.Now()
-	payment.PaidAt = &now
-
-	// Обновляем кредит
-	credit.TotalPaid += payment.TotalAmount
-	credit.RemainingDebt = credit.CalculateRemainingDebt()
+	// Обновляем кредит по сохраненному графику
+	credit.TotalPaid += amount
 	credit.LastPayment = now
-	credit.NextPayment = credit.CalculateNextPaymentDate()
-
-	// Если это последний платеж, закрываем кредит
-	if paymentNumber == credit.Term {
-		credit.Status = model.CreditStatusPaid
-	}
+	credit.ApplySchedule(schedule, now)
 
 	if err := s.creditRepo.Update(context.Background(), credit); err != nil {
 		return fmt.Errorf("failed to update credit: %v", err)
@@ -239,35 +416,297 @@ func (s *creditService) ProcessPayment(creditID uint, paymentNumber int) error {
 	return nil
 }
 
-func (s *creditService) ProcessOverduePayments() error {
-	// Получаем просроченные кредиты
+// ProcessOverduePayments начисляет неустойку по просроченным платежам и списывает их по порядку
+// со счета кредита и резервных счетов заемщика. Возвращает попытки списания.
+func (s *creditService) ProcessOverduePayments() ([]model.AutoDebitAttempt, error) {
+	now := time.Now()
+	if err := s.overdueService.AccrueOverdue(now); err != nil {
+		return nil, err
+	}
+
+	// Получаем кредиты с наступившим сроком платежа
 	overdueCredits, err := s.creditRepo.GetOverdueCredits(context.Background())
 	if err != nil {
-		return fmt.Errorf("failed to get overdue credits: %v", err)
+		return nil, fmt.Errorf("failed to get overdue credits: %v", err)
+	}
+
+	var attempts []model.AutoDebitAttempt
+	for i := range overdueCredits {
+		credit := &overdueCredits[i]
+		schedule, err := s.creditRepo.GetPaymentSchedule(context.Background(), credit.ID)
+		if err != nil {
+			return attempts, fmt.Errorf("failed to get payment schedule for credit %d: %v", credit.ID, err)
+		}
+
+		for j := range schedule {
+			payment := &schedule[j]
+			if !payment.IsDue(now) {
+				continue
+			}
+			paymentAttempts, err := s.autoDebitService.CollectPayment(credit, schedule, payment, now)
+			attempts = append(attempts, paymentAttempts...)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"credit_id":      credit.ID,
+					"payment_number": payment.PaymentNumber,
+					"error":          err.Error(),
+				}).Error("Платеж по кредиту не проведен")
+				break
+			}
+			// Следующий платеж списывается только после полного погашения предыдущего
+			if !payment.IsPaid() {
+				logrus.WithFields(logrus.Fields{
+					"credit_id":      credit.ID,
+					"payment_number": payment.PaymentNumber,
+					"amount_due":     payment.AmountDue(),
+				}).Warn("Платеж по кредиту не погашен: недостаточно средств")
+				break
+			}
+		}
+	}
+
+	return attempts, nil
+}
+
+func (s *creditService) GetPayoffQuote(userID uint, creditID uint) (*model.PayoffQuote, error) {
+	credit, err := s.getUserCredit(userID, creditID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.GetPaymentSchedule(credit.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment schedule: %v", err)
+	}
+
+	return credit.CalculatePayoff(schedule, time.Now())
+}
+
+func (s *creditService) EarlyRepayment(userID uint, creditID uint, amount float64, mode model.EarlyRepaymentMode) (*model.Credit, []model.PaymentSchedule, error) {
+	if amount <= 0 {
+		return nil, nil, model.ErrInvalidPaymentAmount
+	}
+
+	credit, err := s.getUserCredit(userID, creditID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if credit.Status != model.CreditStatusActive {
+		return nil, nil, model.ErrCreditNotActive
+	}
+
+	schedule, err := s.GetPaymentSchedule(credit.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get payment schedule: %v", err)
+	}
+
+	now := time.Now()
+	quote, err := credit.CalculatePayoff(schedule, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Сумма не меньше полной задолженности закрывает кредит, списывается только задолженность
+	fullRepayment := amount >= quote.PayoffAmount
+	if fullRepayment {
+		amount = quote.PayoffAmount
+	} else {
+		if err := mode.Validate(); err != nil {
+			return nil, nil, err
+		}
+		if amount <= quote.AccruedInterest+quote.DeferredInterest {
+			return nil, nil, model.ErrAmountBelowAccrued
+		}
+	}
+
+	account, err := s.accountRepo.GetByID(context.Background(), credit.AccountID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get account: %v", err)
+	}
+	if account.Balance < amount {
+		return nil, nil, model.ErrInsufficientFunds
+	}
+
+	// Пересчитываем неоплаченную часть графика
+	var remaining []model.PaymentSchedule
+	for _, payment := range schedule {
+		if !payment.IsPaid() {
+			remaining = append(remaining, payment)
+		}
+	}
+
+	var recalculated []model.PaymentSchedule
+	if fullRepayment {
+		// Полное погашение фиксируется одним оплаченным платежом на дату погашения
+		payoff := model.PaymentSchedule{
+			CreditID:      credit.ID,
+			PaymentNumber: quote.NextPaymentNumber,
+			DueDate:       now,
+			Amount:        quote.PayoffAmount,
+			Interest:      math.Round((quote.AccruedInterest+quote.DeferredInterest)*100) / 100,
+			Principal:     quote.OutstandingPrincipal,
+			TotalAmount:   quote.PayoffAmount,
+			Status:        model.PaymentStatusPending,
+		}
+		payoff.ApplyPayment(quote.PayoffAmount, now)
+		recalculated = []model.PaymentSchedule{payoff}
+	} else {
+		// Сумма сначала гасит начисленные и отсроченные проценты, затем основной долг
+		principal := quote.OutstandingPrincipal - (amount - quote.AccruedInterest - quote.DeferredInterest)
+		for i := range remaining {
+			remaining[i].DeferredInterest = 0
+		}
+		// Во время кредитных каникул проценты уже начислены до их окончания
+		interestFrom := now
+		if quote.InterestFrom.After(now) {
+			interestFrom = quote.InterestFrom
+		}
+		recalculated = credit.RecalculateSchedule(remaining, principal, mode, interestFrom)
+	}
+
+	// Списание, пересчитанный график, кредит и документы по новой версии графика сохраняются
+	// одной транзакцией базы данных
+	err = s.creditRepo.InTransaction(context.Background(), func(ctx context.Context) error {
+		debited, err := s.accountRepo.Debit(ctx, credit.AccountID, amount)
+		if err != nil {
+			return fmt.Errorf("failed to update account balance: %v", err)
+		}
+		if !debited {
+			return model.ErrInsufficientFunds
+		}
+
+		transaction := &model.Transaction{
+			Type:          model.TransactionTypePayment,
+			FromAccountID: credit.AccountID,
+			Amount:        amount,
+			Description:   fmt.Sprintf("Досрочное погашение по кредиту #%d", credit.ID),
+			Status:        model.TransactionStatusCompleted,
+		}
+		if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to create transaction: %v", err)
+		}
+
+		schedule, err = replaceSchedule(ctx, s.creditRepo, credit, schedule, quote.NextPaymentNumber, recalculated)
+		if err != nil {
+			return err
+		}
+
+		// Обновляем кредит по пересчитанному графику
+		credit.TotalPaid += amount
+		credit.LastPayment = now
+		credit.ApplySchedule(schedule, now)
+		if len(schedule) > 0 {
+			credit.EndDate = schedule[len(schedule)-1].DueDate
+		}
+		if err := s.creditRepo.Update(ctx, credit); err != nil {
+			return fmt.Errorf("failed to update credit: %v", err)
+		}
+		return s.documents.GenerateDocuments(ctx, credit, schedule, now)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return credit, schedule, nil
+}
+
+// GetRateHistory возвращает историю ставок по кредиту пользователя
+func (s *creditService) GetRateHistory(userID uint, creditID uint) ([]model.CreditRateHistory, error) {
+	credit, err := s.getUserCredit(userID, creditID)
+	if err != nil {
+		return nil, err
+	}
+	return s.creditRepo.GetRateHistory(context.Background(), credit.ID)
+}
+
+// GetCreditSecurity возвращает залог и поручителей по кредиту клиента
+func (s *creditService) GetCreditSecurity(userID uint, creditID uint) (*model.CreditSecurity, error) {
+	credit, err := s.getUserCredit(userID, creditID)
+	if err != nil {
+		return nil, err
 	}
+	return s.creditRepo.GetCreditSecurity(context.Background(), credit.ID)
+}
+
+// GetGuaranteedCredits возвращает кредиты других клиентов, по которым пользователь — поручитель
+func (s *creditService) GetGuaranteedCredits(userID uint) ([]model.Credit, error) {
+	return s.creditRepo.GetGuaranteedCredits(context.Background(), userID)
+}
+
+// RepriceFloatingCredits пересматривает ставки кредитов с плавающей ставкой, если ключевая
+// ставка изменилась с последнего пересмотра. Платежи пересчитываются со следующего периода.
+func (s *creditService) RepriceFloatingCredits(keyRate float64) ([]CreditRateChange, error) {
+	credits, err := s.creditRepo.GetFloatingCredits(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get floating rate credits: %v", err)
+	}
+
+	now := time.Now()
+	var changes []CreditRateChange
+	for i := range credits {
+		credit := &credits[i]
+		if credit.KeyRate == keyRate {
+			continue
+		}
 
-	for _, credit := range overdueCredits {
-		// Получаем график платежей
 		schedule, err := s.GetPaymentSchedule(credit.ID)
 		if err != nil {
-			return fmt.Errorf("failed to get payment schedule for credit %d: %v", credit.ID, err)
-		}
-
-		// Находим просроченный платеж
-		for _, payment := range schedule {
-			if payment.Status == model.PaymentStatusPending && payment.DueDate.Before(time.Now()) {
-				// Пытаемся обработать платеж
-				if err := s.ProcessPayment(credit.ID, payment.PaymentNumber); err != nil {
-					// Если не удалось обработать платеж, обновляем статус кредита
-					credit.Status = model.CreditStatusOverdue
-					if err := s.creditRepo.Update(context.Background(), &credit); err != nil {
-						return fmt.Errorf("failed to update credit status: %v", err)
-					}
+			return changes, fmt.Errorf("failed to get payment schedule for credit %d: %v", credit.ID, err)
+		}
+
+		previousRate := credit.InterestRate
+		fromNumber, recalculated, effectiveFrom := credit.Reprice(schedule, keyRate, now)
+		change := model.CreditRateHistory{
+			CreditID:      credit.ID,
+			KeyRate:       keyRate,
+			PreviousRate:  previousRate,
+			InterestRate:  credit.InterestRate,
+			EffectiveFrom: effectiveFrom,
+		}
+
+		// Новый график, ставка кредита, запись в истории ставок и документы сохраняются вместе
+		err = s.creditRepo.InTransaction(context.Background(), func(ctx context.Context) error {
+			// Если пересчитывать нечего (идет последний период), фиксируется только новая ставка
+			if len(recalculated) > 0 {
+				schedule, err = replaceSchedule(ctx, s.creditRepo, credit, schedule, fromNumber, recalculated)
+				if err != nil {
+					return err
 				}
-				break
+				change.MonthlyPayment = recalculated[0].TotalAmount
+				credit.ApplySchedule(schedule, now)
+			} else {
+				change.EffectiveFrom = now
+			}
+
+			if err := s.creditRepo.Update(ctx, credit); err != nil {
+				return fmt.Errorf("failed to update credit: %v", err)
+			}
+			if err := s.creditRepo.AddRateHistory(ctx, &change); err != nil {
+				return fmt.Errorf("failed to save rate history: %v", err)
 			}
+			if len(recalculated) > 0 {
+				return s.documents.GenerateDocuments(ctx, credit, schedule, now)
+			}
+			return nil
+		})
+		if err != nil {
+			return changes, fmt.Errorf("failed to reprice credit %d: %v", credit.ID, err)
 		}
+
+		changes = append(changes, CreditRateChange{Credit: *credit, Change: change})
 	}
 
-	return nil
+	return changes, nil
+}
+
+// getUserCredit получает кредит и проверяет, что он принадлежит пользователю
+func (s *creditService) getUserCredit(userID uint, creditID uint) (*model.Credit, error) {
+	credit, err := s.creditRepo.GetByID(context.Background(), creditID)
+	if err != nil {
+		return nil, err
+	}
+	if credit.UserID != userID {
+		return nil, ErrCreditNotOwned
+	}
+	return credit, nil
 }
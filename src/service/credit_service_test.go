@@ -0,0 +1,223 @@
+package service
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	testBorrowerID  uint = 1
+	testGuarantorID uint = 2
+)
+
+// creditFixture кредит с залогом и поручителем в базе в памяти
+type creditFixture struct {
+	creditRepo repository.CreditRepository
+	service    CreditService
+	documents  *documentRecorder
+	credit     *model.Credit
+}
+
+// documentRecorder запоминает версии графика, по которым сформированы документы
+type documentRecorder struct {
+	versions []int
+}
+
+func (r *documentRecorder) GenerateDocuments(ctx context.Context, credit *model.Credit, schedule []model.PaymentSchedule, date time.Time) error {
+	r.versions = append(r.versions, credit.ScheduleVersion)
+	return nil
+}
+
+// newCreditFixture выдает кредит на три месяца с датой начала start, переводит залог
+// в обременение и активирует поручительство
+func newCreditFixture(t *testing.T, start time.Time) *creditFixture {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// База в памяти существует, пока открыто ее единственное соединение
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("database handle: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(
+		&model.Account{},
+		&model.Transaction{},
+		&model.Credit{},
+		&model.PaymentSchedule{},
+		&model.CreditRateHistory{},
+		&model.ArchivedPayment{},
+		&model.Collateral{},
+		&model.Guarantor{},
+		&model.AutoDebitAccount{},
+		&model.AutoDebitAttempt{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	creditRepo := repository.CreditRepositoryInstance(db)
+	accountRepo := repository.AccountRepositoryInstance(db)
+	transactionRepo := repository.TransactionRepositoryInstance(db)
+	documents := &documentRecorder{}
+	service := CreditServiceInstance(
+		creditRepo,
+		accountRepo,
+		transactionRepo,
+		nil,
+		OverdueServiceInstance(creditRepo, transactionRepo, 0.1),
+		AutoDebitServiceInstance(repository.AutoDebitRepositoryInstance(db), creditRepo, accountRepo, transactionRepo),
+		documents,
+	)
+
+	account := &model.Account{UserID: testBorrowerID, Number: "40817810000000000001", Type: model.AccountTypeDebit, Balance: 1000000, IsActive: true}
+	if err := accountRepo.Create(context.Background(), account); err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+
+	credit := &model.Credit{
+		UserID:        testBorrowerID,
+		AccountID:     account.ID,
+		Amount:        300000,
+		Term:          3,
+		InterestRate:  12,
+		RateType:      model.CreditRateFixed,
+		RepaymentType: model.RepaymentTypeAnnuity,
+		Status:        model.CreditStatusActive,
+		StartDate:     start,
+		PaymentDay:    start.Day(),
+	}
+	schedule := credit.GeneratePaymentSchedule()
+	credit.ApplySchedule(schedule, time.Now())
+	credit.EndDate = schedule[len(schedule)-1].DueDate
+	if err := creditRepo.Create(context.Background(), credit); err != nil {
+		t.Fatalf("create credit: %v", err)
+	}
+	for i := range schedule {
+		schedule[i].CreditID = credit.ID
+	}
+	if err := creditRepo.CreatePaymentSchedule(context.Background(), schedule); err != nil {
+		t.Fatalf("create payment schedule: %v", err)
+	}
+
+	collateral := &model.Collateral{
+		ApplicationID:  1,
+		CreditID:       &credit.ID,
+		Type:           model.CollateralVehicle,
+		Description:    "Автомобиль",
+		AppraisedValue: 500000,
+		AppraisalDate:  start,
+		Status:         model.CollateralStatusPledged,
+	}
+	if err := creditRepo.AddCollateral(context.Background(), collateral); err != nil {
+		t.Fatalf("add collateral: %v", err)
+	}
+	guarantor := &model.Guarantor{ApplicationID: 1, CreditID: &credit.ID, UserID: testGuarantorID, Status: model.GuarantorStatusActive}
+	if err := creditRepo.AddGuarantor(context.Background(), guarantor); err != nil {
+		t.Fatalf("add guarantor: %v", err)
+	}
+
+	return &creditFixture{creditRepo: creditRepo, service: service, documents: documents, credit: credit}
+}
+
+// expectSecurityReleased проверяет, что кредит погашен, а залог и поручительство прекращены
+func (f *creditFixture) expectSecurityReleased(t *testing.T) {
+	t.Helper()
+
+	credit, err := f.creditRepo.GetByID(context.Background(), f.credit.ID)
+	if err != nil {
+		t.Fatalf("get credit: %v", err)
+	}
+	if credit.Status != model.CreditStatusPaid {
+		t.Fatalf("credit status = %s, want %s", credit.Status, model.CreditStatusPaid)
+	}
+
+	security, err := f.creditRepo.GetCreditSecurity(context.Background(), f.credit.ID)
+	if err != nil {
+		t.Fatalf("get credit security: %v", err)
+	}
+	if len(security.Collateral) != 1 || len(security.Guarantors) != 1 {
+		t.Fatalf("credit security = %+v, want one collateral and one guarantor", security)
+	}
+	if collateral := security.Collateral[0]; collateral.Status != model.CollateralStatusReleased || collateral.ReleasedAt == nil {
+		t.Errorf("collateral status = %s, released at %v, want released", collateral.Status, collateral.ReleasedAt)
+	}
+	if guarantor := security.Guarantors[0]; guarantor.Status != model.GuarantorStatusReleased || guarantor.ReleasedAt == nil {
+		t.Errorf("guarantor status = %s, released at %v, want released", guarantor.Status, guarantor.ReleasedAt)
+	}
+}
+
+func TestEarlyRepaymentReleasesSecurity(t *testing.T) {
+	f := newCreditFixture(t, time.Now())
+
+	quote, err := f.service.GetPayoffQuote(testBorrowerID, f.credit.ID)
+	if err != nil {
+		t.Fatalf("GetPayoffQuote: %v", err)
+	}
+	if _, _, err := f.service.EarlyRepayment(testBorrowerID, f.credit.ID, quote.PayoffAmount, model.EarlyRepaymentReduceTerm); err != nil {
+		t.Fatalf("EarlyRepayment: %v", err)
+	}
+
+	f.expectSecurityReleased(t)
+}
+
+func TestProcessPaymentReleasesSecurity(t *testing.T) {
+	f := newCreditFixture(t, time.Now())
+
+	for number := 1; number <= f.credit.Term; number++ {
+		if err := f.service.ProcessPayment(f.credit.ID, number); err != nil {
+			t.Fatalf("ProcessPayment #%d: %v", number, err)
+		}
+	}
+
+	f.expectSecurityReleased(t)
+}
+
+func TestAutoDebitReleasesSecurity(t *testing.T) {
+	// Все платежи по графику уже просрочены и списываются автоматически
+	f := newCreditFixture(t, time.Now().AddDate(0, -3, -1))
+
+	attempts, err := f.service.ProcessOverduePayments()
+	if err != nil {
+		t.Fatalf("ProcessOverduePayments: %v", err)
+	}
+	if len(attempts) != f.credit.Term {
+		t.Fatalf("auto-debit attempts = %d, want %d", len(attempts), f.credit.Term)
+	}
+
+	f.expectSecurityReleased(t)
+}
+
+func TestEarlyRepaymentArchivesSchedule(t *testing.T) {
+	f := newCreditFixture(t, time.Now())
+
+	credit, _, err := f.service.EarlyRepayment(testBorrowerID, f.credit.ID, 100000, model.EarlyRepaymentReducePayment)
+	if err != nil {
+		t.Fatalf("EarlyRepayment: %v", err)
+	}
+	if credit.ScheduleVersion != f.credit.ScheduleVersion+1 {
+		t.Fatalf("schedule version = %d, want %d", credit.ScheduleVersion, f.credit.ScheduleVersion+1)
+	}
+
+	archived, err := f.creditRepo.GetArchivedSchedule(context.Background(), f.credit.ID, f.credit.ScheduleVersion)
+	if err != nil {
+		t.Fatalf("get archived schedule: %v", err)
+	}
+	if len(archived) != f.credit.Term {
+		t.Errorf("archived payments = %d, want %d", len(archived), f.credit.Term)
+	}
+	if len(f.documents.versions) != 1 || f.documents.versions[0] != credit.ScheduleVersion {
+		t.Errorf("documents generated for versions %v, want [%d]", f.documents.versions, credit.ScheduleVersion)
+	}
+}
@@ -164,6 +164,18 @@ func (s *ExternalService) SendEmail(to, subject, body string) error {
 	return nil
 }
 
+// SendRateChangeNotification уведомляет заемщика о новой ставке и платеже по кредиту
+func (s *ExternalService) SendRateChangeNotification(email string, creditID uint, rate, payment float64, effectiveFrom time.Time) error {
+	subject := fmt.Sprintf("Изменение ставки по кредиту #%d", creditID)
+	body := fmt.Sprintf(`
+		<h1>Изменение ставки по кредиту</h1>
+		<p>В связи с изменением ключевой ставки Банка России ставка по кредиту #%d составит %.2f%% годовых с %s.</p>
+		<p>Новый ежемесячный платеж: %.2f ₽</p>
+	`, creditID, rate, effectiveFrom.Format("02.01.2006"), payment)
+
+	return s.SendEmail(email, subject, body)
+}
+
 // SendPaymentNotification отправляет уведомление о платеже
 func (s *ExternalService) SendPaymentNotification(email, paymentType string, amount float64) error {
 	subject := fmt.Sprintf("Уведомление о платеже - %s", paymentType)
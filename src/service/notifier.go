@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Notifier доставляет клиенту сообщения, например одноразовые коды подтверждения
+type Notifier interface {
+	Notify(to, subject, body string) error
+}
+
+// emailNotifier отправляет сообщения по email через ExternalService
+type emailNotifier struct {
+	externalService *ExternalService
+}
+
+// EmailNotifierInstance создает отправку сообщений по email
+func EmailNotifierInstance(externalService *ExternalService) Notifier {
+	return &emailNotifier{externalService: externalService}
+}
+
+func (n *emailNotifier) Notify(to, subject, body string) error {
+	return n.externalService.SendEmail(to, subject, body)
+}
+
+// logNotifier записывает в лог приложения только факт отправки: получателя и тему.
+// Текст сообщения с одноразовым кодом не выводится. Используется при разработке.
+type logNotifier struct{}
+
+// LogNotifierInstance создает запись уведомлений в лог без текста сообщения
+func LogNotifierInstance() Notifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) Notify(to, subject, body string) error {
+	logrus.WithFields(logrus.Fields{
+		"to":      to,
+		"subject": subject,
+	}).Info("Уведомление не доставлено: доставка отключена (OTP_NOTIFIER=log)")
+	return nil
+}
+
+// fileNotifier дописывает сообщения в локальный файл.
+// Используется при разработке вместо реальной доставки.
+type fileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+// FileNotifierInstance создает отправку сообщений в файл path
+func FileNotifierInstance(path string) Notifier {
+	return &fileNotifier{path: path}
+}
+
+func (n *fileNotifier) Notify(to, subject, body string) error {
+	line := fmt.Sprintf("%s to=%s subject=%q body=%q\n", time.Now().Format(time.RFC3339), to, subject, body)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening notification file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(line); err != nil {
+		return fmt.Errorf("error writing notification: %v", err)
+	}
+	return nil
+}
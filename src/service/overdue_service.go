@@ -0,0 +1,94 @@
+package service
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"context"
+	"fmt"
+	"time"
+)
+
+// OverdueService ведет просроченную задолженность по кредитам: считает дни просрочки
+// по каждому платежу, начисляет неустойку и проводит начисления транзакциями
+type OverdueService interface {
+	AccrueOverdue(now time.Time) error
+	AccrueCredit(credit *model.Credit, schedule []model.PaymentSchedule, now time.Time) error
+}
+
+type overdueService struct {
+	creditRepo      repository.CreditRepository
+	transactionRepo repository.TransactionRepository
+	// Ставка неустойки, процентов годовых
+	penaltyRate float64
+}
+
+func OverdueServiceInstance(
+	creditRepo repository.CreditRepository,
+	transactionRepo repository.TransactionRepository,
+	penaltyRate float64,
+) OverdueService {
+	return &overdueService{
+		creditRepo:      creditRepo,
+		transactionRepo: transactionRepo,
+		penaltyRate:     model.CapPenaltyRate(penaltyRate),
+	}
+}
+
+// AccrueOverdue начисляет неустойку по всем кредитам с наступившим сроком платежа
+func (s *overdueService) AccrueOverdue(now time.Time) error {
+	credits, err := s.creditRepo.GetOverdueCredits(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get overdue credits: %v", err)
+	}
+
+	for i := range credits {
+		credit := &credits[i]
+		schedule, err := s.creditRepo.GetPaymentSchedule(context.Background(), credit.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get payment schedule for credit %d: %v", credit.ID, err)
+		}
+		if err := s.AccrueCredit(credit, schedule, now); err != nil {
+			return fmt.Errorf("failed to accrue penalty for credit %d: %v", credit.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// AccrueCredit доначисляет неустойку по просроченным платежам кредита на дату now
+// и обновляет просроченную задолженность и статус кредита
+func (s *overdueService) AccrueCredit(credit *model.Credit, schedule []model.PaymentSchedule, now time.Time) error {
+	for i := range schedule {
+		payment := &schedule[i]
+		if !payment.IsDue(now) {
+			continue
+		}
+
+		penalty := payment.AccruePenalty(s.penaltyRate, now)
+		if err := s.creditRepo.UpdatePaymentSchedule(context.Background(), payment); err != nil {
+			return fmt.Errorf("failed to update payment schedule: %v", err)
+		}
+		if penalty == 0 {
+			continue
+		}
+
+		transaction := &model.Transaction{
+			Type:          model.TransactionTypePenalty,
+			FromAccountID: credit.AccountID,
+			Amount:        penalty,
+			Description: fmt.Sprintf("Неустойка по кредиту #%d, платеж #%d, просрочка %d дн.",
+				credit.ID, payment.PaymentNumber, payment.DaysPastDue),
+			Status: model.TransactionStatusCompleted,
+		}
+		if err := s.transactionRepo.Create(context.Background(), transaction); err != nil {
+			return fmt.Errorf("failed to create penalty transaction: %v", err)
+		}
+	}
+
+	credit.ApplySchedule(schedule, now)
+	if err := s.creditRepo.Update(context.Background(), credit); err != nil {
+		return fmt.Errorf("failed to update credit: %v", err)
+	}
+
+	return nil
+}
@@ -8,326 +8,281 @@ import (
 	"FinanceGolang/src/database"
 	"FinanceGolang/src/model"
 	"FinanceGolang/src/repository"
+
+	"github.com/sirupsen/logrus"
 )
 
 type Scheduler struct {
-	creditRepo      repository.CreditRepository
-	accountRepo     repository.AccountRepository
-	transactionRepo repository.TransactionRepository
-	userRepo        repository.UserRepository
-	keyRateService  *ExternalService
+	creditService     CreditService
+	creditLineService CreditLineService
+	collectionService CollectionService
+	bureauService     CreditBureauService
+	creditRepo        repository.CreditRepository
+	accountRepo       repository.AccountRepository
+	userRepo          repository.UserRepository
+	keyRateService    *ExternalService
+	// Интервалы проверки платежей, ключевой ставки, выписок по кредитным линиям
+	// и выгрузки кредитной истории
+	paymentsInterval     time.Duration
+	keyRateInterval      time.Duration
+	statementsInterval   time.Duration
+	bureauExportInterval time.Duration
 }
 
 func NewScheduler(
+	creditService CreditService,
+	creditLineService CreditLineService,
+	collectionService CollectionService,
+	bureauService CreditBureauService,
 	creditRepo repository.CreditRepository,
 	accountRepo repository.AccountRepository,
-	transactionRepo repository.TransactionRepository,
 	keyRateService *ExternalService,
+	paymentsInterval time.Duration,
+	keyRateInterval time.Duration,
+	statementsInterval time.Duration,
+	bureauExportInterval time.Duration,
 ) *Scheduler {
 	return &Scheduler{
-		creditRepo:      creditRepo,
-		accountRepo:     accountRepo,
-		transactionRepo: transactionRepo,
-		userRepo:        repository.UserRepositoryInstance(database.DB),
-		keyRateService:  keyRateService,
+		creditService:        creditService,
+		creditLineService:    creditLineService,
+		collectionService:    collectionService,
+		bureauService:        bureauService,
+		creditRepo:           creditRepo,
+		accountRepo:          accountRepo,
+		userRepo:             repository.UserRepositoryInstance(database.DB),
+		keyRateService:       keyRateService,
+		paymentsInterval:     paymentsInterval,
+		keyRateInterval:      keyRateInterval,
+		statementsInterval:   statementsInterval,
+		bureauExportInterval: bureauExportInterval,
 	}
 }
 
 // Start запускает шедулер
 func (s *Scheduler) Start() {
-	// Проверка платежей каждые 12 часов
-	go s.checkPayments()
+	// Пропущенные дни просрочки доначисляются при следующей проверке
+	go s.runPeriodically(s.paymentsInterval, "обработке платежей по кредитам", s.CheckPayments)
+	go s.runPeriodically(s.keyRateInterval, "пересмотре плавающих ставок", s.CheckKeyRate)
+	go s.runPeriodically(s.statementsInterval, "обработке выписок по кредитным линиям", func() error {
+		_, err := s.ProcessStatements()
+		return err
+	})
+	go s.runPeriodically(s.bureauExportInterval, "выгрузке кредитной истории", func() error {
+		_, err := s.bureauService.GenerateExport(time.Now())
+		return err
+	})
 }
 
-// checkPayments проверяет и обрабатывает платежи по кредитам
-func (s *Scheduler) checkPayments() {
-	ticker := time.NewTicker(12 * time.Hour)
+// runPeriodically выполняет задачу при запуске и далее с заданным интервалом
+func (s *Scheduler) runPeriodically(interval time.Duration, name string, job func() error) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		credits, err := s.creditRepo.GetActiveCredits(context.Background())
-		if err != nil {
-			fmt.Printf("Ошибка при получении кредитов: %v\n", err)
-			continue
-		}
-
-		for _, credit := range credits {
-			if credit.Status == model.CreditStatusActive {
-				s.processCreditPayment(&credit)
-			}
+	for {
+		if err := job(); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"job":   name,
+				"error": err.Error(),
+			}).Error("Ошибка фоновой задачи")
 		}
+		<-ticker.C
 	}
 }
 
-// processCreditPayment обрабатывает платеж по кредиту
-func (s *Scheduler) processCreditPayment(credit *model.Credit) {
-	// Получаем график платежей
-	schedule, err := s.creditRepo.GetCreditsByDateRange(context.Background(), credit.StartDate, credit.EndDate)
-	if err != nil {
-		fmt.Printf("Ошибка при получении графика платежей: %v\n", err)
-		return
-	}
-
-	now := time.Now()
-	for _, payment := range schedule {
-		// Если платеж просрочен и не оплачен
-		if payment.NextPayment.Before(now) && payment.Status == model.CreditStatusActive {
-			// Проверяем баланс счета
-			account, err := s.accountRepo.GetByID(context.Background(), credit.AccountID)
-			if err != nil {
-				fmt.Printf("Ошибка при получении счета: %v\n", err)
-				continue
-			}
-
-			monthlyPayment := payment.CalculateMonthlyPayment()
-
-			// Если на счету достаточно средств
-			if account.Balance >= monthlyPayment {
-				// Списание средств
-				account.Balance -= monthlyPayment
-				if err := s.accountRepo.Update(context.Background(), account); err != nil {
-					fmt.Printf("Ошибка при списании средств: %v\n", err)
-					continue
-				}
-
-				// Обновление статуса платежа
-				payment.Status = model.CreditStatusPaid
-				now := time.Now()
-				payment.LastPayment = now
-
-				// Создаем транзакцию о платеже
-				transaction := &model.Transaction{
-					Type:          model.TransactionTypePayment,
-					FromAccountID: credit.AccountID,
-					Amount:        monthlyPayment,
-					Description:   fmt.Sprintf("Платеж по кредиту #%d", credit.ID),
-					Status:        model.TransactionStatusCompleted,
-				}
-				if err := s.transactionRepo.Create(context.Background(), transaction); err != nil {
-					fmt.Printf("Ошибка при создании транзакции: %v\n", err)
-					continue
-				}
-
-				// Отправка уведомления
-				user, err := s.userRepo.GetByID(context.Background(), account.UserID)
-				if err != nil {
-					fmt.Printf("Ошибка при получении пользователя: %v\n", err)
-					continue
-				}
-
-				if user == nil {
-					fmt.Printf("Пользователь не найден\n")
-					continue
-				}
-
-				if err := s.keyRateService.SendPaymentNotification(
-					user.Email,
-					"Платеж по кредиту",
-					monthlyPayment,
-				); err != nil {
-					fmt.Printf("Ошибка при отправке уведомления: %v\n", err)
-				}
-			} else {
-				// Начисление штрафа за просрочку
-				penalty := monthlyPayment * 0.1
-				payment.OverdueAmount += penalty
-				payment.Status = model.CreditStatusOverdue
-
-				// Создаем транзакцию о штрафе
-				transaction := &model.Transaction{
-					Type:          model.TransactionTypePayment,
-					FromAccountID: credit.AccountID,
-					Amount:        penalty,
-					Description:   fmt.Sprintf("Штраф за просрочку платежа по кредиту #%d", credit.ID),
-					Status:        model.TransactionStatusCompleted,
-				}
-				if err := s.transactionRepo.Create(context.Background(), transaction); err != nil {
-					fmt.Printf("Ошибка при создании транзакции: %v\n", err)
-					continue
-				}
-
-				// Отправка уведомления о просрочке
-				user, err := s.userRepo.GetByID(context.Background(), account.UserID)
-				if err != nil {
-					fmt.Printf("Ошибка при получении пользователя: %v\n", err)
-					continue
-				}
-
-				if user == nil {
-					fmt.Printf("Пользователь не найден\n")
-					continue
-				}
-
-				if err := s.keyRateService.SendPaymentNotification(
-					user.Email,
-					"Просрочка платежа по кредиту",
-					monthlyPayment+penalty,
-				); err != nil {
-					fmt.Printf("Ошибка при отправке уведомления: %v\n", err)
-				}
-			}
-		}
-	}
+// sendPaymentOverdueNotification отправляет уведомление о просрочке платежа
+func (s *Scheduler) sendPaymentOverdueNotification(email string, creditID uint, amount float64) error {
+	return s.keyRateService.SendPaymentNotification(
+		email,
+		fmt.Sprintf("Просрочка платежа по кредиту #%d", creditID),
+		amount,
+	)
 }
 
-// ProcessPayment обрабатывает платеж по кредиту
-func (s *Scheduler) ProcessPayment(creditID uint, paymentNumber int) error {
-	credit, err := s.creditRepo.GetByID(context.Background(), creditID)
-	if err != nil {
-		return fmt.Errorf("failed to get credit: %v", err)
-	}
-
-	account, err := s.accountRepo.GetByID(context.Background(), credit.AccountID)
+// CheckPayments начисляет неустойку, списывает платежи с наступившим сроком со счетов кредитов
+// и резервных счетов, уведомляет клиентов о каждой попытке списания и оставшейся просрочке
+// и ведет взыскание по просроченным кредитам
+func (s *Scheduler) CheckPayments() error {
+	attempts, err := s.creditService.ProcessOverduePayments()
+	s.sendAutoDebitNotifications(attempts)
 	if err != nil {
-		return fmt.Errorf("failed to get account: %v", err)
+		return fmt.Errorf("failed to process overdue payments: %v", err)
 	}
 
-	// Получаем график платежей
-	schedule, err := s.creditRepo.GetPaymentSchedule(context.Background(), creditID)
+	credits, err := s.creditRepo.GetOverdueCredits(context.Background())
 	if err != nil {
-		return fmt.Errorf("failed to get payment schedule: %v", err)
+		return fmt.Errorf("failed to get overdue credits: %v", err)
 	}
 
-	// Находим нужный платеж
-	var payment *model.PaymentSchedule
-	for _, p := range schedule {
-		if p.PaymentNumber == paymentNumber {
-			payment = &p
-			break
+	for _, credit := range credits {
+		if credit.Status != model.CreditStatusOverdue {
+			continue
 		}
-	}
 
-	if payment == nil {
-		return fmt.Errorf("payment not found")
-	}
+		account, err := s.accountRepo.GetByID(context.Background(), credit.AccountID)
+		if err != nil {
+			return fmt.Errorf("failed to get account: %v", err)
+		}
 
-	// Проверяем достаточно ли средств
-	if account.Balance < payment.Amount {
-		return fmt.Errorf("insufficient funds")
-	}
+		user, err := s.userRepo.GetByID(context.Background(), account.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %v", err)
+		}
 
-	// Списываем платеж
-	account.Balance -= payment.Amount
-	if err := s.accountRepo.Update(context.Background(), account); err != nil {
-		return fmt.Errorf("failed to update account: %v", err)
+		if err := s.sendPaymentOverdueNotification(user.Email, credit.ID, credit.OverdueAmount); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"credit_id": credit.ID,
+				"error":     err.Error(),
+			}).Error("Не удалось отправить уведомление")
+		}
 	}
 
-	// Обновляем статус платежа
-	payment.Status = "COMPLETED"
-	if err := s.creditRepo.UpdatePaymentSchedule(context.Background(), payment); err != nil {
-		return fmt.Errorf("failed to update payment schedule: %v", err)
-	}
+	return s.processCollections()
+}
 
-	// Создаем транзакцию
-	transaction := &model.Transaction{
-		FromAccountID: credit.AccountID,
-		Amount:        payment.Amount,
-		Type:          "CREDIT_PAYMENT",
-		Status:        "COMPLETED",
-		Description:   fmt.Sprintf("Платеж по кредиту #%d", credit.ID),
-	}
+// sendAutoDebitNotifications уведомляет заемщиков о попытках списания платежей по кредитам
+func (s *Scheduler) sendAutoDebitNotifications(attempts []model.AutoDebitAttempt) {
+	for _, attempt := range attempts {
+		paymentType := fmt.Sprintf("Списание платежа #%d по кредиту #%d со счета #%d",
+			attempt.PaymentNumber, attempt.CreditID, attempt.AccountID)
+		amount := attempt.Debited
+		if attempt.Status == model.AutoDebitStatusFailed {
+			paymentType = fmt.Sprintf("Недостаточно средств на счете #%d для платежа #%d по кредиту #%d",
+				attempt.AccountID, attempt.PaymentNumber, attempt.CreditID)
+			amount = attempt.Requested
+		}
 
-	if err := s.transactionRepo.Create(context.Background(), transaction); err != nil {
-		return fmt.Errorf("failed to create transaction: %v", err)
+		user, err := s.userRepo.GetByID(context.Background(), attempt.UserID)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"credit_id": attempt.CreditID,
+				"error":     err.Error(),
+			}).Error("Не удалось получить пользователя для уведомления")
+			continue
+		}
+		if err := s.keyRateService.SendPaymentNotification(user.Email, paymentType, amount); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"credit_id": attempt.CreditID,
+				"error":     err.Error(),
+			}).Error("Не удалось отправить уведомление")
+		}
 	}
+}
 
-	// Получаем пользователя для уведомления
-	user, err := s.userRepo.GetByID(context.Background(), account.UserID)
-	if err != nil {
-		return fmt.Errorf("failed to get user: %v", err)
-	}
+// processCollections повышает стадии взыскания и отправляет заемщикам напоминание
+// и требование о погашении просроченной задолженности
+func (s *Scheduler) processCollections() error {
+	cases, err := s.collectionService.ProcessCollections(time.Now())
+	for _, collectionCase := range cases {
+		var paymentType string
+		switch collectionCase.Stage {
+		case model.CollectionStageReminder:
+			paymentType = fmt.Sprintf("Напоминание о просроченном платеже по кредиту #%d", collectionCase.CreditID)
+		case model.CollectionStageDemand:
+			paymentType = fmt.Sprintf("Требование о погашении просроченной задолженности по кредиту #%d", collectionCase.CreditID)
+		default:
+			continue
+		}
 
-	if user == nil {
-		return fmt.Errorf("user not found")
+		user, err := s.userRepo.GetByID(context.Background(), collectionCase.UserID)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"credit_id": collectionCase.CreditID,
+				"error":     err.Error(),
+			}).Error("Не удалось получить пользователя для уведомления")
+			continue
+		}
+		if err := s.keyRateService.SendPaymentNotification(user.Email, paymentType, collectionCase.OverdueAmount); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"credit_id": collectionCase.CreditID,
+				"error":     err.Error(),
+			}).Error("Не удалось отправить уведомление")
+		}
 	}
-
-	// Отправляем уведомление
-	if err := s.keyRateService.SendPaymentNotification(
-		user.Email,
-		"Платеж по кредиту",
-		payment.Amount,
-	); err != nil {
-		return fmt.Errorf("failed to send notification: %v", err)
+	if err != nil {
+		return fmt.Errorf("failed to process collections: %v", err)
 	}
 
 	return nil
 }
 
-// sendPaymentOverdueNotification отправляет уведомление о просрочке платежа
-func (s *Scheduler) sendPaymentOverdueNotification(email string, creditID uint, amount float64) error {
-	return s.keyRateService.SendPaymentNotification(
-		email,
-		"Просрочка платежа по кредиту",
-		amount,
-	)
-}
-
-// CheckPayments запускает проверку платежей вручную
-func (s *Scheduler) CheckPayments() error {
-	// Получаем все активные кредиты
-	credits, err := s.creditRepo.GetActiveCredits(context.Background())
+// CheckKeyRate получает ключевую ставку ЦБ РФ и при ее изменении пересматривает ставки
+// кредитов с плавающей ставкой, уведомляя заемщиков о новом платеже
+func (s *Scheduler) CheckKeyRate() error {
+	keyRate, err := s.keyRateService.GetKeyRate()
 	if err != nil {
-		return fmt.Errorf("failed to get active credits: %v", err)
+		return fmt.Errorf("failed to get key rate: %v", err)
 	}
 
-	for _, credit := range credits {
-		// Получаем счет
-		account, err := s.accountRepo.GetByID(context.Background(), credit.AccountID)
-		if err != nil {
-			return fmt.Errorf("failed to get account: %v", err)
-		}
-
-		// Получаем пользователя
-		user, err := s.userRepo.GetByID(context.Background(), account.UserID)
+	changes, err := s.creditService.RepriceFloatingCredits(keyRate)
+	for _, change := range changes {
+		user, err := s.userRepo.GetByID(context.Background(), change.Credit.UserID)
 		if err != nil {
-			return fmt.Errorf("failed to get user: %v", err)
+			logrus.WithFields(logrus.Fields{
+				"credit_id": change.Credit.ID,
+				"error":     err.Error(),
+			}).Error("Не удалось получить пользователя для уведомления")
+			continue
 		}
 
-		if user == nil {
-			return fmt.Errorf("user not found")
+		if err := s.keyRateService.SendRateChangeNotification(
+			user.Email,
+			change.Credit.ID,
+			change.Change.InterestRate,
+			change.Change.MonthlyPayment,
+			change.Change.EffectiveFrom,
+		); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"credit_id": change.Credit.ID,
+				"error":     err.Error(),
+			}).Error("Не удалось отправить уведомление")
 		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reprice floating rate credits: %v", err)
+	}
 
-		// Проверяем, нужно ли списать платеж
-		if time.Now().After(credit.NextPayment) {
-			// Получаем график платежей
-			schedule, err := s.creditRepo.GetPaymentSchedule(context.Background(), credit.ID)
-			if err != nil {
-				return fmt.Errorf("failed to get payment schedule: %v", err)
-			}
-
-			// Находим следующий платеж
-			var nextPayment *model.PaymentSchedule
-			for _, payment := range schedule {
-				if payment.Status == "PENDING" {
-					nextPayment = &payment
-					break
-				}
-			}
+	return nil
+}
 
-			if nextPayment != nil {
-				// Проверяем достаточно ли средств
-				if account.Balance >= nextPayment.Amount {
-					// Списываем платеж
-					if err := s.ProcessPayment(credit.ID, nextPayment.PaymentNumber); err != nil {
-						return fmt.Errorf("failed to process payment: %v", err)
-					}
-				} else {
-					// Отмечаем платеж как просроченный
-					nextPayment.Status = "OVERDUE"
-					if err := s.creditRepo.UpdatePaymentSchedule(context.Background(), nextPayment); err != nil {
-						return fmt.Errorf("failed to update payment schedule: %v", err)
-					}
+// ProcessStatements формирует выписки по кредитным линиям, подводит итог по выпискам с прошедшей
+// датой платежа и уведомляет клиентов о минимальном платеже и его просрочке
+func (s *Scheduler) ProcessStatements() ([]model.CreditStatement, error) {
+	statements, err := s.creditLineService.ProcessStatements(time.Now())
+	for _, statement := range statements {
+		var paymentType string
+		var amount float64
+		switch {
+		case statement.Status == model.CreditStatementStatusOpen && statement.Debt > 0:
+			paymentType = fmt.Sprintf("Выписка по кредитной линии #%d: минимальный платеж до %s",
+				statement.CreditLineID, statement.DueDate.Format("02.01.2006"))
+			amount = statement.MinimumPayment
+		case statement.Status == model.CreditStatementStatusOverdue:
+			paymentType = fmt.Sprintf("Просрочка минимального платежа по кредитной линии #%d", statement.CreditLineID)
+			amount = statement.MinimumPayment - statement.PaidAmount
+		default:
+			continue
+		}
 
-					// Отправляем уведомление пользователю
-					if err := s.sendPaymentOverdueNotification(user.Email, credit.ID, nextPayment.Amount); err != nil {
-						return fmt.Errorf("failed to send notification: %v", err)
-					}
-				}
-			}
+		user, err := s.userRepo.GetByID(context.Background(), statement.UserID)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"credit_line_id": statement.CreditLineID,
+				"error":          err.Error(),
+			}).Error("Не удалось получить пользователя для уведомления")
+			continue
+		}
+		if err := s.keyRateService.SendPaymentNotification(user.Email, paymentType, amount); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"credit_line_id": statement.CreditLineID,
+				"error":          err.Error(),
+			}).Error("Не удалось отправить уведомление")
 		}
 	}
+	if err != nil {
+		return statements, fmt.Errorf("failed to process credit line statements: %v", err)
+	}
 
-	return nil
+	return statements, nil
 }
 
 // GetAllCredits возвращает список всех кредитов
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"FinanceGolang/src/model"
+	"FinanceGolang/src/repository"
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// scoringPeriodDays период, за который оцениваются доходы и остатки на счетах
+const scoringPeriodDays = 90
+
+// ScoringService рассчитывает скоринговый балл заявки по данным клиента в системе
+// и кредитному отчету из бюро кредитных историй
+type ScoringService interface {
+	ScoreApplication(application *model.CreditApplication) (*model.CreditScore, error)
+}
+
+type scoringService struct {
+	accountRepo     repository.AccountRepository
+	transactionRepo repository.TransactionRepository
+	creditRepo      repository.CreditRepository
+	userRepo        repository.UserRepository
+	bureauClient    CreditBureauClient
+	thresholds      model.ScoreThresholds
+	// Ставка, по которой оценивается платеж по новому кредиту до выдачи
+	stressRate float64
+}
+
+func ScoringServiceInstance(
+	accountRepo repository.AccountRepository,
+	transactionRepo repository.TransactionRepository,
+	creditRepo repository.CreditRepository,
+	userRepo repository.UserRepository,
+	bureauClient CreditBureauClient,
+	thresholds model.ScoreThresholds,
+	stressRate float64,
+) ScoringService {
+	return &scoringService{
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		creditRepo:      creditRepo,
+		userRepo:        userRepo,
+		bureauClient:    bureauClient,
+		thresholds:      thresholds,
+		stressRate:      stressRate,
+	}
+}
+
+// ScoreApplication оценивает заявку. Платежи и просрочки по кредитам в других банках из кредитного
+// отчета учитываются вместе с кредитами в системе. Если отчет получить не удалось, скоринг
+// выполняется по данным системы, а заявка не одобряется автоматически.
+func (s *scoringService) ScoreApplication(application *model.CreditApplication) (*model.CreditScore, error) {
+	now := time.Now()
+	since := now.AddDate(0, 0, -scoringPeriodDays)
+
+	accounts, err := s.accountRepo.GetByUserID(context.Background(), application.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user accounts: %v", err)
+	}
+
+	// Собираем операции по всем счетам клиента без повторов
+	own := make(map[uint]bool, len(accounts))
+	balance := 0.0
+	for _, account := range accounts {
+		own[account.ID] = true
+		balance += account.Balance
+	}
+	seen := make(map[uint]bool)
+	var transactions []model.Transaction
+	for _, account := range accounts {
+		accountTransactions, err := s.transactionRepo.GetByAccountID(context.Background(), account.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account transactions: %v", err)
+		}
+		for _, transaction := range accountTransactions {
+			if seen[transaction.ID] || transaction.Status != model.TransactionStatusCompleted ||
+				transaction.CreatedAt.Before(since) {
+				continue
+			}
+			seen[transaction.ID] = true
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	credits, err := s.creditRepo.GetCreditsByUserID(context.Background(), application.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user credits: %v", err)
+	}
+
+	income := monthlyIncome(transactions, own)
+	payments, lateCount, err := s.creditObligations(credits, now)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.bureauReport(application.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if report != nil {
+		payments += report.MonthlyPayments
+		lateCount += report.LatePayments
+	}
+	newCredit := model.Credit{
+		Amount:        application.Amount,
+		Term:          application.Term,
+		InterestRate:  s.stressRate,
+		RepaymentType: application.RepaymentType,
+	}
+	payments += newCredit.CalculateMonthlyPayment()
+
+	factors := []model.ScoreFactor{
+		scoreIncome(income),
+		scoreDebtToIncome(payments, income),
+		scoreOverdueHistory(lateCount),
+		scoreAccountAge(accounts, now),
+		scoreBalanceVolatility(dailyBalances(transactions, own, balance, now)),
+	}
+
+	score := model.NewCreditScore(factors, s.thresholds)
+	score.ApplyBureauReport(report)
+	return score, nil
+}
+
+// bureauReport получает кредитный отчет заемщика. Недоступность бюро не прерывает скоринг:
+// ошибка записывается в лог, отчет не возвращается.
+func (s *scoringService) bureauReport(userID uint) (*model.CreditBureauReport, error) {
+	user, err := s.userRepo.GetByID(context.Background(), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+
+	report, err := s.bureauClient.GetReport(user)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		}).Warn("Кредитный отчет не получен")
+		return nil, nil
+	}
+	return report, nil
+}
+
+// creditObligations возвращает сумму ближайших платежей по непогашенным кредитам
+// и число платежей, внесенных с опозданием или просроченных
+func (s *scoringService) creditObligations(credits []model.Credit, now time.Time) (float64, int, error) {
+	payments := 0.0
+	late := 0
+	for _, credit := range credits {
+		schedule, err := s.creditRepo.GetPaymentSchedule(context.Background(), credit.ID)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get payment schedule: %v", err)
+		}
+
+		open := credit.Status == model.CreditStatusActive || credit.Status == model.CreditStatusOverdue
+		nextFound := false
+		for _, payment := range schedule {
+			if payment.IsDue(now) || (payment.PaidAt != nil && payment.PaidAt.After(payment.DueDate.AddDate(0, 0, 1))) {
+				late++
+			}
+			// Нагрузка — полный ближайший платеж по действующему графику, в том числе
+			// после реструктуризации или досрочного погашения
+			if open && !nextFound && !payment.IsPaid() {
+				payments += payment.TotalAmount
+				nextFound = true
+			}
+		}
+		if open && !nextFound {
+			payments += credit.MonthlyPayment
+		}
+	}
+	return payments, late, nil
+}
+
+// monthlyIncome рассчитывает средний месячный приход на счета клиента: пополнения
+// и входящие переводы с чужих счетов
+func monthlyIncome(transactions []model.Transaction, own map[uint]bool) float64 {
+	total := 0.0
+	for _, transaction := range transactions {
+		if !own[transaction.ToAccountID] || own[transaction.FromAccountID] {
+			continue
+		}
+		if transaction.Type == model.TransactionTypeDeposit || transaction.Type == model.TransactionTypeTransfer {
+			total += transaction.Amount
+		}
+	}
+	return total / (scoringPeriodDays / 30)
+}
+
+// dailyBalances восстанавливает суммарный остаток на счетах клиента на конец каждого дня периода,
+// откатывая операции от текущего остатка
+func dailyBalances(transactions []model.Transaction, own map[uint]bool, balance float64, now time.Time) []float64 {
+	netByDay := make(map[int]float64)
+	for _, transaction := range transactions {
+		// Начисление неустойки не изменяет остаток на счете
+		if transaction.Type == model.TransactionTypePenalty {
+			continue
+		}
+		day := int(now.Sub(transaction.CreatedAt).Hours() / 24)
+		switch {
+		case own[transaction.ToAccountID] && !own[transaction.FromAccountID]:
+			netByDay[day] += transaction.Amount
+		case own[transaction.FromAccountID] && !own[transaction.ToAccountID]:
+			netByDay[day] -= transaction.Amount
+		}
+	}
+
+	balances := make([]float64, scoringPeriodDays)
+	for day := 0; day < scoringPeriodDays; day++ {
+		balances[day] = balance
+		balance -= netByDay[day]
+	}
+	return balances
+}
+
+func scoreIncome(income float64) model.ScoreFactor {
+	const maxPoints = 200
+	points := int(math.Min(income/100000, 1) * maxPoints)
+	return model.ScoreFactor{Factor: model.ScoreFactorIncome, Value: math.Round(income*100) / 100, Points: points, MaxPoints: maxPoints}
+}
+
+func scoreDebtToIncome(payments, income float64) model.ScoreFactor {
+	const maxPoints = 250
+	factor := model.ScoreFactor{Factor: model.ScoreFactorDebtToIncome, MaxPoints: maxPoints}
+	if income <= 0 {
+		factor.Value = -1
+		return factor
+	}
+
+	ratio := payments / income
+	factor.Value = math.Round(ratio*100) / 100
+	switch {
+	case ratio <= 0.3:
+		factor.Points = maxPoints
+	case ratio <= 0.5:
+		factor.Points = 150
+	case ratio <= 0.7:
+		factor.Points = 50
+	}
+	return factor
+}
+
+func scoreOverdueHistory(lateCount int) model.ScoreFactor {
+	const maxPoints = 250
+	factor := model.ScoreFactor{Factor: model.ScoreFactorOverdueHistory, Value: float64(lateCount), MaxPoints: maxPoints}
+	switch {
+	case lateCount == 0:
+		factor.Points = maxPoints
+	case lateCount <= 2:
+		factor.Points = 100
+	}
+	return factor
+}
+
+func scoreAccountAge(accounts []model.Account, now time.Time) model.ScoreFactor {
+	const maxPoints = 150
+	oldest := now
+	for _, account := range accounts {
+		if account.CreatedAt.Before(oldest) {
+			oldest = account.CreatedAt
+		}
+	}
+
+	months := now.Sub(oldest).Hours() / 24 / 30
+	factor := model.ScoreFactor{Factor: model.ScoreFactorAccountAge, Value: math.Floor(months), MaxPoints: maxPoints}
+	switch {
+	case months >= 24:
+		factor.Points = maxPoints
+	case months >= 12:
+		factor.Points = 100
+	case months >= 6:
+		factor.Points = 60
+	case months >= 1:
+		factor.Points = 20
+	}
+	return factor
+}
+
+// scoreBalanceVolatility оценивает коэффициент вариации дневных остатков
+func scoreBalanceVolatility(balances []float64) model.ScoreFactor {
+	const maxPoints = 150
+	factor := model.ScoreFactor{Factor: model.ScoreFactorBalanceVolatility, MaxPoints: maxPoints}
+
+	mean := 0.0
+	for _, balance := range balances {
+		mean += balance
+	}
+	mean /= float64(len(balances))
+	if mean <= 0 {
+		factor.Value = -1
+		return factor
+	}
+
+	variance := 0.0
+	for _, balance := range balances {
+		variance += (balance - mean) * (balance - mean)
+	}
+	cv := math.Sqrt(variance/float64(len(balances))) / mean
+	factor.Value = math.Round(cv*100) / 100
+	switch {
+	case cv <= 0.25:
+		factor.Points = maxPoints
+	case cv <= 0.5:
+		factor.Points = 100
+	case cv <= 1:
+		factor.Points = 50
+	}
+	return factor
+}